@@ -2,64 +2,166 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
-	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/GustavoNoronha0/gofinance-backend/money"
 	"github.com/gin-gonic/gin"
 )
 
+var errAccountTypeMismatch = errors.New("account type is different of category type")
+
 type createAccountRequest struct {
-	UserID      int32     `json:"user_id" binding:"required"`
+	WalletID    int32     `json:"wallet_id" binding:"required"`
 	CategoryID  int32     `json:"category_id" binding:"required"`
 	Title       string    `json:"title" binding:"required"`
-	Type        string    `json:"type" binding:"required"`
+	Type        string    `json:"type" binding:"required,oneof=credit debit"`
 	Description string    `json:"description" binding:"required"`
-	Value       int32     `json:"value" binding:"required"`
+	Amount      string    `json:"amount" binding:"required"`
+	Currency    string    `json:"currency" binding:"omitempty,len=3"`
 	Date        time.Time `json:"date" binding:"required"`
 }
 
-func (server *Server) createAccount(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
+type accountResponse struct {
+	ID          int32     `json:"id"`
+	UserID      int32     `json:"user_id"`
+	WalletID    int32     `json:"wallet_id"`
+	CategoryID  int32     `json:"category_id"`
+	Title       string    `json:"title"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Amount      string    `json:"amount"`
+	Currency    string    `json:"currency"`
+	Date        time.Time `json:"date"`
+	RecurringID *int32    `json:"recurring_id,omitempty"`
+	// InstallmentPlanID and InstallmentNumber are set on entries of an
+	// installment plan.
+	InstallmentPlanID *int32 `json:"installment_plan_id,omitempty"`
+	InstallmentNumber *int32 `json:"installment_number,omitempty"`
+	// GoalID is set on contributions to a savings goal.
+	GoalID    *int32    `json:"goal_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	rsp := accountResponse{
+		ID:          account.ID,
+		UserID:      account.UserID,
+		WalletID:    account.WalletID,
+		CategoryID:  account.CategoryID,
+		Title:       account.Title,
+		Type:        account.Type,
+		Description: account.Description,
+		Amount:      formatAmount(account.Amount, account.Currency),
+		Currency:    account.Currency,
+		Date:        account.Date,
+		CreatedAt:   account.CreatedAt,
 	}
+	if account.RecurringID.Valid {
+		rsp.RecurringID = &account.RecurringID.Int32
+	}
+	if account.InstallmentPlanID.Valid {
+		rsp.InstallmentPlanID = &account.InstallmentPlanID.Int32
+		rsp.InstallmentNumber = &account.InstallmentNumber.Int32
+	}
+	if account.GoalID.Valid {
+		rsp.GoalID = &account.GoalID.Int32
+	}
+	return rsp
+}
+
+func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
+	claims := authClaims(ctx)
 	var categoryId = req.CategoryID
 	var accountType = req.Type
 
-	category, err := server.store.GetCategory(ctx, categoryId)
-	if err != nil {
-		ctx.JSON(http.StatusNotFound, errorResponse(err))
-	}
+	var account db.Account
+	var notifications []db.Notification
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		category, err := q.GetCategory(ctx, db.GetCategoryParams{
+			ID:     categoryId,
+			UserID: claims.UserID,
+		})
+		if err != nil {
+			return err
+		}
+
+		var categoryTypeIsDifferentOfAccountType = category.Type != accountType
+		if categoryTypeIsDifferentOfAccountType {
+			return errAccountTypeMismatch
+		}
 
-	var categoryTypeIsDifferentOfAccountType = category.Type != accountType
-	if categoryTypeIsDifferentOfAccountType {
-		ctx.JSON(http.StatusBadRequest, "Account type is different of Category type")
-	} else {
-		arg := db.CreateAccountParams{
-			UserID:      req.UserID,
+		wallet, err := transactionWallet(ctx, q, req.WalletID, claims.UserID, strings.ToUpper(req.Currency))
+		if err != nil {
+			return err
+		}
+		amount, err := parseAmount(req.Amount, wallet.Currency)
+		if err != nil {
+			return err
+		}
+
+		account, err = q.CreateAccount(ctx, db.CreateAccountParams{
+			UserID:      claims.UserID,
+			WalletID:    wallet.ID,
 			CategoryID:  categoryId,
 			Title:       req.Title,
 			Type:        accountType,
 			Description: req.Description,
-			Value:       req.Value,
+			Amount:      amount.Amount,
+			Currency:    amount.Currency,
 			Date:        req.Date,
+		})
+		if err != nil {
+			return err
+		}
+		err = checkEnvelopeMonths(ctx, q, account)
+		if err != nil {
+			return err
 		}
 
-		account, err := server.store.CreateAccount(ctx, arg)
+		err = recordAudit(ctx, q, auditEvent{
+			UserID:     claims.UserID,
+			TargetType: auditTargetAccount,
+			TargetID:   account.ID,
+			Action:     auditActionCreate,
+			After:      account,
+		})
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return err
 		}
 
-		ctx.JSON(http.StatusOK, account)
+		notifications, err = checkBudgetThresholds(ctx, q, account)
+		return err
+	})
+	if err != nil {
+		var amountErr amountError
+		switch {
+		case err == sql.ErrNoRows:
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case err == errAccountTypeMismatch:
+			ctx.JSON(http.StatusBadRequest, "Account type is different of Category type")
+		case err == errWalletArchived, err == errWalletCurrencyMismatch, errors.As(err, &amountErr):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		case err == errEnvelopeDateClosed:
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
 	}
+
+	server.deliverNotifications(claims.UserID, notifications)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 type getAccountRequest struct {
@@ -67,17 +169,17 @@ type getAccountRequest struct {
 }
 
 func (server *Server) getAccount(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getAccountRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	account, err := server.store.GetAccount(ctx, db.GetAccountParams{
+		ID:     req.ID,
+		UserID: authClaims(ctx).UserID,
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -87,7 +189,91 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+// accountDayTotal is what a user spent or earned on one day, in their base
+// currency.
+type accountDayTotal struct {
+	Date   time.Time
+	Amount money.Money
+	Count  int64
+}
+
+type accountTotals struct {
+	Total money.Money
+	Days  []accountDayTotal
+	Rates *rateUsage
+}
+
+// accountTotals adds up the user's entries of one type in their base
+// currency, converting each day with the rates in effect on it.
+func (server *Server) accountTotals(ctx *gin.Context, userID int32, accountType string) (accountTotals, error) {
+	user, err := server.store.GetUserById(ctx, userID)
+	if err != nil {
+		return accountTotals{}, err
+	}
+
+	rows, err := server.store.GetAccountsReports(ctx, db.GetAccountsReportsParams{
+		UserID: userID,
+		Type:   accountType,
+	})
+	if err != nil {
+		return accountTotals{}, err
+	}
+
+	converter := newCurrencyConverter(server.store, user.BaseCurrency)
+	totals := accountTotals{
+		Total: money.Money{Currency: user.BaseCurrency},
+		Days:  []accountDayTotal{},
+		Rates: newRateUsage(),
+	}
+	for _, row := range rows {
+		amount := money.Money{Amount: row.SumAmount, Currency: row.Currency}
+		converted, rate, err := converter.convert(ctx, amount, row.Date)
+		if err != nil {
+			return accountTotals{}, err
+		}
+		if rate != nil {
+			err = totals.Rates.add(*rate, amount, converted)
+			if err != nil {
+				return accountTotals{}, err
+			}
+		}
+
+		totals.Total, err = totals.Total.Add(converted)
+		if err != nil {
+			return accountTotals{}, err
+		}
+
+		// Rows come sorted by date, so entries of the same day in other
+		// currencies are next to each other.
+		last := len(totals.Days) - 1
+		if last < 0 || !totals.Days[last].Date.Equal(row.Date) {
+			totals.Days = append(totals.Days, accountDayTotal{
+				Date:   row.Date,
+				Amount: money.Money{Currency: user.BaseCurrency},
+			})
+			last++
+		}
+		totals.Days[last].Amount, err = totals.Days[last].Amount.Add(converted)
+		if err != nil {
+			return accountTotals{}, err
+		}
+		totals.Days[last].Count += row.Count
+	}
+	return totals, nil
+}
+
+// reportError answers report requests that failed, telling users when a
+// rate they need hasn't been loaded.
+func reportError(ctx *gin.Context, err error) {
+	var missingRate missingRateError
+	if errors.As(err, &missingRate) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 }
 
 type getAccountGraphRequest struct {
@@ -95,19 +281,24 @@ type getAccountGraphRequest struct {
 	Type   string `uri:"type" binding:"required"`
 }
 
+// getAccountGraph is kept for existing clients. GET /account/cashflow
+// reports income and expense over time and should be used instead.
 func (server *Server) getAccountGraph(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getAccountGraphRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	claims := authClaims(ctx)
+	if req.UserID != claims.UserID {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
 	}
 
 	arg := db.GetAccountsGraphParams{
-		UserID: req.UserID,
+		UserID: claims.UserID,
 		Type:   req.Type,
 	}
 
@@ -117,7 +308,39 @@ func (server *Server) getAccountGraph(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, countGraph)
+	totals, err := server.accountTotals(ctx, claims.UserID, req.Type)
+	if err != nil {
+		reportError(ctx, err)
+		return
+	}
+
+	rsp := accountGraphResponse{
+		Count:    countGraph,
+		Currency: totals.Total.Currency,
+		Points:   make([]accountGraphPoint, 0, len(totals.Days)),
+		Rates:    totals.Rates.response(),
+	}
+	for _, day := range totals.Days {
+		rsp.Points = append(rsp.Points, accountGraphPoint{
+			Date:   day.Date,
+			Amount: day.Amount.String(),
+			Count:  day.Count,
+		})
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type accountGraphPoint struct {
+	Date   time.Time `json:"date"`
+	Amount string    `json:"amount"`
+	Count  int64     `json:"count"`
+}
+
+type accountGraphResponse struct {
+	Count    int64               `json:"count"`
+	Currency string              `json:"currency"`
+	Points   []accountGraphPoint `json:"points"`
+	Rates    []rateResponse      `json:"rates"`
 }
 
 type getAccountReportsRequest struct {
@@ -126,28 +349,36 @@ type getAccountReportsRequest struct {
 }
 
 func (server *Server) getAccountReports(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getAccountReportsRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	arg := db.GetAccountsReportsParams{
-		UserID: req.UserID,
-		Type:   req.Type,
+	claims := authClaims(ctx)
+	if req.UserID != claims.UserID {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
 	}
 
-	sumReports, err := server.store.GetAccountsReports(ctx, arg)
+	totals, err := server.accountTotals(ctx, claims.UserID, req.Type)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		reportError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, sumReports)
+	ctx.JSON(http.StatusOK, accountReportsResponse{
+		Amount:   totals.Total.String(),
+		Currency: totals.Total.Currency,
+		Rates:    totals.Rates.response(),
+	})
+}
+
+type accountReportsResponse struct {
+	Amount   string         `json:"amount"`
+	Currency string         `json:"currency"`
+	Rates    []rateResponse `json:"rates"`
 }
 
 type deleteAccountRequest struct {
@@ -155,18 +386,52 @@ type deleteAccountRequest struct {
 }
 
 func (server *Server) deleteAccount(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req deleteAccountRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	err = server.store.DeleteAccount(ctx, req.ID)
+	userID := authClaims(ctx).UserID
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetAccountForUpdate(ctx, db.GetAccountForUpdateParams{
+			ID:     req.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		err = checkEnvelopeMonths(ctx, q, before)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteAccount(ctx, db.DeleteAccountParams{
+			ID:     req.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetAccount,
+			TargetID:   before.ID,
+			Action:     auditActionDelete,
+			Before:     before,
+		})
+	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if err == errEnvelopeDateClosed {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -178,49 +443,122 @@ type updateAccountRequest struct {
 	ID          int32  `json:"id" binding:"required"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
-	Value       int32  `json:"value"`
+	Amount      string `json:"amount" binding:"required"`
+	Currency    string `json:"currency" binding:"omitempty,len=3"`
+	WalletID    int32  `json:"wallet_id"`
 }
 
 func (server *Server) updateAccount(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req updateAccountRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
 	arg := db.UpdateAccountParams{
 		ID:          req.ID,
+		UserID:      authClaims(ctx).UserID,
 		Title:       req.Title,
 		Description: req.Description,
-		Value:       req.Value,
 	}
 
-	account, err := server.store.UpdateAccount(ctx, arg)
+	var account db.Account
+	var notifications []db.Notification
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetAccountForUpdate(ctx, db.GetAccountForUpdateParams{
+			ID:     arg.ID,
+			UserID: arg.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		err = checkEnvelopeMonths(ctx, q, before)
+		if err != nil {
+			return err
+		}
+
+		// Entries are in the currency of their wallet, so the amount is
+		// read in the currency of the wallet it ends up in.
+		walletID := before.WalletID
+		if req.WalletID != 0 {
+			walletID = req.WalletID
+		}
+		wallet, err := transactionWallet(ctx, q, walletID, arg.UserID, strings.ToUpper(req.Currency))
+		if err != nil {
+			return err
+		}
+		amount, err := parseAmount(req.Amount, wallet.Currency)
+		if err != nil {
+			return err
+		}
+		arg.Amount = amount.Amount
+		arg.Currency = amount.Currency
+		arg.WalletID = wallet.ID
+
+		account, err = q.UpdateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, q, auditEvent{
+			UserID:     arg.UserID,
+			TargetType: auditTargetAccount,
+			TargetID:   account.ID,
+			Action:     auditActionUpdate,
+			Before:     before,
+			After:      account,
+		})
+		if err != nil {
+			return err
+		}
+
+		notifications, err = checkBudgetThresholds(ctx, q, account)
+		return err
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		var amountErr amountError
+		switch {
+		case err == sql.ErrNoRows:
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case err == errWalletArchived, err == errWalletCurrencyMismatch, errors.As(err, &amountErr):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		case err == errEnvelopeDateClosed:
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	server.deliverNotifications(arg.UserID, notifications)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+type accountListResponse struct {
+	ID            int32          `json:"id"`
+	UserID        int32          `json:"user_id"`
+	WalletID      int32          `json:"wallet_id"`
+	Title         string         `json:"title"`
+	Type          string         `json:"type"`
+	Description   string         `json:"description"`
+	Amount        string         `json:"amount"`
+	Currency      string         `json:"currency"`
+	Date          time.Time      `json:"date"`
+	CreatedAt     time.Time      `json:"created_at"`
+	CategoryTitle sql.NullString `json:"category_title"`
 }
 
 type getAccountsRequest struct {
-	UserID      int32     `form:"user_id" json:"user_id" binding:"required"`
 	Type        string    `form:"type" json:"type" binding:"required"`
 	CategoryID  int32     `form:"category_id" json:"category_id"`
+	WalletID    int32     `form:"wallet_id" json:"wallet_id"`
 	Title       string    `form:"title" json:"title"`
 	Description string    `form:"description" json:"description"`
 	Date        time.Time `form:"date" json:"date"`
 }
 
 func (server *Server) getAccounts(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getAccountsRequest
 	err := ctx.ShouldBindQuery(&req)
 	if err != nil {
@@ -229,12 +567,16 @@ func (server *Server) getAccounts(ctx *gin.Context) {
 	}
 
 	arg := db.GetAccountsParams{
-		UserID: req.UserID,
+		UserID: authClaims(ctx).UserID,
 		Type:   req.Type,
 		CategoryID: sql.NullInt32{
 			Int32: req.CategoryID,
 			Valid: req.CategoryID > 0,
 		},
+		WalletID: sql.NullInt32{
+			Int32: req.WalletID,
+			Valid: req.WalletID > 0,
+		},
 		Title:       req.Title,
 		Description: req.Description,
 		Date: sql.NullTime{
@@ -249,5 +591,21 @@ func (server *Server) getAccounts(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	rsp := make([]accountListResponse, 0, len(accounts))
+	for _, account := range accounts {
+		rsp = append(rsp, accountListResponse{
+			ID:            account.ID,
+			UserID:        account.UserID,
+			WalletID:      account.WalletID,
+			Title:         account.Title,
+			Type:          account.Type,
+			Description:   account.Description,
+			Amount:        formatAmount(account.Amount, account.Currency),
+			Currency:      account.Currency,
+			Date:          account.Date,
+			CreatedAt:     account.CreatedAt,
+			CategoryTitle: account.CategoryTitle,
+		})
+	}
+	ctx.JSON(http.StatusOK, rsp)
 }
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+var errCannotChangeSelf = errors.New("admins can't change their own role or status")
+
+// adminUserResponse adds to userResponse the fields only administrators
+// get to see.
+type adminUserResponse struct {
+	userResponse
+	DisabledAt            *time.Time `json:"disabled_at"`
+	LockedUntil           *time.Time `json:"locked_until"`
+	FailedLoginAttempts   int32      `json:"failed_login_attempts"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+}
+
+func newAdminUserResponse(user db.User) adminUserResponse {
+	rsp := adminUserResponse{
+		userResponse:          newUserResponse(user),
+		FailedLoginAttempts:   user.FailedLoginAttempts,
+		PasswordResetRequired: user.PasswordResetRequired,
+	}
+	if user.DisabledAt.Valid {
+		rsp.DisabledAt = &user.DisabledAt.Time
+	}
+	if user.LockedUntil.Valid {
+		rsp.LockedUntil = &user.LockedUntil.Time
+	}
+	return rsp
+}
+
+type listUsersRequest struct {
+	Search   string `form:"search"`
+	Role     string `form:"role" binding:"omitempty,oneof=user admin support-readonly"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+func (server *Server) listUsers(ctx *gin.Context) {
+	var req listUsersRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	users, err := server.store.ListUsers(ctx, db.ListUsersParams{
+		Search:     sql.NullString{String: req.Search, Valid: req.Search != ""},
+		Role:       sql.NullString{String: req.Role, Valid: req.Role != ""},
+		PageLimit:  req.PageSize,
+		PageOffset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]adminUserResponse, len(users))
+	for i, user := range users {
+		rsp[i] = newAdminUserResponse(user)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type adminUserRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getUser(ctx *gin.Context) {
+	var req adminUserRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+type userStatsResponse struct {
+	UserID               int32      `json:"user_id"`
+	Categories           int64      `json:"categories"`
+	Accounts             int64      `json:"accounts"`
+	ActiveSessions       int64      `json:"active_sessions"`
+	PersonalAccessTokens int64      `json:"personal_access_tokens"`
+	LastAccountAt        *time.Time `json:"last_account_at"`
+	LastLoginAt          *time.Time `json:"last_login_at"`
+}
+
+func (server *Server) getUserStats(ctx *gin.Context) {
+	var req adminUserRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	stats, err := server.store.GetUserUsageStats(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := userStatsResponse{
+		UserID:               stats.UserID,
+		Categories:           stats.Categories,
+		Accounts:             stats.Accounts,
+		ActiveSessions:       stats.ActiveSessions,
+		PersonalAccessTokens: stats.PersonalAccessTokens,
+	}
+	if stats.LastAccountAt.Valid {
+		rsp.LastAccountAt = &stats.LastAccountAt.Time
+	}
+	if stats.LastLoginAt.Valid {
+		rsp.LastLoginAt = &stats.LastLoginAt.Time
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin support-readonly"`
+}
+
+// updateUserRole changes the role stored for the user. Tokens already
+// issued keep the old role until they are refreshed, so the user's sessions
+// are revoked to make the change take effect right away.
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uri adminUserRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateUserRoleRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if uri.ID == authClaims(ctx).UserID {
+		ctx.JSON(http.StatusForbidden, errorResponse(errCannotChangeSelf))
+		return
+	}
+
+	user, err := server.updateUserAsAdmin(ctx, uri.ID, auditActionRoleChange, func(q *db.Queries) (db.User, error) {
+		user, err := q.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+			ID:   uri.ID,
+			Role: req.Role,
+		})
+		if err != nil {
+			return user, err
+		}
+		_, err = q.RevokeUserSessions(ctx, user.ID)
+		return user, err
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+func (server *Server) disableUser(ctx *gin.Context) {
+	var req adminUserRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.ID == authClaims(ctx).UserID {
+		ctx.JSON(http.StatusForbidden, errorResponse(errCannotChangeSelf))
+		return
+	}
+
+	user, err := server.updateUserAsAdmin(ctx, req.ID, auditActionDisable, func(q *db.Queries) (db.User, error) {
+		user, err := q.DisableUser(ctx, req.ID)
+		if err != nil {
+			return user, err
+		}
+		_, err = q.RevokeUserSessions(ctx, user.ID)
+		return user, err
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+func (server *Server) enableUser(ctx *gin.Context) {
+	var req adminUserRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.updateUserAsAdmin(ctx, req.ID, auditActionEnable, func(q *db.Queries) (db.User, error) {
+		return q.EnableUser(ctx, req.ID)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+// forcePasswordReset logs the user out everywhere and blocks logins until
+// they set a new password through the link we email them.
+func (server *Server) forcePasswordReset(ctx *gin.Context) {
+	var req adminUserRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.updateUserAsAdmin(ctx, req.ID, auditActionForcePasswordReset, func(q *db.Queries) (db.User, error) {
+		user, err := q.RequireUserPasswordReset(ctx, req.ID)
+		if err != nil {
+			return user, err
+		}
+		_, err = q.RevokeUserSessions(ctx, user.ID)
+		if err != nil {
+			return user, err
+		}
+		return user, q.RevokeUserPersonalAccessTokens(ctx, user.ID)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.sendPasswordReset(ctx, user)
+	if err != nil {
+		log.Printf("cannot send forced password reset to user %d: %v", user.ID, err)
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+// updateUserAsAdmin runs update in a transaction together with the audit
+// event that records the user as it was before and after.
+func (server *Server) updateUserAsAdmin(ctx *gin.Context, userID int32, action string, update func(q *db.Queries) (db.User, error)) (db.User, error) {
+	var user db.User
+	err := server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetUserById(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		user, err = update(q)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetUser,
+			TargetID:   userID,
+			Action:     action,
+			Before:     newAdminUserResponse(before),
+			After:      newAdminUserResponse(user),
+		})
+	})
+	return user, err
+}
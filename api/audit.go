@@ -0,0 +1,186 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	auditTargetAccount         = "account"
+	auditTargetCategory        = "category"
+	auditTargetUser            = "user"
+	auditTargetSession         = "session"
+	auditTargetWallet          = "wallet"
+	auditTargetTransfer        = "transfer"
+	auditTargetRecurring       = "recurring"
+	auditTargetInstallmentPlan = "installment_plan"
+	auditTargetBudget          = "budget"
+	auditTargetEnvelope        = "envelope"
+	auditTargetGoal            = "goal"
+
+	auditTargetPersonalAccessToken = "personal_access_token"
+
+	auditTargetExchangeRates = "exchange_rates"
+
+	auditActionCreate             = "create"
+	auditActionUpdate             = "update"
+	auditActionDelete             = "delete"
+	auditActionRestore            = "restore"
+	auditActionPasswordChange     = "password_change"
+	auditActionPasswordReset      = "password_reset"
+	auditActionForcePasswordReset = "force_password_reset"
+	auditActionRoleChange         = "role_change"
+	auditActionDisable            = "disable"
+	auditActionEnable             = "enable"
+	auditActionLoginSuccess       = "login_success"
+	auditActionLoginFailure       = "login_failure"
+	auditActionImport             = "import"
+	auditActionSkip               = "skip"
+	auditActionClose              = "close"
+	auditActionMFAEnable          = "mfa_enable"
+	auditActionMFADisable         = "mfa_disable"
+	auditActionLogout             = "logout"
+	auditActionRevoke             = "revoke"
+	auditActionUnlock             = "unlock"
+)
+
+// auditEvent describes one change for the audit log. UserID is the owner of
+// the data that changed, which isn't always the caller: admins act on other
+// users and failed logins have no caller at all.
+type auditEvent struct {
+	// ActorID defaults to the authenticated caller. Logins set it
+	// themselves since nobody is authenticated yet.
+	ActorID    int32
+	UserID     int32
+	TargetType string
+	TargetID   int32
+	Action     string
+	Before     interface{}
+	After      interface{}
+}
+
+// recordAudit writes the event through q, so callers running inside
+// ExecTx get it committed or rolled back together with the change.
+func recordAudit(ctx *gin.Context, q db.Querier, event auditEvent) error {
+	before, err := json.Marshal(event.Before)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(event.After)
+	if err != nil {
+		return err
+	}
+
+	actorID := event.ActorID
+	if payload, ok := ctx.Get(authorizationPayloadKey); ok && actorID == 0 {
+		actorID = payload.(*util.Claims).UserID
+	}
+
+	_, err = q.CreateAuditEvent(ctx, db.CreateAuditEventParams{
+		UserID:      sql.NullInt32{Int32: event.UserID, Valid: event.UserID != 0},
+		ActorUserID: sql.NullInt32{Int32: actorID, Valid: actorID != 0},
+		TargetType:  event.TargetType,
+		TargetID:    sql.NullInt32{Int32: event.TargetID, Valid: event.TargetID != 0},
+		Action:      event.Action,
+		Before:      before,
+		After:       after,
+		ClientIp:    ctx.ClientIP(),
+		UserAgent:   ctx.Request.UserAgent(),
+	})
+	return err
+}
+
+type loginFailure struct {
+	Username string `json:"username,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// recordLoginFailure keeps a trace of failed logins. Attempts on unknown
+// usernames aren't tied to any user, so only operators can see them.
+func recordLoginFailure(ctx *gin.Context, q db.Querier, userID int32, username, reason string) error {
+	return recordAudit(ctx, q, auditEvent{
+		UserID:     userID,
+		TargetType: auditTargetSession,
+		Action:     auditActionLoginFailure,
+		After:      loginFailure{Username: username, Reason: reason},
+	})
+}
+
+type auditEventResponse struct {
+	ID          int64           `json:"id"`
+	ActorUserID *int32          `json:"actor_user_id"`
+	TargetType  string          `json:"target_type"`
+	TargetID    *int32          `json:"target_id"`
+	Action      string          `json:"action"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	ClientIP    string          `json:"client_ip"`
+	UserAgent   string          `json:"user_agent"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func newAuditEventResponse(event db.AuditEvent) auditEventResponse {
+	rsp := auditEventResponse{
+		ID:         event.ID,
+		TargetType: event.TargetType,
+		Action:     event.Action,
+		Before:     event.Before,
+		After:      event.After,
+		ClientIP:   event.ClientIp,
+		UserAgent:  event.UserAgent,
+		CreatedAt:  event.CreatedAt,
+	}
+	if event.ActorUserID.Valid {
+		rsp.ActorUserID = &event.ActorUserID.Int32
+	}
+	if event.TargetID.Valid {
+		rsp.TargetID = &event.TargetID.Int32
+	}
+	return rsp
+}
+
+type listAuditEventsRequest struct {
+	TargetType string    `form:"target_type" binding:"omitempty,oneof=account category user session wallet transfer recurring installment_plan budget envelope goal exchange_rates personal_access_token"`
+	TargetID   int32     `form:"target_id" binding:"omitempty,min=1"`
+	Action     string    `form:"action"`
+	From       time.Time `form:"from"`
+	To         time.Time `form:"to"`
+	PageID     int32     `form:"page_id" binding:"required,min=1"`
+	PageSize   int32     `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+func (server *Server) listAuditEvents(ctx *gin.Context) {
+	var req listAuditEventsRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	events, err := server.store.ListAuditEvents(ctx, db.ListAuditEventsParams{
+		UserID:     authClaims(ctx).UserID,
+		TargetType: sql.NullString{String: req.TargetType, Valid: req.TargetType != ""},
+		TargetID:   sql.NullInt32{Int32: req.TargetID, Valid: req.TargetID != 0},
+		Action:     sql.NullString{String: req.Action, Valid: req.Action != ""},
+		FromTime:   sql.NullTime{Time: req.From, Valid: !req.From.IsZero()},
+		ToTime:     sql.NullTime{Time: req.To, Valid: !req.To.IsZero()},
+		PageLimit:  req.PageSize,
+		PageOffset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]auditEventResponse, len(events))
+	for i, event := range events {
+		rsp[i] = newAuditEventResponse(event)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
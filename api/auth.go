@@ -1,15 +1,24 @@
 package api
 
 import (
-	"bytes"
-	"crypto/sha512"
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
+)
+
+const refreshTokenSize = 32
+
+var (
+	errAccountDisabled       = errors.New("account has been disabled")
+	errPasswordResetRequired = errors.New("password must be reset before logging in")
 )
 
 type loginRequest struct {
@@ -18,13 +27,12 @@ type loginRequest struct {
 }
 
 type loginResponse struct {
-	UserID int32 `json:"user_id"`
-	Token string `json:"token"`
-}
-
-type Claims struct {
-	Username string `json:"username"`
-	jwt.RegisteredClaims
+	UserID                int32     `json:"user_id"`
+	SessionID             uuid.UUID `json:"session_id"`
+	Token                 string    `json:"token"`
+	TokenExpiresAt        time.Time `json:"token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
 func (server *Server) login(ctx *gin.Context) {
@@ -32,50 +40,195 @@ func (server *Server) login(ctx *gin.Context) {
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	usernameKey := "user:" + strings.ToLower(req.Username)
+	ipKey := "ip:" + ctx.ClientIP()
+	if !server.checkLoginThrottle(ctx, usernameKey, ipKey) {
+		return
 	}
 
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			util.SimulatePasswordCheck(req.Password)
+			err = recordLoginFailure(ctx, server.store, 0, req.Username, "unknown_user")
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			server.rejectLogin(ctx, usernameKey, ipKey)
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	hashedInput := sha512.Sum512_256([]byte(req.Password))
-	trimmedHash := bytes.Trim(hashedInput[:], "\x00")
-	preparedPassword := string(trimmedHash)
-	plainTextInBytes := []byte(preparedPassword)
-	hashTextInBytes := []byte(user.Password)
-	err = bcrypt.CompareHashAndPassword(hashTextInBytes, plainTextInBytes)
+	if user.LockedUntil.Valid && time.Now().Before(user.LockedUntil.Time) {
+		util.SimulatePasswordCheck(req.Password)
+		err = recordLoginFailure(ctx, server.store, user.ID, user.Username, "locked")
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		server.rejectLogin(ctx, usernameKey, ipKey)
+		return
+	}
+	if user.LockedUntil.Valid {
+		err = server.store.ResetUserLoginFailures(ctx, user.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	err = util.CheckPassword(req.Password, user.Password)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		err = server.recordUserLoginFailure(ctx, user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		server.rejectLogin(ctx, usernameKey, ipKey)
 		return
 	}
 
-	expirationTime := time.Now().Add(100 * time.Minute)
+	if util.PasswordNeedsRehash(user.Password) {
+		server.rehashPassword(ctx, user.ID, req.Password)
+	}
+
+	err = server.loginLimiter.Reset(ctx, usernameKey)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if user.FailedLoginAttempts > 0 {
+		err = server.store.ResetUserLoginFailures(ctx, user.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
 
-	claims := &Claims{
-		Username: req.Username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-		},
+	err = checkUserCanLogin(user)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
 	}
 
-	generatedToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	var jwtSignedKey = []byte("secret_key")
-	generatedTokenToString, err := generatedToken.SignedString(jwtSignedKey)
+	mfaEnabled, err := server.hasTOTPEnabled(ctx, user.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	if mfaEnabled {
+		server.sendMFAChallenge(ctx, user)
+		return
+	}
 
-	arg := &loginResponse{
-		UserID: user.ID,
-		Token: generatedTokenToString,
+	rsp, err := server.startSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// rehashPassword upgrades the stored hash to the current algorithm. The
+// login already succeeded, so failures are only logged.
+func (server *Server) rehashPassword(ctx *gin.Context, userID int32, password string) {
+	passwordHashed, err := util.HashPassword(password)
+	if err == nil {
+		err = server.store.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			ID:       userID,
+			Password: passwordHashed,
+		})
+	}
+	if err != nil {
+		log.Printf("cannot rehash password of user %d: %v", userID, err)
+	}
+}
+
+// checkUserCanLogin runs once the user proved who they are, so telling them
+// why they can't get in doesn't leak anything.
+func checkUserCanLogin(user db.User) error {
+	if user.DisabledAt.Valid {
+		return errAccountDisabled
+	}
+	if user.PasswordResetRequired {
+		return errPasswordResetRequired
+	}
+	return nil
+}
+
+func (server *Server) startSession(ctx *gin.Context, user db.User) (*loginResponse, error) {
+	var session db.Session
+	err := server.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		session, err = q.CreateSession(ctx, db.CreateSessionParams{
+			UserID:    user.ID,
+			UserAgent: ctx.Request.UserAgent(),
+			ClientIp:  ctx.ClientIP(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			ActorID:    user.ID,
+			UserID:     user.ID,
+			TargetType: auditTargetSession,
+			Action:     auditActionLoginSuccess,
+			After:      gin.H{"session_id": session.ID},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return server.issueTokens(ctx, user, session.ID)
+}
+
+// issueTokens signs a new access token for the session and stores a fresh
+// refresh token for it, returning both to the client.
+func (server *Server) issueTokens(ctx *gin.Context, user db.User, sessionID uuid.UUID) (*loginResponse, error) {
+	subject := util.TokenSubject{
+		UserID:        user.ID,
+		Username:      user.Username,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		Role:          user.Role,
+	}
+	accessToken, accessClaims, err := server.keys.CreateToken(subject, sessionID, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, err
 	}
 
-	ctx.JSON(http.StatusOK, arg)
+	refreshToken, err := util.RandomToken(refreshTokenSize)
+	if err != nil {
+		return nil, err
+	}
+
+	storedToken, err := server.store.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		SessionID: sessionID,
+		TokenHash: util.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(server.config.RefreshTokenDuration),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &loginResponse{
+		UserID:                user.ID,
+		SessionID:             sessionID,
+		Token:                 accessToken,
+		TokenExpiresAt:        accessClaims.ExpiresAt.Time,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: storedToken.ExpiresAt,
+	}, nil
+}
+
+func (server *Server) getJWKS(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.keys.JWKS())
 }
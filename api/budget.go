@@ -0,0 +1,517 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/money"
+	"github.com/gin-gonic/gin"
+)
+
+const periodLayout = "2006-01"
+
+var (
+	errInvalidPeriod      = errors.New("period must be a month like 2024-03")
+	errBudgetCategoryType = errors.New("budgets can only be set on expense categories")
+	errBudgetExists       = errors.New("category already has a budget for this period")
+	errNegativeBudget     = errors.New("budget amount can't be negative")
+)
+
+// parsePeriod reads a month like "2024-03" as its first day.
+func parsePeriod(value string) (time.Time, error) {
+	period, err := time.Parse(periodLayout, value)
+	if err != nil {
+		return time.Time{}, errInvalidPeriod
+	}
+	return period, nil
+}
+
+// periodOf returns the month date falls in.
+func periodOf(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+type budgetResponse struct {
+	ID         int32     `json:"id"`
+	CategoryID int32     `json:"category_id"`
+	Period     string    `json:"period"`
+	Amount     string    `json:"amount"`
+	Currency   string    `json:"currency"`
+	Rollover   bool      `json:"rollover"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newBudgetResponse(budget db.Budget) budgetResponse {
+	return budgetResponse{
+		ID:         budget.ID,
+		CategoryID: budget.CategoryID,
+		Period:     budget.Period.Format(periodLayout),
+		Amount:     formatAmount(budget.Amount, budget.Currency),
+		Currency:   budget.Currency,
+		Rollover:   budget.Rollover,
+		CreatedAt:  budget.CreatedAt,
+	}
+}
+
+func budgetErrorResponse(ctx *gin.Context, err error) {
+	var amountErr amountError
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case err == errInvalidPeriod, err == errBudgetCategoryType, err == errNegativeBudget,
+		errors.As(err, &amountErr):
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	case isUniqueViolation(err):
+		ctx.JSON(http.StatusConflict, errorResponse(errBudgetExists))
+	default:
+		reportError(ctx, err)
+	}
+}
+
+// budgetLine is where the budget of one category stands in a month.
+type budgetLine struct {
+	Budget db.ListBudgetsUntilRow
+	// Carried is what was left of last month's budget when it rolls over.
+	Carried money.Money
+	Planned money.Money
+	Spent   money.Money
+}
+
+func (line budgetLine) Remaining() money.Money {
+	return money.Money{Amount: line.Planned.Amount - line.Spent.Amount, Currency: line.Planned.Currency}
+}
+
+// PercentUsed is nil when nothing was planned for the category.
+func (line budgetLine) PercentUsed() *big.Rat {
+	if line.Planned.Amount == 0 {
+		return nil
+	}
+	return big.NewRat(line.Spent.Amount*100, line.Planned.Amount)
+}
+
+type budgetMonth struct {
+	CategoryID int32
+	Period     time.Time
+}
+
+// budgetStatus works out the budgets of period, optionally of a single
+// category. Expenses are converted to the currency of each budget with the
+// rates of the day they happened. A budget with rollover gets what was left
+// of the previous month's budget, as long as months follow each other in
+// the same currency.
+func budgetStatus(ctx context.Context, q db.Querier, userID int32, period time.Time, categoryID sql.NullInt32) ([]budgetLine, *rateUsage, error) {
+	budgets, err := q.ListBudgetsUntil(ctx, db.ListBudgetsUntilParams{
+		UserID:     userID,
+		Period:     period,
+		CategoryID: categoryID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Budgets come sorted by category and period, so each chain of
+	// rolled over months ends with the budget of the period.
+	var chains [][]db.ListBudgetsUntilRow
+	for end := range budgets {
+		if !budgets[end].Period.Equal(period) {
+			continue
+		}
+		start := end
+		for start > 0 && budgets[start].Rollover {
+			previous := budgets[start-1]
+			if previous.CategoryID != budgets[start].CategoryID ||
+				previous.Currency != budgets[start].Currency ||
+				!previous.Period.AddDate(0, 1, 0).Equal(budgets[start].Period) {
+				break
+			}
+			start--
+		}
+		chains = append(chains, budgets[start:end+1])
+	}
+	if len(chains) == 0 {
+		return []budgetLine{}, newRateUsage(), nil
+	}
+
+	from := period
+	categoryIDs := make([]int32, 0, len(chains))
+	byMonth := map[budgetMonth]db.ListBudgetsUntilRow{}
+	for _, chain := range chains {
+		if chain[0].Period.Before(from) {
+			from = chain[0].Period
+		}
+		categoryIDs = append(categoryIDs, chain[0].CategoryID)
+		for _, budget := range chain {
+			byMonth[budgetMonth{CategoryID: budget.CategoryID, Period: budget.Period}] = budget
+		}
+	}
+
+	rows, err := q.GetBudgetSpending(ctx, db.GetBudgetSpendingParams{
+		UserID:      userID,
+		FromDate:    from,
+		ToDate:      period.AddDate(0, 1, 0),
+		CategoryIds: categoryIDs,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rates := newRateUsage()
+	converters := map[string]*currencyConverter{}
+	spent := map[budgetMonth]int64{}
+	for _, row := range rows {
+		month := budgetMonth{CategoryID: row.CategoryID, Period: periodOf(row.Date)}
+		budget, ok := byMonth[month]
+		if !ok {
+			continue
+		}
+		converter, ok := converters[budget.Currency]
+		if !ok {
+			converter = newCurrencyConverter(q, budget.Currency)
+			converters[budget.Currency] = converter
+		}
+
+		amount := money.Money{Amount: row.SumAmount, Currency: row.Currency}
+		converted, rate, err := converter.convert(ctx, amount, row.Date)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rate != nil {
+			err = rates.add(*rate, amount, converted)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		spent[month] += converted.Amount
+	}
+
+	lines := make([]budgetLine, 0, len(chains))
+	for _, chain := range chains {
+		var line budgetLine
+		var carried int64
+		for _, budget := range chain {
+			monthSpent := spent[budgetMonth{CategoryID: budget.CategoryID, Period: budget.Period}]
+			line = budgetLine{
+				Budget:  budget,
+				Carried: money.Money{Amount: carried, Currency: budget.Currency},
+				Planned: money.Money{Amount: budget.Amount + carried, Currency: budget.Currency},
+				Spent:   money.Money{Amount: monthSpent, Currency: budget.Currency},
+			}
+			carried = line.Remaining().Amount
+			if carried < 0 {
+				carried = 0
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, rates, nil
+}
+
+type createBudgetRequest struct {
+	CategoryID int32  `json:"category_id" binding:"required,min=1"`
+	Period     string `json:"period" binding:"required"`
+	Amount     string `json:"amount" binding:"required"`
+	Currency   string `json:"currency" binding:"omitempty,len=3"`
+	Rollover   bool   `json:"rollover"`
+}
+
+// createBudget sets the budget of an expense category for one month, in the
+// user's base currency unless another one is given.
+func (server *Server) createBudget(ctx *gin.Context) {
+	var req createBudgetRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	period, err := parsePeriod(req.Period)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var budget db.Budget
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		category, err := q.GetCategory(ctx, db.GetCategoryParams{
+			ID:     req.CategoryID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		if category.Type != "debit" {
+			return errBudgetCategoryType
+		}
+
+		currency := strings.ToUpper(req.Currency)
+		if currency == "" {
+			user, err := q.GetUserById(ctx, userID)
+			if err != nil {
+				return err
+			}
+			currency = user.BaseCurrency
+		}
+		amount, err := parseAmount(req.Amount, currency)
+		if err != nil {
+			return err
+		}
+		if amount.Amount < 0 {
+			return errNegativeBudget
+		}
+
+		budget, err = q.CreateBudget(ctx, db.CreateBudgetParams{
+			UserID:     userID,
+			CategoryID: category.ID,
+			Period:     period,
+			Amount:     amount.Amount,
+			Currency:   amount.Currency,
+			Rollover:   req.Rollover,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetBudget,
+			TargetID:   budget.ID,
+			Action:     auditActionCreate,
+			After:      budget,
+		})
+	})
+	if err != nil {
+		budgetErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newBudgetResponse(budget))
+}
+
+type listBudgetsRequest struct {
+	Period string `form:"period"`
+}
+
+func (server *Server) listBudgets(ctx *gin.Context) {
+	var req listBudgetsRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListBudgetsParams{UserID: authClaims(ctx).UserID}
+	if req.Period != "" {
+		period, err := parsePeriod(req.Period)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		arg.Period = sql.NullTime{Time: period, Valid: true}
+	}
+
+	budgets, err := server.store.ListBudgets(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]budgetResponse, 0, len(budgets))
+	for _, budget := range budgets {
+		rsp = append(rsp, newBudgetResponse(budget))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type budgetRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+type updateBudgetRequest struct {
+	Amount   *string `json:"amount"`
+	Rollover *bool   `json:"rollover"`
+}
+
+func (server *Server) updateBudget(ctx *gin.Context) {
+	var uri budgetRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateBudgetRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var budget db.Budget
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetBudgetForUpdate(ctx, db.GetBudgetForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		arg := db.UpdateBudgetParams{
+			ID:       before.ID,
+			UserID:   userID,
+			Amount:   before.Amount,
+			Rollover: before.Rollover,
+		}
+		if req.Amount != nil {
+			amount, err := parseAmount(*req.Amount, before.Currency)
+			if err != nil {
+				return err
+			}
+			if amount.Amount < 0 {
+				return errNegativeBudget
+			}
+			arg.Amount = amount.Amount
+		}
+		if req.Rollover != nil {
+			arg.Rollover = *req.Rollover
+		}
+
+		budget, err = q.UpdateBudget(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetBudget,
+			TargetID:   budget.ID,
+			Action:     auditActionUpdate,
+			Before:     before,
+			After:      budget,
+		})
+	})
+	if err != nil {
+		budgetErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newBudgetResponse(budget))
+}
+
+func (server *Server) deleteBudget(ctx *gin.Context) {
+	var uri budgetRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetBudgetForUpdate(ctx, db.GetBudgetForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteBudget(ctx, db.DeleteBudgetParams{
+			ID:     before.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetBudget,
+			TargetID:   before.ID,
+			Action:     auditActionDelete,
+			Before:     before,
+		})
+	})
+	if err != nil {
+		budgetErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type budgetStatusRequest struct {
+	Period string `uri:"period" binding:"required"`
+}
+
+type budgetLineResponse struct {
+	BudgetID      int32   `json:"budget_id"`
+	CategoryID    int32   `json:"category_id"`
+	CategoryTitle string  `json:"category_title"`
+	Currency      string  `json:"currency"`
+	Budgeted      string  `json:"budgeted"`
+	Carried       string  `json:"carried"`
+	Planned       string  `json:"planned"`
+	Spent         string  `json:"spent"`
+	Remaining     string  `json:"remaining"`
+	PercentUsed   *string `json:"percent_used"`
+}
+
+type budgetStatusResponse struct {
+	Period     string               `json:"period"`
+	Categories []budgetLineResponse `json:"categories"`
+	Rates      []rateResponse       `json:"rates"`
+}
+
+// getBudgetStatus compares what was planned for each category in the month
+// with what was spent on it.
+func (server *Server) getBudgetStatus(ctx *gin.Context) {
+	var uri budgetStatusRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	period, err := parsePeriod(uri.Period)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	lines, rates, err := budgetStatus(ctx, server.store, authClaims(ctx).UserID, period, sql.NullInt32{})
+	if err != nil {
+		reportError(ctx, err)
+		return
+	}
+
+	rsp := budgetStatusResponse{
+		Period:     period.Format(periodLayout),
+		Categories: make([]budgetLineResponse, 0, len(lines)),
+		Rates:      rates.response(),
+	}
+	for _, line := range lines {
+		lineRsp := budgetLineResponse{
+			BudgetID:      line.Budget.ID,
+			CategoryID:    line.Budget.CategoryID,
+			CategoryTitle: line.Budget.CategoryTitle,
+			Currency:      line.Budget.Currency,
+			Budgeted:      formatAmount(line.Budget.Amount, line.Budget.Currency),
+			Carried:       line.Carried.String(),
+			Planned:       line.Planned.String(),
+			Spent:         line.Spent.String(),
+			Remaining:     line.Remaining().String(),
+		}
+		if percent := line.PercentUsed(); percent != nil {
+			formatted := percent.FloatString(2)
+			lineRsp.PercentUsed = &formatted
+		}
+		rsp.Categories = append(rsp.Categories, lineRsp)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
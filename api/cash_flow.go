@@ -0,0 +1,295 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/money"
+	"github.com/GustavoNoronha0/gofinance-backend/recurrence"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	granularityDay   = "day"
+	granularityWeek  = "week"
+	granularityMonth = "month"
+	granularityYear  = "year"
+
+	dateLayout = "2006-01-02"
+
+	// defaultCashFlowBuckets is how far back a cash flow goes when no
+	// start date is given.
+	defaultCashFlowBuckets = 12
+	maxCashFlowBuckets     = 1000
+)
+
+var (
+	errInvalidDate     = errors.New("dates must look like 2024-03-01 or 2024-03-01T10:00:00-03:00")
+	errCashFlowRange   = errors.New("from can't be after to")
+	errCashFlowTooLong = fmt.Errorf("a cash flow can't have more than %d buckets", maxCashFlowBuckets)
+)
+
+// localDate reads a date the way the user sees it. Plain dates are taken
+// as they are, timestamps are moved to the user's time zone first, so
+// midnight in UTC can still be the day before for them.
+func localDate(value string, location *time.Location) (time.Time, error) {
+	date, err := time.Parse(dateLayout, value)
+	if err == nil {
+		return date, nil
+	}
+	instant, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errInvalidDate
+	}
+	return recurrence.Date(instant.In(location)), nil
+}
+
+// bucketStart is the first day of the bucket date falls in. Weeks start on
+// Monday, as they do for Postgres.
+func bucketStart(date time.Time, granularity string) time.Time {
+	switch granularity {
+	case granularityDay:
+		return date
+	case granularityWeek:
+		return date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+	case granularityMonth:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// nextBucket moves start the given number of buckets, backwards when n is
+// negative.
+func nextBucket(start time.Time, granularity string, n int) time.Time {
+	switch granularity {
+	case granularityDay:
+		return start.AddDate(0, 0, n)
+	case granularityWeek:
+		return start.AddDate(0, 0, 7*n)
+	case granularityMonth:
+		return start.AddDate(0, n, 0)
+	default:
+		return start.AddDate(n, 0, 0)
+	}
+}
+
+// atMidnight is the instant a local date starts in the user's time zone.
+func atMidnight(date time.Time, location *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, location)
+}
+
+type cashFlowCategoryResponse struct {
+	CategoryID    int32  `json:"category_id"`
+	CategoryTitle string `json:"category_title"`
+	Type          string `json:"type"`
+	Amount        string `json:"amount"`
+}
+
+type cashFlowBucketResponse struct {
+	// Start and End are the instants the bucket covers in the user's time
+	// zone, End excluded. The first and last buckets are cut to the range
+	// asked for.
+	Start      time.Time                  `json:"start"`
+	End        time.Time                  `json:"end"`
+	Income     string                     `json:"income"`
+	Expense    string                     `json:"expense"`
+	Net        string                     `json:"net"`
+	Categories []cashFlowCategoryResponse `json:"categories,omitempty"`
+}
+
+type cashFlowResponse struct {
+	From        string                   `json:"from"`
+	To          string                   `json:"to"`
+	Granularity string                   `json:"granularity"`
+	Timezone    string                   `json:"timezone"`
+	Currency    string                   `json:"currency"`
+	Income      string                   `json:"income"`
+	Expense     string                   `json:"expense"`
+	Net         string                   `json:"net"`
+	Buckets     []cashFlowBucketResponse `json:"buckets"`
+	Rates       []rateResponse           `json:"rates"`
+}
+
+type cashFlowBucket struct {
+	Start      time.Time
+	Income     int64
+	Expense    int64
+	Categories map[int32]int64
+}
+
+type getCashFlowRequest struct {
+	From        string `form:"from"`
+	To          string `form:"to"`
+	Granularity string `form:"granularity" binding:"omitempty,oneof=day week month year"`
+	ByCategory  bool   `form:"by_category"`
+}
+
+// getCashFlow reports income, expense and net for each day, week, month or
+// year between two dates, in the user's base currency. Buckets follow the
+// user's calendar: they start at midnight in their time zone and, without
+// an end date, run up to their today.
+func (server *Server) getCashFlow(ctx *gin.Context) {
+	var req getCashFlowRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = granularityMonth
+	}
+
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	location, err := loadTimezone(user.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	to := recurrence.Date(time.Now().In(location))
+	if req.To != "" {
+		to, err = localDate(req.To, location)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+	from := nextBucket(bucketStart(to, granularity), granularity, 1-defaultCashFlowBuckets)
+	if req.From != "" {
+		from, err = localDate(req.From, location)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+	if from.After(to) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errCashFlowRange))
+		return
+	}
+	buckets := 0
+	for start := bucketStart(from, granularity); !start.After(to); start = nextBucket(start, granularity, 1) {
+		buckets++
+		if buckets > maxCashFlowBuckets {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errCashFlowTooLong))
+			return
+		}
+	}
+
+	rows, err := server.store.GetCashFlow(ctx, db.GetCashFlowParams{
+		UserID:      user.ID,
+		Granularity: granularity,
+		FromDate:    from,
+		ToDate:      to,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	converter := newCurrencyConverter(server.store, user.BaseCurrency)
+	rates := newRateUsage()
+	var flow []*cashFlowBucket
+	for _, row := range rows {
+		if len(flow) == 0 || !flow[len(flow)-1].Start.Equal(row.Bucket) {
+			flow = append(flow, &cashFlowBucket{Start: row.Bucket, Categories: map[int32]int64{}})
+		}
+		if !row.Date.Valid {
+			continue
+		}
+
+		amount := money.Money{Amount: row.SumAmount, Currency: row.Currency.String}
+		converted, rate, err := converter.convert(ctx, amount, row.Date.Time)
+		if err != nil {
+			reportError(ctx, err)
+			return
+		}
+		if rate != nil {
+			err = rates.add(*rate, amount, converted)
+			if err != nil {
+				reportError(ctx, err)
+				return
+			}
+		}
+
+		bucket := flow[len(flow)-1]
+		switch row.Type.String {
+		case "credit":
+			bucket.Income += converted.Amount
+		case "debit":
+			bucket.Expense += converted.Amount
+		}
+		bucket.Categories[row.CategoryID.Int32] += converted.Amount
+	}
+
+	var categories map[int32]db.Category
+	if req.ByCategory {
+		list, err := server.store.ListUserCategories(ctx, user.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		categories = make(map[int32]db.Category, len(list))
+		for _, category := range list {
+			categories[category.ID] = category
+		}
+	}
+
+	currency := user.BaseCurrency
+	rsp := cashFlowResponse{
+		From:        from.Format(dateLayout),
+		To:          to.Format(dateLayout),
+		Granularity: granularity,
+		Timezone:    location.String(),
+		Currency:    currency,
+		Buckets:     make([]cashFlowBucketResponse, 0, len(flow)),
+		Rates:       rates.response(),
+	}
+	var income, expense int64
+	for _, bucket := range flow {
+		start, end := bucket.Start, nextBucket(bucket.Start, granularity, 1)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to.AddDate(0, 0, 1)
+		}
+		bucketRsp := cashFlowBucketResponse{
+			Start:   atMidnight(start, location),
+			End:     atMidnight(end, location),
+			Income:  formatAmount(bucket.Income, currency),
+			Expense: formatAmount(bucket.Expense, currency),
+			Net:     formatAmount(bucket.Income-bucket.Expense, currency),
+		}
+		if req.ByCategory {
+			for categoryID, amount := range bucket.Categories {
+				category := categories[categoryID]
+				bucketRsp.Categories = append(bucketRsp.Categories, cashFlowCategoryResponse{
+					CategoryID:    categoryID,
+					CategoryTitle: category.Title,
+					Type:          category.Type,
+					Amount:        formatAmount(amount, currency),
+				})
+			}
+			sort.Slice(bucketRsp.Categories, func(i, j int) bool {
+				return bucketRsp.Categories[i].CategoryID < bucketRsp.Categories[j].CategoryID
+			})
+		}
+		rsp.Buckets = append(rsp.Buckets, bucketRsp)
+		income += bucket.Income
+		expense += bucket.Expense
+	}
+	rsp.Income = formatAmount(income, currency)
+	rsp.Expense = formatAmount(expense, currency)
+	rsp.Net = formatAmount(income-expense, currency)
+
+	ctx.JSON(http.StatusOK, rsp)
+}
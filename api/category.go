@@ -5,38 +5,49 @@ import (
 	"net/http"
 
 	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
-	"github.com/GustavoNoronha0/gofinance-backend/util"
 	"github.com/gin-gonic/gin"
 )
 
 type createCategoryRequest struct {
-	UserID      int32  `json:"user_id" binding:"required"`
 	Title       string `json:"title" binding:"required"`
-	Type        string `json:"type" binding:"required"`
+	Type        string `json:"type" binding:"required,oneof=credit debit"`
 	Description string `json:"description" binding:"required"`
 }
 
 func (server *Server) createCategory(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req createCategoryRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
 	arg := db.CreateCategoryParams{
-		UserID:      req.UserID,
+		UserID:      authClaims(ctx).UserID,
 		Title:       req.Title,
 		Type:        req.Type,
 		Description: req.Description,
 	}
 
-	category, err := server.store.CreateCategory(ctx, arg)
+	var category db.Category
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		category, err = q.CreateCategory(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     category.UserID,
+			TargetType: auditTargetCategory,
+			TargetID:   category.ID,
+			Action:     auditActionCreate,
+			After:      category,
+		})
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
 	ctx.JSON(http.StatusOK, category)
@@ -47,17 +58,17 @@ type getCategoryRequest struct {
 }
 
 func (server *Server) getCategory(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getCategoryRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	category, err := server.store.GetCategory(ctx, req.ID)
+	category, err := server.store.GetCategory(ctx, db.GetCategoryParams{
+		ID:     req.ID,
+		UserID: authClaims(ctx).UserID,
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -75,18 +86,44 @@ type deleteCategoryRequest struct {
 }
 
 func (server *Server) deleteCategory(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req deleteCategoryRequest
 	err := ctx.ShouldBindUri(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	err = server.store.DeleteCategories(ctx, req.ID)
+	userID := authClaims(ctx).UserID
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetCategoryForUpdate(ctx, db.GetCategoryForUpdateParams{
+			ID:     req.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteCategories(ctx, db.DeleteCategoriesParams{
+			ID:     req.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetCategory,
+			TargetID:   before.ID,
+			Action:     auditActionDelete,
+			Before:     before,
+		})
+	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -101,42 +138,63 @@ type updateCategoryRequest struct {
 }
 
 func (server *Server) updateCategory(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req updateCategoryRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
 	arg := db.UpdateCategoriesParams{
 		ID:          req.ID,
+		UserID:      authClaims(ctx).UserID,
 		Title:       req.Title,
 		Description: req.Description,
 	}
 
-	category, err := server.store.UpdateCategories(ctx, arg)
+	var category db.Category
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetCategoryForUpdate(ctx, db.GetCategoryForUpdateParams{
+			ID:     arg.ID,
+			UserID: arg.UserID,
+		})
+		if err != nil {
+			return err
+		}
+
+		category, err = q.UpdateCategories(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     arg.UserID,
+			TargetType: auditTargetCategory,
+			TargetID:   category.ID,
+			Action:     auditActionUpdate,
+			Before:     before,
+			After:      category,
+		})
+	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
 	ctx.JSON(http.StatusOK, category)
 }
 
 type getCategoriesRequest struct {
-	UserID      int32  `form:"user_id" json:"user_id" binding:"required"`
 	Type        string `form:"type" json:"type" binding:"required"`
 	Title       string `form:"title" json:"title"`
 	Description string `form:"description" json:"description"`
 }
 
 func (server *Server) getCategories(ctx *gin.Context) {
-	errOnValiteToken := util.GetTokenInHeaderAndVerify(ctx)
-	if errOnValiteToken != nil {
-		return
-	}
 	var req getCategoriesRequest
 	err := ctx.ShouldBindQuery(&req)
 	if err != nil {
@@ -145,7 +203,7 @@ func (server *Server) getCategories(ctx *gin.Context) {
 	}
 
 	arg := db.GetCategoriesParams{
-		UserID:      req.UserID,
+		UserID:      authClaims(ctx).UserID,
 		Type:        req.Type,
 		Title:       req.Title,
 		Description: req.Description,
@@ -154,6 +212,7 @@ func (server *Server) getCategories(ctx *gin.Context) {
 	categories, err := server.store.GetCategories(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
 	ctx.JSON(http.StatusOK, categories)
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/mail"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tokenPurposePasswordReset     = "password_reset"
+	tokenPurposeEmailVerification = "email_verification"
+
+	passwordResetTokenDuration     = time.Hour
+	emailVerificationTokenDuration = 48 * time.Hour
+	userTokenSize                  = 32
+)
+
+var (
+	errInvalidUserToken     = errors.New("token is invalid or has expired")
+	errEmailNotVerified     = errors.New("email address has not been verified yet")
+	errEmailAlreadyVerified = errors.New("email address is already verified")
+)
+
+// createUserToken stores a single use token for the user and returns the
+// plain value, which is only ever sent by email.
+func (server *Server) createUserToken(ctx context.Context, userID int32, purpose string, duration time.Duration) (string, error) {
+	token, err := util.RandomToken(userTokenSize)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = server.store.CreateUserToken(ctx, db.CreateUserTokenParams{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(duration),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeUserToken marks the token as used and returns it. Expired, unknown
+// and already used tokens all fail the same way.
+func consumeUserToken(ctx context.Context, q db.Querier, token string, purpose string) (db.UserToken, error) {
+	userToken, err := q.GetUserToken(ctx, db.GetUserTokenParams{
+		TokenHash: util.HashToken(token),
+		Purpose:   purpose,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return userToken, errInvalidUserToken
+		}
+		return userToken, err
+	}
+
+	rows, err := q.UseUserToken(ctx, userToken.ID)
+	if err != nil {
+		return userToken, err
+	}
+	if rows == 0 {
+		return userToken, errInvalidUserToken
+	}
+	return userToken, nil
+}
+
+func (server *Server) sendEmailVerification(ctx context.Context, user db.User) error {
+	token, err := server.createUserToken(ctx, user.ID, tokenPurposeEmailVerification, emailVerificationTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	return server.mailer.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Confirm your gofinance email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening the link below:\n\n%s/email/verify?token=%s\n\nThe link expires in %s.\n",
+			user.Username, server.config.AppBaseURL, token, emailVerificationTokenDuration),
+	})
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		userToken, err := consumeUserToken(ctx, q, req.Token, tokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		return q.VerifyUserEmail(ctx, userToken.UserID)
+	})
+	if err != nil {
+		if err == errInvalidUserToken {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+func (server *Server) resendEmailVerification(ctx *gin.Context) {
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.EmailVerifiedAt.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(errEmailAlreadyVerified))
+		return
+	}
+
+	err = server.sendEmailVerification(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
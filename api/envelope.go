@@ -0,0 +1,811 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/money"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errEnvelopeModeOff      = errors.New("envelope budgeting isn't enabled")
+	errEnvelopeBeforeStart  = errors.New("period is before envelope budgeting started")
+	errEnvelopeMonthClosed  = errors.New("month is already closed")
+	errEnvelopeMonthNotOver = errors.New("only past months can be closed")
+	errEnvelopePreviousOpen = errors.New("the previous month has to be closed first")
+	errEnvelopeSameCategory = errors.New("money can't be moved to the same envelope")
+	errEnvelopeMoveAmount   = errors.New("amount to move must be positive")
+	errEnvelopeDateClosed   = errors.New("entries can't be dated in a closed envelope month")
+)
+
+func envelopeErrorResponse(ctx *gin.Context, err error) {
+	var amountErr amountError
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case err == errInvalidPeriod, err == errBudgetCategoryType, err == errEnvelopeModeOff,
+		err == errEnvelopeBeforeStart, err == errEnvelopeMonthNotOver, err == errEnvelopeSameCategory,
+		err == errEnvelopeMoveAmount, errors.As(err, &amountErr):
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	case err == errEnvelopeMonthClosed, err == errEnvelopePreviousOpen:
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case isUniqueViolation(err):
+		ctx.JSON(http.StatusConflict, errorResponse(errEnvelopeMonthClosed))
+	default:
+		reportError(ctx, err)
+	}
+}
+
+// envelope is the money set aside for an expense category in a month.
+type envelope struct {
+	CategoryID    int32
+	CategoryTitle string
+	// Carried is what the envelope had left at the start of the month. It
+	// is negative when it was overspent.
+	Carried  money.Money
+	Assigned money.Money
+	Activity money.Money
+}
+
+func (env envelope) Available() money.Money {
+	return money.Money{
+		Amount:   env.Carried.Amount + env.Assigned.Amount - env.Activity.Amount,
+		Currency: env.Carried.Currency,
+	}
+}
+
+type envelopeMonth struct {
+	Period time.Time
+	Closed bool
+	Income money.Money
+	// Assigned is what went into envelopes this month and ReadyToAssign
+	// what is left of all income so far after everything assigned.
+	Assigned      money.Money
+	ReadyToAssign money.Money
+	Envelopes     []envelope
+	Rates         *rateUsage
+}
+
+// envelopeStatus works out the envelopes of a user in period from what the
+// last month closed before it carried forward, or from zero when none was
+// closed since envelope budgeting started, and the entries and assignments
+// after that.
+// Income adds to the money ready to assign, expenses draw their category's
+// envelope down, and whatever is left of an envelope, overspending
+// included, carries into the next month. Everything is in the user's base
+// currency, converted with the rates of the day.
+func envelopeStatus(ctx context.Context, q db.Querier, user db.User, period time.Time) (envelopeMonth, error) {
+	if !user.EnvelopeStart.Valid {
+		return envelopeMonth{}, errEnvelopeModeOff
+	}
+	start := user.EnvelopeStart.Time
+	if period.Before(start) {
+		return envelopeMonth{}, errEnvelopeBeforeStart
+	}
+
+	currency := user.BaseCurrency
+	converter := newCurrencyConverter(q, currency)
+	rates := newRateUsage()
+	convert := func(amount money.Money, date time.Time) (money.Money, error) {
+		converted, rate, err := converter.convert(ctx, amount, date)
+		if err != nil {
+			return money.Money{}, err
+		}
+		if rate != nil {
+			err = rates.add(*rate, amount, converted)
+		}
+		return converted, err
+	}
+
+	categories, err := q.ListUserCategories(ctx, user.ID)
+	if err != nil {
+		return envelopeMonth{}, err
+	}
+	zero := money.Money{Currency: currency}
+	var order []int32
+	envelopes := map[int32]*envelope{}
+	for _, category := range categories {
+		if category.Type != "debit" {
+			continue
+		}
+		order = append(order, category.ID)
+		envelopes[category.ID] = &envelope{
+			CategoryID:    category.ID,
+			CategoryTitle: category.Title,
+			Carried:       zero,
+			Assigned:      zero,
+			Activity:      zero,
+		}
+	}
+
+	month := envelopeMonth{
+		Period:        period,
+		Income:        zero,
+		Assigned:      zero,
+		ReadyToAssign: zero,
+		Rates:         rates,
+	}
+
+	previous, err := q.GetEnvelopeCloseBefore(ctx, db.GetEnvelopeCloseBeforeParams{
+		UserID: user.ID,
+		Period: period,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return envelopeMonth{}, err
+	}
+	if err == nil && !previous.Period.Before(start) {
+		start = previous.Period.AddDate(0, 1, 0)
+		balances, err := q.ListEnvelopeCloseBalances(ctx, db.ListEnvelopeCloseBalancesParams{
+			UserID: user.ID,
+			Period: previous.Period,
+		})
+		if err != nil {
+			return envelopeMonth{}, err
+		}
+		for _, balance := range balances {
+			// The base currency may have changed since the month closed.
+			amount, err := convert(money.Money{Amount: balance.Balance, Currency: balance.Currency}, start.AddDate(0, 0, -1))
+			if err != nil {
+				return envelopeMonth{}, err
+			}
+			if !balance.CategoryID.Valid {
+				month.ReadyToAssign.Amount += amount.Amount
+				continue
+			}
+			if env, ok := envelopes[balance.CategoryID.Int32]; ok {
+				env.Carried.Amount += amount.Amount
+			}
+		}
+	}
+
+	assignments, err := q.ListEnvelopeAssignments(ctx, db.ListEnvelopeAssignmentsParams{
+		UserID:     user.ID,
+		FromPeriod: start,
+		ToPeriod:   period,
+	})
+	if err != nil {
+		return envelopeMonth{}, err
+	}
+	for _, assignment := range assignments {
+		amount, err := convert(money.Money{Amount: assignment.Amount, Currency: assignment.Currency}, assignment.Period)
+		if err != nil {
+			return envelopeMonth{}, err
+		}
+		month.ReadyToAssign.Amount -= amount.Amount
+		env, ok := envelopes[assignment.CategoryID]
+		if !ok {
+			continue
+		}
+		if assignment.Period.Equal(period) {
+			env.Assigned.Amount += amount.Amount
+			month.Assigned.Amount += amount.Amount
+		} else {
+			env.Carried.Amount += amount.Amount
+		}
+	}
+
+	activity, err := q.GetEnvelopeActivity(ctx, db.GetEnvelopeActivityParams{
+		UserID:   user.ID,
+		FromDate: start,
+		ToDate:   period.AddDate(0, 1, 0),
+	})
+	if err != nil {
+		return envelopeMonth{}, err
+	}
+	for _, row := range activity {
+		amount, err := convert(money.Money{Amount: row.SumAmount, Currency: row.Currency}, row.Date)
+		if err != nil {
+			return envelopeMonth{}, err
+		}
+		thisMonth := periodOf(row.Date).Equal(period)
+		switch row.Type {
+		case "credit":
+			month.ReadyToAssign.Amount += amount.Amount
+			if thisMonth {
+				month.Income.Amount += amount.Amount
+			}
+		case "debit":
+			env, ok := envelopes[row.CategoryID]
+			if !ok {
+				continue
+			}
+			if thisMonth {
+				env.Activity.Amount += amount.Amount
+			} else {
+				env.Carried.Amount -= amount.Amount
+			}
+		}
+	}
+
+	month.Envelopes = make([]envelope, 0, len(order))
+	for _, categoryID := range order {
+		month.Envelopes = append(month.Envelopes, *envelopes[categoryID])
+	}
+
+	lastClose, err := q.GetLastEnvelopeClose(ctx, user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return envelopeMonth{}, err
+	}
+	month.Closed = err == nil && !period.After(lastClose.Period)
+	return month, nil
+}
+
+// envelopeClosedUntil is the last envelope month the user closed. ok is
+// false when they don't budget with envelopes or haven't closed one yet.
+func envelopeClosedUntil(ctx context.Context, q db.Querier, userID int32) (period time.Time, ok bool, err error) {
+	period, err = q.GetEnvelopeClosedUntil(ctx, userID)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return period, true, nil
+}
+
+// checkEnvelopeMonths rejects creating, changing or deleting entries dated
+// in a closed envelope month, since what that month carried forward is
+// settled. Callers pass the entries as written, inside the transaction, so
+// the write is rolled back with the error.
+func checkEnvelopeMonths(ctx context.Context, q db.Querier, accounts ...db.Account) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+	closedUntil, ok, err := envelopeClosedUntil(ctx, q, accounts[0].UserID)
+	if err != nil || !ok {
+		return err
+	}
+	for _, account := range accounts {
+		if !periodOf(account.Date).After(closedUntil) {
+			return errEnvelopeDateClosed
+		}
+	}
+	return nil
+}
+
+// openEnvelopeMonth checks that money can still be assigned in period.
+func openEnvelopeMonth(ctx context.Context, q db.Querier, user db.User, period time.Time) error {
+	if !user.EnvelopeStart.Valid {
+		return errEnvelopeModeOff
+	}
+	if period.Before(user.EnvelopeStart.Time) {
+		return errEnvelopeBeforeStart
+	}
+
+	lastClose, err := q.GetLastEnvelopeClose(ctx, user.ID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !period.After(lastClose.Period) {
+		return errEnvelopeMonthClosed
+	}
+	return nil
+}
+
+// setEnvelope changes what is assigned to the envelope of a category in
+// period, keeping the assignment in the user's base currency. It returns
+// the assignment as it was, if there was one, and as it is now.
+func setEnvelope(ctx context.Context, q *db.Queries, user db.User, categoryID int32, period time.Time, change func(assigned int64) int64) (*db.EnvelopeAssignment, db.EnvelopeAssignment, error) {
+	category, err := q.GetCategory(ctx, db.GetCategoryParams{
+		ID:     categoryID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return nil, db.EnvelopeAssignment{}, err
+	}
+	if category.Type != "debit" {
+		return nil, db.EnvelopeAssignment{}, errBudgetCategoryType
+	}
+
+	var before *db.EnvelopeAssignment
+	var assigned int64
+	existing, err := q.GetEnvelopeAssignmentForUpdate(ctx, db.GetEnvelopeAssignmentForUpdateParams{
+		UserID:     user.ID,
+		CategoryID: category.ID,
+		Period:     period,
+	})
+	switch {
+	case err == nil:
+		before = &existing
+		// The base currency may have changed since the money was
+		// assigned.
+		converted, _, err := newCurrencyConverter(q, user.BaseCurrency).convert(ctx,
+			money.Money{Amount: existing.Amount, Currency: existing.Currency}, period)
+		if err != nil {
+			return nil, db.EnvelopeAssignment{}, err
+		}
+		assigned = converted.Amount
+	case err != sql.ErrNoRows:
+		return nil, db.EnvelopeAssignment{}, err
+	}
+
+	after, err := q.SetEnvelopeAssignment(ctx, db.SetEnvelopeAssignmentParams{
+		UserID:     user.ID,
+		CategoryID: category.ID,
+		Period:     period,
+		Amount:     change(assigned),
+		Currency:   user.BaseCurrency,
+	})
+	if err != nil {
+		return nil, db.EnvelopeAssignment{}, err
+	}
+	return before, after, nil
+}
+
+func auditEnvelope(ctx *gin.Context, q db.Querier, before *db.EnvelopeAssignment, after db.EnvelopeAssignment) error {
+	event := auditEvent{
+		UserID:     after.UserID,
+		TargetType: auditTargetEnvelope,
+		TargetID:   after.ID,
+		Action:     auditActionCreate,
+		After:      after,
+	}
+	if before != nil {
+		event.Action = auditActionUpdate
+		event.Before = before
+	}
+	return recordAudit(ctx, q, event)
+}
+
+type setEnvelopeModeRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+	// Start is the first month of envelope budgeting, the current one
+	// unless given.
+	Start string `json:"start"`
+}
+
+func (server *Server) setEnvelopeMode(ctx *gin.Context) {
+	var req setEnvelopeModeRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var start sql.NullTime
+	if *req.Enabled {
+		start = sql.NullTime{Time: periodOf(time.Now()), Valid: true}
+		if req.Start != "" {
+			start.Time, err = parsePeriod(req.Start)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+		}
+	}
+
+	userID := authClaims(ctx).UserID
+	var user db.User
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetUserById(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.SetUserEnvelopeStart(ctx, db.SetUserEnvelopeStartParams{
+			ID:            userID,
+			EnvelopeStart: start,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetUser,
+			TargetID:   userID,
+			Action:     auditActionUpdate,
+			Before:     newUserResponse(before),
+			After:      newUserResponse(user),
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type envelopePeriodRequest struct {
+	Period string `uri:"period" binding:"required"`
+}
+
+type envelopeResponse struct {
+	CategoryID    int32  `json:"category_id"`
+	CategoryTitle string `json:"category_title"`
+	Carried       string `json:"carried"`
+	Assigned      string `json:"assigned"`
+	Activity      string `json:"activity"`
+	Available     string `json:"available"`
+}
+
+type envelopeMonthResponse struct {
+	Period        string             `json:"period"`
+	Currency      string             `json:"currency"`
+	Closed        bool               `json:"closed"`
+	Income        string             `json:"income"`
+	Assigned      string             `json:"assigned"`
+	ReadyToAssign string             `json:"ready_to_assign"`
+	Envelopes     []envelopeResponse `json:"envelopes"`
+	Rates         []rateResponse     `json:"rates"`
+}
+
+func newEnvelopeMonthResponse(month envelopeMonth) envelopeMonthResponse {
+	rsp := envelopeMonthResponse{
+		Period:        month.Period.Format(periodLayout),
+		Currency:      month.Income.Currency,
+		Closed:        month.Closed,
+		Income:        month.Income.String(),
+		Assigned:      month.Assigned.String(),
+		ReadyToAssign: month.ReadyToAssign.String(),
+		Envelopes:     make([]envelopeResponse, len(month.Envelopes)),
+		Rates:         month.Rates.response(),
+	}
+	for i, env := range month.Envelopes {
+		rsp.Envelopes[i] = envelopeResponse{
+			CategoryID:    env.CategoryID,
+			CategoryTitle: env.CategoryTitle,
+			Carried:       env.Carried.String(),
+			Assigned:      env.Assigned.String(),
+			Activity:      env.Activity.String(),
+			Available:     env.Available().String(),
+		}
+	}
+	return rsp
+}
+
+type envelopeAssignmentResponse struct {
+	ID         int32     `json:"id"`
+	CategoryID int32     `json:"category_id"`
+	Period     string    `json:"period"`
+	Amount     string    `json:"amount"`
+	Currency   string    `json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newEnvelopeAssignmentResponse(assignment db.EnvelopeAssignment) envelopeAssignmentResponse {
+	return envelopeAssignmentResponse{
+		ID:         assignment.ID,
+		CategoryID: assignment.CategoryID,
+		Period:     assignment.Period.Format(periodLayout),
+		Amount:     formatAmount(assignment.Amount, assignment.Currency),
+		Currency:   assignment.Currency,
+		CreatedAt:  assignment.CreatedAt,
+	}
+}
+
+type envelopeCloseBalanceResponse struct {
+	CategoryID int32  `json:"category_id"`
+	Balance    string `json:"balance"`
+	Currency   string `json:"currency"`
+}
+
+type envelopeCloseResponse struct {
+	Period        string                         `json:"period"`
+	ClosedAt      time.Time                      `json:"closed_at"`
+	ReadyToAssign *string                        `json:"ready_to_assign"`
+	Currency      string                         `json:"currency"`
+	Balances      []envelopeCloseBalanceResponse `json:"balances"`
+}
+
+// newEnvelopeCloseResponses pairs closed months with the balances they
+// carried forward.
+func newEnvelopeCloseResponses(closes []db.EnvelopeClose, balances []db.EnvelopeCloseBalance) []envelopeCloseResponse {
+	rsp := make([]envelopeCloseResponse, len(closes))
+	byPeriod := make(map[string]*envelopeCloseResponse, len(closes))
+	for i, closed := range closes {
+		rsp[i] = envelopeCloseResponse{
+			Period:   closed.Period.Format(periodLayout),
+			ClosedAt: closed.ClosedAt,
+			Balances: []envelopeCloseBalanceResponse{},
+		}
+		byPeriod[rsp[i].Period] = &rsp[i]
+	}
+	for _, balance := range balances {
+		closed, ok := byPeriod[balance.Period.Format(periodLayout)]
+		if !ok {
+			continue
+		}
+		amount := formatAmount(balance.Balance, balance.Currency)
+		if !balance.CategoryID.Valid {
+			closed.ReadyToAssign = &amount
+			closed.Currency = balance.Currency
+			continue
+		}
+		closed.Balances = append(closed.Balances, envelopeCloseBalanceResponse{
+			CategoryID: balance.CategoryID.Int32,
+			Balance:    amount,
+			Currency:   balance.Currency,
+		})
+	}
+	return rsp
+}
+
+func (server *Server) getEnvelopes(ctx *gin.Context) {
+	var uri envelopePeriodRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	period, err := parsePeriod(uri.Period)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	month, err := envelopeStatus(ctx, server.store, user, period)
+	if err != nil {
+		envelopeErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newEnvelopeMonthResponse(month))
+}
+
+type assignEnvelopeURI struct {
+	Period     string `uri:"period" binding:"required"`
+	CategoryID int32  `uri:"category_id" binding:"required,min=1"`
+}
+
+type assignEnvelopeRequest struct {
+	Amount string `json:"amount" binding:"required"`
+}
+
+// assignEnvelope sets how much of the money ready to assign goes into the
+// envelope of a category in the month. Lowering it puts money back.
+func (server *Server) assignEnvelope(ctx *gin.Context) {
+	var uri assignEnvelopeURI
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req assignEnvelopeRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	period, err := parsePeriod(uri.Period)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var month envelopeMonth
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		user, err := q.GetUserById(ctx, userID)
+		if err != nil {
+			return err
+		}
+		err = openEnvelopeMonth(ctx, q, user, period)
+		if err != nil {
+			return err
+		}
+		amount, err := parseAmount(req.Amount, user.BaseCurrency)
+		if err != nil {
+			return err
+		}
+
+		before, after, err := setEnvelope(ctx, q, user, uri.CategoryID, period, func(int64) int64 {
+			return amount.Amount
+		})
+		if err != nil {
+			return err
+		}
+		err = auditEnvelope(ctx, q, before, after)
+		if err != nil {
+			return err
+		}
+
+		month, err = envelopeStatus(ctx, q, user, period)
+		return err
+	})
+	if err != nil {
+		envelopeErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newEnvelopeMonthResponse(month))
+}
+
+type moveEnvelopeRequest struct {
+	FromCategoryID int32  `json:"from_category_id" binding:"required,min=1"`
+	ToCategoryID   int32  `json:"to_category_id" binding:"required,min=1"`
+	Amount         string `json:"amount" binding:"required"`
+}
+
+// moveEnvelope moves money between the envelopes of two categories, e.g.
+// to cover overspending.
+func (server *Server) moveEnvelope(ctx *gin.Context) {
+	var uri envelopePeriodRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req moveEnvelopeRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	period, err := parsePeriod(uri.Period)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.FromCategoryID == req.ToCategoryID {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errEnvelopeSameCategory))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var month envelopeMonth
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		user, err := q.GetUserById(ctx, userID)
+		if err != nil {
+			return err
+		}
+		err = openEnvelopeMonth(ctx, q, user, period)
+		if err != nil {
+			return err
+		}
+		amount, err := parseAmount(req.Amount, user.BaseCurrency)
+		if err != nil {
+			return err
+		}
+		if amount.Amount <= 0 {
+			return errEnvelopeMoveAmount
+		}
+
+		moves := []struct {
+			categoryID int32
+			delta      int64
+		}{
+			{req.FromCategoryID, -amount.Amount},
+			{req.ToCategoryID, amount.Amount},
+		}
+		for _, move := range moves {
+			delta := move.delta
+			before, after, err := setEnvelope(ctx, q, user, move.categoryID, period, func(assigned int64) int64 {
+				return assigned + delta
+			})
+			if err != nil {
+				return err
+			}
+			err = auditEnvelope(ctx, q, before, after)
+			if err != nil {
+				return err
+			}
+		}
+
+		month, err = envelopeStatus(ctx, q, user, period)
+		return err
+	})
+	if err != nil {
+		envelopeErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newEnvelopeMonthResponse(month))
+}
+
+// envelopeCloseSnapshot is a closed month with the balances it carried
+// forward.
+type envelopeCloseSnapshot struct {
+	db.EnvelopeClose
+	Balances []db.EnvelopeCloseBalance `json:"balances"`
+}
+
+// closeEnvelopeMonth closes a past month once the user is done with it.
+// Months close in order. What each envelope has left then, and the money
+// still ready to assign, is stored and rolls into the next month; entries
+// can no longer be dated in the month, so it stays as it was closed.
+func (server *Server) closeEnvelopeMonth(ctx *gin.Context) {
+	var uri envelopePeriodRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	period, err := parsePeriod(uri.Period)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !period.Before(periodOf(time.Now())) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errEnvelopeMonthNotOver))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var month envelopeMonth
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		user, err := q.GetUserById(ctx, userID)
+		if err != nil {
+			return err
+		}
+		err = openEnvelopeMonth(ctx, q, user, period)
+		if err != nil {
+			return err
+		}
+		if period.After(user.EnvelopeStart.Time) {
+			lastClose, err := q.GetLastEnvelopeClose(ctx, user.ID)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			if err == sql.ErrNoRows || !lastClose.Period.AddDate(0, 1, 0).Equal(period) {
+				return errEnvelopePreviousOpen
+			}
+		}
+
+		closed, err := q.CreateEnvelopeClose(ctx, db.CreateEnvelopeCloseParams{
+			UserID: user.ID,
+			Period: period,
+		})
+		if err != nil {
+			return err
+		}
+
+		month, err = envelopeStatus(ctx, q, user, period)
+		if err != nil {
+			return err
+		}
+
+		snapshot := envelopeCloseSnapshot{EnvelopeClose: closed}
+		pool, err := q.CreateEnvelopeCloseBalance(ctx, db.CreateEnvelopeCloseBalanceParams{
+			UserID:   user.ID,
+			Period:   period,
+			Balance:  month.ReadyToAssign.Amount,
+			Currency: month.ReadyToAssign.Currency,
+		})
+		if err != nil {
+			return err
+		}
+		snapshot.Balances = append(snapshot.Balances, pool)
+		for _, env := range month.Envelopes {
+			available := env.Available()
+			balance, err := q.CreateEnvelopeCloseBalance(ctx, db.CreateEnvelopeCloseBalanceParams{
+				UserID:     user.ID,
+				Period:     period,
+				CategoryID: sql.NullInt32{Int32: env.CategoryID, Valid: true},
+				Balance:    available.Amount,
+				Currency:   available.Currency,
+			})
+			if err != nil {
+				return err
+			}
+			snapshot.Balances = append(snapshot.Balances, balance)
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     user.ID,
+			TargetType: auditTargetEnvelope,
+			Action:     auditActionClose,
+			After:      snapshot,
+		})
+	})
+	if err != nil {
+		envelopeErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newEnvelopeMonthResponse(month))
+}
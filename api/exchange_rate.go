@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/money"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Rates missing for a pair are derived through the euro, which is what
+	// the ECB publishes everything against.
+	pivotCurrency = "EUR"
+
+	rateSourceCSV = "csv"
+	rateSourceECB = "ecb"
+
+	maxRatesFileSize = 32 << 20
+)
+
+type missingRateError struct {
+	From string
+	To   string
+	Date time.Time
+}
+
+func (err missingRateError) Error() string {
+	return fmt.Sprintf("no exchange rate from %s to %s on or before %s", err.From, err.To, err.Date.Format("2006-01-02"))
+}
+
+// exchangeRate is the rate used to turn From into To for entries dated on
+// or after Date.
+type exchangeRate struct {
+	From string
+	To   string
+	Date time.Time
+	Rate *big.Rat
+}
+
+type rateKey struct {
+	currency string
+	date     time.Time
+}
+
+// currencyConverter turns amounts into one currency with the rates in
+// effect on their dates. Lookups are cached, so create one per request.
+type currencyConverter struct {
+	q     db.Querier
+	to    string
+	rates map[rateKey]exchangeRate
+}
+
+func newCurrencyConverter(q db.Querier, to string) *currencyConverter {
+	return &currencyConverter{q: q, to: to, rates: map[rateKey]exchangeRate{}}
+}
+
+// convert returns m in the converter currency, along with the rate used.
+// The rate is nil when m already is in that currency.
+func (converter *currencyConverter) convert(ctx context.Context, m money.Money, date time.Time) (money.Money, *exchangeRate, error) {
+	if m.Currency == converter.to {
+		return m, nil, nil
+	}
+
+	rate, err := converter.rate(ctx, m.Currency, date)
+	if err != nil {
+		return money.Money{}, nil, err
+	}
+	converted, err := money.Convert(m, converter.to, rate.Rate)
+	if err != nil {
+		return money.Money{}, nil, err
+	}
+	return converted, &rate, nil
+}
+
+func (converter *currencyConverter) rate(ctx context.Context, from string, date time.Time) (exchangeRate, error) {
+	key := rateKey{currency: from, date: date}
+	if rate, ok := converter.rates[key]; ok {
+		return rate, nil
+	}
+
+	rate, found, err := converter.lookup(ctx, from, converter.to, date)
+	if err != nil {
+		return exchangeRate{}, err
+	}
+	if !found && from != pivotCurrency && converter.to != pivotCurrency {
+		rate, found, err = converter.lookupThroughPivot(ctx, from, date)
+		if err != nil {
+			return exchangeRate{}, err
+		}
+	}
+	if !found {
+		return exchangeRate{}, missingRateError{From: from, To: converter.to, Date: date}
+	}
+
+	converter.rates[key] = rate
+	return rate, nil
+}
+
+func (converter *currencyConverter) lookupThroughPivot(ctx context.Context, from string, date time.Time) (exchangeRate, bool, error) {
+	toPivot, found, err := converter.lookup(ctx, from, pivotCurrency, date)
+	if err != nil || !found {
+		return exchangeRate{}, false, err
+	}
+	fromPivot, found, err := converter.lookup(ctx, pivotCurrency, converter.to, date)
+	if err != nil || !found {
+		return exchangeRate{}, false, err
+	}
+
+	rate := exchangeRate{
+		From: from,
+		To:   converter.to,
+		Date: toPivot.Date,
+		Rate: new(big.Rat).Mul(toPivot.Rate, fromPivot.Rate),
+	}
+	if fromPivot.Date.Before(rate.Date) {
+		rate.Date = fromPivot.Date
+	}
+	return rate, true, nil
+}
+
+// lookup finds the latest rate for the pair on or before date, whichever
+// way round it was stored.
+func (converter *currencyConverter) lookup(ctx context.Context, from, to string, date time.Time) (exchangeRate, bool, error) {
+	stored, err := converter.q.GetExchangeRate(ctx, db.GetExchangeRateParams{
+		FromCurrency: from,
+		ToCurrency:   to,
+		Date:         date,
+	})
+	if err == sql.ErrNoRows {
+		return exchangeRate{}, false, nil
+	}
+	if err != nil {
+		return exchangeRate{}, false, err
+	}
+
+	rate, err := money.ParseRate(stored.Rate)
+	if err != nil {
+		return exchangeRate{}, false, err
+	}
+	if stored.BaseCurrency != from {
+		rate.Inv(rate)
+	}
+	return exchangeRate{From: from, To: to, Date: stored.Date, Rate: rate}, true, nil
+}
+
+// rateResponse tells which rate a report used and for how much money.
+type rateResponse struct {
+	From            string    `json:"from"`
+	To              string    `json:"to"`
+	Date            time.Time `json:"date"`
+	Rate            string    `json:"rate"`
+	Amount          string    `json:"amount"`
+	ConvertedAmount string    `json:"converted_amount"`
+}
+
+// rateUsage adds up the amounts converted with each rate so reports can
+// show them next to their totals.
+type rateUsage struct {
+	order []string
+	usage map[string]*rateUsageEntry
+}
+
+type rateUsageEntry struct {
+	rate      exchangeRate
+	amount    money.Money
+	converted money.Money
+}
+
+func newRateUsage() *rateUsage {
+	return &rateUsage{usage: map[string]*rateUsageEntry{}}
+}
+
+func (usage *rateUsage) add(rate exchangeRate, amount, converted money.Money) error {
+	key := fmt.Sprintf("%s/%s/%s", rate.From, rate.Date.Format("2006-01-02"), rate.Rate.RatString())
+	entry, ok := usage.usage[key]
+	if !ok {
+		usage.order = append(usage.order, key)
+		usage.usage[key] = &rateUsageEntry{rate: rate, amount: amount, converted: converted}
+		return nil
+	}
+
+	var err error
+	entry.amount, err = entry.amount.Add(amount)
+	if err != nil {
+		return err
+	}
+	entry.converted, err = entry.converted.Add(converted)
+	return err
+}
+
+func (usage *rateUsage) response() []rateResponse {
+	rsp := make([]rateResponse, 0, len(usage.order))
+	for _, key := range usage.order {
+		entry := usage.usage[key]
+		rsp = append(rsp, rateResponse{
+			From:            entry.rate.From,
+			To:              entry.rate.To,
+			Date:            entry.rate.Date,
+			Rate:            money.FormatRate(entry.rate.Rate),
+			Amount:          entry.amount.String(),
+			ConvertedAmount: entry.converted.String(),
+		})
+	}
+	return rsp
+}
+
+type importExchangeRatesRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ecb"`
+}
+
+type importExchangeRatesResponse struct {
+	Imported int        `json:"imported"`
+	From     *time.Time `json:"from"`
+	To       *time.Time `json:"to"`
+}
+
+// importExchangeRates loads rates from the request body, either a
+// date,base,quote,rate CSV or an ECB eurofxref XML file. The format comes
+// from ?format= or else from the content type. Rates already stored for
+// the same pair and day are replaced.
+func (server *Server) importExchangeRates(ctx *gin.Context) {
+	var req importExchangeRatesRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = rateSourceCSV
+		if strings.Contains(ctx.ContentType(), "xml") {
+			format = rateSourceECB
+		}
+	}
+
+	body := io.Reader(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxRatesFileSize))
+	var rates []money.Rate
+	switch format {
+	case rateSourceECB:
+		rates, err = money.ParseECBRates(body)
+	default:
+		rates, err = money.ParseRatesCSV(body)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var rsp importExchangeRatesResponse
+	for i := range rates {
+		if rsp.From == nil || rates[i].Date.Before(*rsp.From) {
+			rsp.From = &rates[i].Date
+		}
+		if rsp.To == nil || rates[i].Date.After(*rsp.To) {
+			rsp.To = &rates[i].Date
+		}
+	}
+	rsp.Imported = len(rates)
+
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		for _, rate := range rates {
+			_, err := q.UpsertExchangeRate(ctx, db.UpsertExchangeRateParams{
+				BaseCurrency:  rate.Base,
+				QuoteCurrency: rate.Quote,
+				Date:          rate.Date,
+				Rate:          rate.Decimal(),
+				Source:        format,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			TargetType: auditTargetExchangeRates,
+			Action:     auditActionImport,
+			After:      rsp,
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,652 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/money"
+	"github.com/GustavoNoronha0/gofinance-backend/recurrence"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultGoalHistory is how many months of contributions the pace of a
+// goal is taken from.
+const defaultGoalHistory = 6
+
+var (
+	errGoalTarget       = errors.New("goal target amount must be greater than zero")
+	errGoalContribution = errors.New("contribution amount must be greater than zero")
+	errGoalNoWallet     = errors.New("contribution needs a wallet since the goal has none linked")
+	errGoalNoCategory   = errors.New("contribution needs a category since the goal has none linked")
+)
+
+func goalErrorResponse(ctx *gin.Context, err error) {
+	var amountErr amountError
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case err == errGoalTarget, err == errGoalContribution, err == errGoalNoWallet, err == errGoalNoCategory,
+		err == errWalletArchived, err == errWalletCurrencyMismatch, errors.As(err, &amountErr):
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	case err == errEnvelopeDateClosed:
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		reportError(ctx, err)
+	}
+}
+
+type goalResponse struct {
+	ID           int32      `json:"id"`
+	Title        string     `json:"title"`
+	TargetAmount string     `json:"target_amount"`
+	Currency     string     `json:"currency"`
+	TargetDate   *time.Time `json:"target_date"`
+	WalletID     *int32     `json:"wallet_id"`
+	CategoryID   *int32     `json:"category_id"`
+	Saved        string     `json:"saved"`
+	Remaining    string     `json:"remaining"`
+	PercentSaved string     `json:"percent_saved"`
+	Reached      bool       `json:"reached"`
+	// RequiredMonthly is what has to be saved each month, this one
+	// included, to reach the target by its date.
+	RequiredMonthly *string `json:"required_monthly"`
+	// AverageMonthly is the pace of the last months and ProjectedDate when
+	// the goal is reached at that pace.
+	AverageMonthly string         `json:"average_monthly"`
+	ProjectedDate  *time.Time     `json:"projected_date"`
+	Rates          []rateResponse `json:"rates"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// goalRecord is a goal as stored, without the progress worked out from
+// its contributions.
+type goalRecord struct {
+	ID           int32      `json:"id"`
+	Title        string     `json:"title"`
+	TargetAmount string     `json:"target_amount"`
+	Currency     string     `json:"currency"`
+	TargetDate   *time.Time `json:"target_date"`
+	WalletID     *int32     `json:"wallet_id"`
+	CategoryID   *int32     `json:"category_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func newGoalRecord(goal db.Goal) goalRecord {
+	rsp := goalRecord{
+		ID:           goal.ID,
+		Title:        goal.Title,
+		TargetAmount: formatAmount(goal.TargetAmount, goal.Currency),
+		Currency:     goal.Currency,
+		CreatedAt:    goal.CreatedAt,
+	}
+	if goal.TargetDate.Valid {
+		rsp.TargetDate = &goal.TargetDate.Time
+	}
+	if goal.WalletID.Valid {
+		rsp.WalletID = &goal.WalletID.Int32
+	}
+	if goal.CategoryID.Valid {
+		rsp.CategoryID = &goal.CategoryID.Int32
+	}
+	return rsp
+}
+
+// newGoalResponse reports how far a goal is, given what was saved in total
+// and over the last months of history.
+func newGoalResponse(goal db.Goal, saved, recent int64, history int, today time.Time) goalResponse {
+	remaining := goal.TargetAmount - saved
+	if remaining < 0 {
+		remaining = 0
+	}
+	average := recent / int64(history)
+
+	rsp := goalResponse{
+		ID:             goal.ID,
+		Title:          goal.Title,
+		TargetAmount:   formatAmount(goal.TargetAmount, goal.Currency),
+		Currency:       goal.Currency,
+		Saved:          formatAmount(saved, goal.Currency),
+		Remaining:      formatAmount(remaining, goal.Currency),
+		PercentSaved:   big.NewRat(saved*100, goal.TargetAmount).FloatString(2),
+		Reached:        remaining == 0,
+		AverageMonthly: formatAmount(average, goal.Currency),
+		Rates:          []rateResponse{},
+		CreatedAt:      goal.CreatedAt,
+	}
+	if goal.TargetDate.Valid {
+		rsp.TargetDate = &goal.TargetDate.Time
+	}
+	if goal.WalletID.Valid {
+		rsp.WalletID = &goal.WalletID.Int32
+	}
+	if goal.CategoryID.Valid {
+		rsp.CategoryID = &goal.CategoryID.Int32
+	}
+	if rsp.Reached {
+		return rsp
+	}
+
+	if goal.TargetDate.Valid {
+		// Past the date, whatever is missing is due now.
+		months := 1
+		if !goal.TargetDate.Time.Before(today) {
+			months += monthsBetween(today, goal.TargetDate.Time)
+		}
+		required := formatAmount(ceilDiv(remaining, int64(months)), goal.Currency)
+		rsp.RequiredMonthly = &required
+	}
+	if average > 0 {
+		projected := addMonths(today, int(ceilDiv(remaining, average)))
+		rsp.ProjectedDate = &projected
+	}
+	return rsp
+}
+
+func ceilDiv(a, b int64) int64 {
+	return (a + b - 1) / b
+}
+
+// goalResponses adds up the contributions to each goal in its currency,
+// converted with the rates of the day they were made.
+func goalResponses(ctx context.Context, q db.Querier, userID int32, goals []db.Goal, history int) ([]goalResponse, error) {
+	goalIDs := make([]int32, 0, len(goals))
+	for _, goal := range goals {
+		goalIDs = append(goalIDs, goal.ID)
+	}
+	contributions, err := q.GetGoalContributions(ctx, db.GetGoalContributionsParams{
+		UserID:  userID,
+		GoalIds: goalIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	today := recurrence.Date(time.Now())
+	since := addMonths(today, -history)
+	rsp := make([]goalResponse, 0, len(goals))
+	for _, goal := range goals {
+		converter := newCurrencyConverter(q, goal.Currency)
+		rates := newRateUsage()
+		var saved, recent int64
+		for _, contribution := range contributions {
+			if contribution.GoalID != goal.ID {
+				continue
+			}
+			amount := money.Money{Amount: contribution.SumAmount, Currency: contribution.Currency}
+			converted, rate, err := converter.convert(ctx, amount, contribution.Date)
+			if err != nil {
+				return nil, err
+			}
+			if rate != nil {
+				err = rates.add(*rate, amount, converted)
+				if err != nil {
+					return nil, err
+				}
+			}
+			saved += converted.Amount
+			if contribution.Date.After(since) && !contribution.Date.After(today) {
+				recent += converted.Amount
+			}
+		}
+
+		goalRsp := newGoalResponse(goal, saved, recent, history, today)
+		goalRsp.Rates = rates.response()
+		rsp = append(rsp, goalRsp)
+	}
+	return rsp, nil
+}
+
+// goalLinks checks that the wallet and category a goal is linked to belong
+// to the user. Zero IDs leave the goal unlinked.
+func goalLinks(ctx *gin.Context, q *db.Queries, userID, walletID, categoryID int32) (sql.NullInt32, sql.NullInt32, *db.Wallet, error) {
+	var wallet *db.Wallet
+	if walletID != 0 {
+		found, err := transactionWallet(ctx, q, walletID, userID, "")
+		if err != nil {
+			return sql.NullInt32{}, sql.NullInt32{}, nil, err
+		}
+		wallet = &found
+	}
+	if categoryID != 0 {
+		_, err := q.GetCategory(ctx, db.GetCategoryParams{
+			ID:     categoryID,
+			UserID: userID,
+		})
+		if err != nil {
+			return sql.NullInt32{}, sql.NullInt32{}, nil, err
+		}
+	}
+	return sql.NullInt32{Int32: walletID, Valid: walletID != 0},
+		sql.NullInt32{Int32: categoryID, Valid: categoryID != 0},
+		wallet, nil
+}
+
+type createGoalRequest struct {
+	Title        string    `json:"title" binding:"required"`
+	TargetAmount string    `json:"target_amount" binding:"required"`
+	Currency     string    `json:"currency" binding:"omitempty,len=3"`
+	TargetDate   time.Time `json:"target_date"`
+	WalletID     int32     `json:"wallet_id" binding:"omitempty,min=1"`
+	CategoryID   int32     `json:"category_id" binding:"omitempty,min=1"`
+}
+
+// createGoal starts a savings goal. It is in the currency of its wallet
+// when it has one, otherwise in the user's base currency, unless another
+// one is given.
+func (server *Server) createGoal(ctx *gin.Context) {
+	var req createGoalRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var goal db.Goal
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		walletID, categoryID, wallet, err := goalLinks(ctx, q, userID, req.WalletID, req.CategoryID)
+		if err != nil {
+			return err
+		}
+
+		currency := strings.ToUpper(req.Currency)
+		switch {
+		case currency != "":
+		case wallet != nil:
+			currency = wallet.Currency
+		default:
+			user, err := q.GetUserById(ctx, userID)
+			if err != nil {
+				return err
+			}
+			currency = user.BaseCurrency
+		}
+		target, err := parseAmount(req.TargetAmount, currency)
+		if err != nil {
+			return err
+		}
+		if target.Amount <= 0 {
+			return errGoalTarget
+		}
+
+		goal, err = q.CreateGoal(ctx, db.CreateGoalParams{
+			UserID:       userID,
+			Title:        req.Title,
+			TargetAmount: target.Amount,
+			Currency:     target.Currency,
+			TargetDate:   sql.NullTime{Time: recurrence.Date(req.TargetDate), Valid: !req.TargetDate.IsZero()},
+			WalletID:     walletID,
+			CategoryID:   categoryID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetGoal,
+			TargetID:   goal.ID,
+			Action:     auditActionCreate,
+			After:      goal,
+		})
+	})
+	if err != nil {
+		goalErrorResponse(ctx, err)
+		return
+	}
+
+	rsp, err := goalResponses(ctx, server.store, userID, []db.Goal{goal}, defaultGoalHistory)
+	if err != nil {
+		goalErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp[0])
+}
+
+type goalHistoryRequest struct {
+	// Months is how far back contributions count for the projection.
+	Months int `form:"months" binding:"omitempty,min=1,max=60"`
+}
+
+func (req goalHistoryRequest) history() int {
+	if req.Months == 0 {
+		return defaultGoalHistory
+	}
+	return req.Months
+}
+
+func (server *Server) listGoals(ctx *gin.Context) {
+	var req goalHistoryRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	goals, err := server.store.ListGoals(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := goalResponses(ctx, server.store, userID, goals, req.history())
+	if err != nil {
+		goalErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type goalRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getGoal(ctx *gin.Context) {
+	var uri goalRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req goalHistoryRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	goal, err := server.store.GetGoal(ctx, db.GetGoalParams{
+		ID:     uri.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		goalErrorResponse(ctx, err)
+		return
+	}
+
+	rsp, err := goalResponses(ctx, server.store, userID, []db.Goal{goal}, req.history())
+	if err != nil {
+		goalErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp[0])
+}
+
+type updateGoalRequest struct {
+	Title        *string    `json:"title" binding:"omitempty,min=1"`
+	TargetAmount *string    `json:"target_amount"`
+	TargetDate   *time.Time `json:"target_date"`
+	// WalletID and CategoryID unlink the goal when set to 0.
+	WalletID   *int32 `json:"wallet_id" binding:"omitempty,min=0"`
+	CategoryID *int32 `json:"category_id" binding:"omitempty,min=0"`
+}
+
+func (server *Server) updateGoal(ctx *gin.Context) {
+	var uri goalRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateGoalRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var goal db.Goal
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetGoalForUpdate(ctx, db.GetGoalForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		arg := db.UpdateGoalParams{
+			ID:           before.ID,
+			UserID:       userID,
+			Title:        before.Title,
+			TargetAmount: before.TargetAmount,
+			TargetDate:   before.TargetDate,
+			WalletID:     before.WalletID,
+			CategoryID:   before.CategoryID,
+		}
+		if req.Title != nil {
+			arg.Title = *req.Title
+		}
+		if req.TargetAmount != nil {
+			target, err := parseAmount(*req.TargetAmount, before.Currency)
+			if err != nil {
+				return err
+			}
+			if target.Amount <= 0 {
+				return errGoalTarget
+			}
+			arg.TargetAmount = target.Amount
+		}
+		if req.TargetDate != nil {
+			arg.TargetDate = sql.NullTime{Time: recurrence.Date(*req.TargetDate), Valid: !req.TargetDate.IsZero()}
+		}
+		// Only links that change are checked, so a goal whose wallet was
+		// archived since can still be renamed.
+		var walletID, categoryID int32
+		if req.WalletID != nil {
+			walletID = *req.WalletID
+		}
+		if req.CategoryID != nil {
+			categoryID = *req.CategoryID
+		}
+		wallet, category, _, err := goalLinks(ctx, q, userID, walletID, categoryID)
+		if err != nil {
+			return err
+		}
+		if req.WalletID != nil {
+			arg.WalletID = wallet
+		}
+		if req.CategoryID != nil {
+			arg.CategoryID = category
+		}
+
+		goal, err = q.UpdateGoal(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetGoal,
+			TargetID:   goal.ID,
+			Action:     auditActionUpdate,
+			Before:     before,
+			After:      goal,
+		})
+	})
+	if err != nil {
+		goalErrorResponse(ctx, err)
+		return
+	}
+
+	rsp, err := goalResponses(ctx, server.store, userID, []db.Goal{goal}, defaultGoalHistory)
+	if err != nil {
+		goalErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp[0])
+}
+
+// deleteGoal removes a goal. Its contributions stay as ordinary entries.
+func (server *Server) deleteGoal(ctx *gin.Context) {
+	var uri goalRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetGoalForUpdate(ctx, db.GetGoalForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteGoal(ctx, db.DeleteGoalParams{
+			ID:     before.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetGoal,
+			TargetID:   before.ID,
+			Action:     auditActionDelete,
+			Before:     before,
+		})
+	})
+	if err != nil {
+		goalErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type contributeToGoalRequest struct {
+	Amount      string    `json:"amount" binding:"required"`
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	// WalletID and CategoryID default to the ones linked to the goal.
+	WalletID   int32 `json:"wallet_id" binding:"omitempty,min=1"`
+	CategoryID int32 `json:"category_id" binding:"omitempty,min=1"`
+}
+
+// contributeToGoal records money put towards a goal as an entry linked to
+// it, dated today unless another date is given.
+func (server *Server) contributeToGoal(ctx *gin.Context) {
+	var uri goalRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req contributeToGoalRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	date := recurrence.Date(req.Date)
+	if date.IsZero() {
+		date = recurrence.Date(time.Now())
+	}
+
+	userID := authClaims(ctx).UserID
+	var account db.Account
+	var notifications []db.Notification
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		goal, err := q.GetGoal(ctx, db.GetGoalParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		walletID, categoryID := req.WalletID, req.CategoryID
+		if walletID == 0 {
+			if !goal.WalletID.Valid {
+				return errGoalNoWallet
+			}
+			walletID = goal.WalletID.Int32
+		}
+		if categoryID == 0 {
+			if !goal.CategoryID.Valid {
+				return errGoalNoCategory
+			}
+			categoryID = goal.CategoryID.Int32
+		}
+		wallet, err := transactionWallet(ctx, q, walletID, userID, "")
+		if err != nil {
+			return err
+		}
+		category, err := q.GetCategory(ctx, db.GetCategoryParams{
+			ID:     categoryID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		amount, err := parseAmount(req.Amount, wallet.Currency)
+		if err != nil {
+			return err
+		}
+		if amount.Amount <= 0 {
+			return errGoalContribution
+		}
+
+		title := fmt.Sprintf("Contribution to %s", goal.Title)
+		description := req.Description
+		if description == "" {
+			description = title
+		}
+		account, err = q.CreateGoalContribution(ctx, db.CreateGoalContributionParams{
+			UserID:      userID,
+			WalletID:    wallet.ID,
+			CategoryID:  category.ID,
+			Title:       title,
+			Type:        category.Type,
+			Description: description,
+			Amount:      amount.Amount,
+			Currency:    amount.Currency,
+			Date:        date,
+			GoalID:      goal.ID,
+		})
+		if err != nil {
+			return err
+		}
+		err = checkEnvelopeMonths(ctx, q, account)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetAccount,
+			TargetID:   account.ID,
+			Action:     auditActionCreate,
+			After:      account,
+		})
+		if err != nil {
+			return err
+		}
+
+		notifications, err = checkBudgetThresholds(ctx, q, account)
+		return err
+	})
+	if err != nil {
+		goalErrorResponse(ctx, err)
+		return
+	}
+
+	server.deliverNotifications(userID, notifications)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
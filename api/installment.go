@@ -0,0 +1,767 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/money"
+	"github.com/GustavoNoronha0/gofinance-backend/recurrence"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	planStatusActive    = "active"
+	planStatusPaidOff   = "paid_off"
+	planStatusCancelled = "cancelled"
+
+	remainderOnFirst = "first"
+	remainderOnLast  = "last"
+)
+
+var (
+	errPlanNotActive       = errors.New("installment plan was already paid off or cancelled")
+	errPlanAmount          = errors.New("installment plan amount must be greater than zero")
+	errPlanAlreadyPaid     = errors.New("installment plan has no unpaid installments")
+	errPlanPaidInstallment = errors.New("installment plan can't have fewer installments than the ones already paid")
+	errPlanPaidAmount      = errors.New("installment plan can't cost less than what was already paid")
+)
+
+type installmentResponse struct {
+	AccountID int32     `json:"account_id"`
+	Number    int32     `json:"number"`
+	Date      time.Time `json:"date"`
+	Amount    string    `json:"amount"`
+	Paid      bool      `json:"paid"`
+}
+
+type installmentPlanResponse struct {
+	ID           int32                 `json:"id"`
+	WalletID     int32                 `json:"wallet_id"`
+	CategoryID   int32                 `json:"category_id"`
+	Title        string                `json:"title"`
+	Type         string                `json:"type"`
+	Description  string                `json:"description"`
+	Amount       string                `json:"amount"`
+	Currency     string                `json:"currency"`
+	Installments int32                 `json:"installments"`
+	FirstDueDate time.Time             `json:"first_due_date"`
+	InterestRate string                `json:"interest_rate"`
+	RemainderOn  string                `json:"remainder_on"`
+	Status       string                `json:"status"`
+	Total        string                `json:"total"`
+	Paid         string                `json:"paid"`
+	Remaining    string                `json:"remaining"`
+	Entries      []installmentResponse `json:"entries"`
+	CreatedAt    time.Time             `json:"created_at"`
+}
+
+// newInstallmentPlanResponse counts installments due up to today as paid,
+// the way a card statement closes them.
+func newInstallmentPlanResponse(plan db.InstallmentPlan, installments []db.Account, today time.Time) installmentPlanResponse {
+	rsp := installmentPlanResponse{
+		ID:           plan.ID,
+		WalletID:     plan.WalletID,
+		CategoryID:   plan.CategoryID,
+		Title:        plan.Title,
+		Type:         plan.Type,
+		Description:  plan.Description,
+		Amount:       formatAmount(plan.Amount, plan.Currency),
+		Currency:     plan.Currency,
+		Installments: plan.Installments,
+		FirstDueDate: plan.FirstDueDate,
+		InterestRate: plan.InterestRate,
+		RemainderOn:  plan.RemainderOn,
+		Status:       plan.Status,
+		Entries:      make([]installmentResponse, 0, len(installments)),
+		CreatedAt:    plan.CreatedAt,
+	}
+
+	var total, paid int64
+	for _, installment := range installments {
+		isPaid := !installment.Date.After(today)
+		total += installment.Amount
+		if isPaid {
+			paid += installment.Amount
+		}
+		rsp.Entries = append(rsp.Entries, installmentResponse{
+			AccountID: installment.ID,
+			Number:    installment.InstallmentNumber.Int32,
+			Date:      installment.Date,
+			Amount:    formatAmount(installment.Amount, installment.Currency),
+			Paid:      isPaid,
+		})
+	}
+	rsp.Total = formatAmount(total, plan.Currency)
+	rsp.Paid = formatAmount(paid, plan.Currency)
+	rsp.Remaining = formatAmount(total-paid, plan.Currency)
+	return rsp
+}
+
+func installmentErrorResponse(ctx *gin.Context, err error) {
+	var amountErr amountError
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case err == errAccountTypeMismatch, err == errWalletArchived, err == errWalletCurrencyMismatch,
+		err == errPlanAmount, err == errPlanPaidInstallment, err == errPlanPaidAmount,
+		err == money.ErrInvalidInterest, errors.As(err, &amountErr):
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	case err == errPlanNotActive, err == errPlanAlreadyPaid, err == errEnvelopeDateClosed:
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+// addMonths moves date n months ahead, keeping it in the target month:
+// a purchase due on the 31st falls on the last day of shorter months.
+func addMonths(date time.Time, n int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// monthsBetween counts the whole months from one date to a later one.
+func monthsBetween(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+	if to.Day() < from.Day() {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+// interestRate reads the stored monthly percentage back as a fraction.
+func interestRate(plan db.InstallmentPlan) (*big.Rat, error) {
+	return money.ParseInterest(plan.InterestRate)
+}
+
+type scheduledInstallment struct {
+	Number int32
+	Date   time.Time
+	Amount money.Money
+}
+
+// installmentSchedule lays out the installments of a plan that are still
+// to be created. Paid installments stay as they are, so what's left of the
+// plan's total is spread over the remaining ones.
+func installmentSchedule(plan db.InstallmentPlan, paid []db.Account) ([]scheduledInstallment, error) {
+	rate, err := interestRate(plan)
+	if err != nil {
+		return nil, err
+	}
+	total, err := money.InstallmentTotal(money.Money{Amount: plan.Amount, Currency: plan.Currency}, int(plan.Installments), rate)
+	if err != nil {
+		return nil, err
+	}
+
+	var paidCount int32
+	for _, installment := range paid {
+		total.Amount -= installment.Amount
+		if installment.InstallmentNumber.Int32 > paidCount {
+			paidCount = installment.InstallmentNumber.Int32
+		}
+	}
+	if paidCount > plan.Installments {
+		return nil, errPlanPaidInstallment
+	}
+	if total.Amount < 0 {
+		return nil, errPlanPaidAmount
+	}
+	if paidCount == plan.Installments {
+		if total.Amount != 0 {
+			return nil, errPlanPaidInstallment
+		}
+		return nil, nil
+	}
+
+	parts, err := total.Split(int(plan.Installments-paidCount), plan.RemainderOn == remainderOnFirst)
+	if err != nil {
+		return nil, err
+	}
+	schedule := make([]scheduledInstallment, 0, len(parts))
+	for i, part := range parts {
+		number := paidCount + int32(i) + 1
+		schedule = append(schedule, scheduledInstallment{
+			Number: number,
+			Date:   addMonths(plan.FirstDueDate, int(number-1)),
+			Amount: part,
+		})
+	}
+	return schedule, nil
+}
+
+func installmentTitle(plan db.InstallmentPlan, number int32) string {
+	return fmt.Sprintf("%s (%d/%d)", plan.Title, number, plan.Installments)
+}
+
+func createInstallments(ctx *gin.Context, q *db.Queries, plan db.InstallmentPlan, schedule []scheduledInstallment) error {
+	for _, installment := range schedule {
+		account, err := q.CreateInstallment(ctx, db.CreateInstallmentParams{
+			UserID:            plan.UserID,
+			WalletID:          plan.WalletID,
+			CategoryID:        plan.CategoryID,
+			Title:             installmentTitle(plan, installment.Number),
+			Type:              plan.Type,
+			Description:       plan.Description,
+			Amount:            installment.Amount.Amount,
+			Currency:          installment.Amount.Currency,
+			Date:              installment.Date,
+			InstallmentPlanID: plan.ID,
+			InstallmentNumber: installment.Number,
+		})
+		if err != nil {
+			return err
+		}
+		err = checkEnvelopeMonths(ctx, q, account)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, q, auditEvent{
+			UserID:     plan.UserID,
+			TargetType: auditTargetAccount,
+			TargetID:   account.ID,
+			Action:     auditActionCreate,
+			After:      account,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeUnpaidInstallments deletes the installments due after paidUntil
+// and returns them.
+func removeUnpaidInstallments(ctx *gin.Context, q *db.Queries, plan db.InstallmentPlan, paidUntil time.Time) ([]db.Account, error) {
+	removed, err := q.DeleteUnpaidInstallments(ctx, db.DeleteUnpaidInstallmentsParams{
+		InstallmentPlanID: plan.ID,
+		PaidUntil:         paidUntil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = checkEnvelopeMonths(ctx, q, removed...)
+	if err != nil {
+		return nil, err
+	}
+	return removed, auditDeletedAccounts(ctx, q, removed)
+}
+
+type createInstallmentPlanRequest struct {
+	WalletID     int32     `json:"wallet_id" binding:"required"`
+	CategoryID   int32     `json:"category_id" binding:"required"`
+	Title        string    `json:"title" binding:"required"`
+	Type         string    `json:"type" binding:"required,oneof=credit debit"`
+	Description  string    `json:"description" binding:"required"`
+	Amount       string    `json:"amount" binding:"required"`
+	Currency     string    `json:"currency" binding:"omitempty,len=3"`
+	Installments int32     `json:"installments" binding:"required,min=1,max=420"`
+	FirstDueDate time.Time `json:"first_due_date" binding:"required"`
+	// InterestRate is a monthly percentage, "1.99" for 1.99% a month.
+	InterestRate string `json:"interest_rate"`
+	RemainderOn  string `json:"remainder_on" binding:"omitempty,oneof=first last"`
+}
+
+// createInstallmentPlan records a purchase split in monthly installments
+// and creates one entry for each of them.
+func (server *Server) createInstallmentPlan(ctx *gin.Context) {
+	var req createInstallmentPlanRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.RemainderOn == "" {
+		req.RemainderOn = remainderOnLast
+	}
+	rate, err := money.ParseInterest(req.InterestRate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var plan db.InstallmentPlan
+	var installments []db.Account
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		category, err := q.GetCategory(ctx, db.GetCategoryParams{
+			ID:     req.CategoryID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		if category.Type != req.Type {
+			return errAccountTypeMismatch
+		}
+		wallet, err := transactionWallet(ctx, q, req.WalletID, userID, strings.ToUpper(req.Currency))
+		if err != nil {
+			return err
+		}
+		amount, err := parseAmount(req.Amount, wallet.Currency)
+		if err != nil {
+			return err
+		}
+		if amount.Amount <= 0 {
+			return errPlanAmount
+		}
+
+		plan, err = q.CreateInstallmentPlan(ctx, db.CreateInstallmentPlanParams{
+			UserID:       userID,
+			WalletID:     wallet.ID,
+			CategoryID:   category.ID,
+			Title:        req.Title,
+			Type:         req.Type,
+			Description:  req.Description,
+			Amount:       amount.Amount,
+			Currency:     amount.Currency,
+			Installments: req.Installments,
+			FirstDueDate: recurrence.Date(req.FirstDueDate),
+			InterestRate: money.FormatRate(new(big.Rat).Mul(rate, big.NewRat(100, 1))),
+			RemainderOn:  req.RemainderOn,
+		})
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetInstallmentPlan,
+			TargetID:   plan.ID,
+			Action:     auditActionCreate,
+			After:      plan,
+		})
+		if err != nil {
+			return err
+		}
+
+		schedule, err := installmentSchedule(plan, nil)
+		if err != nil {
+			return err
+		}
+		err = createInstallments(ctx, q, plan, schedule)
+		if err != nil {
+			return err
+		}
+		installments, err = q.ListPlanInstallments(ctx, []int32{plan.ID})
+		return err
+	})
+	if err != nil {
+		installmentErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newInstallmentPlanResponse(plan, installments, recurrence.Date(time.Now())))
+}
+
+func (server *Server) listInstallmentPlans(ctx *gin.Context) {
+	plans, err := server.store.ListInstallmentPlans(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.installmentPlanResponses(ctx, plans)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) installmentPlanResponses(ctx context.Context, plans []db.InstallmentPlan) ([]installmentPlanResponse, error) {
+	planIDs := make([]int32, 0, len(plans))
+	for _, plan := range plans {
+		planIDs = append(planIDs, plan.ID)
+	}
+	installments, err := server.store.ListPlanInstallments(ctx, planIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	today := recurrence.Date(time.Now())
+	rsp := make([]installmentPlanResponse, 0, len(plans))
+	for _, plan := range plans {
+		var found []db.Account
+		for _, installment := range installments {
+			if installment.InstallmentPlanID.Int32 == plan.ID {
+				found = append(found, installment)
+			}
+		}
+		rsp = append(rsp, newInstallmentPlanResponse(plan, found, today))
+	}
+	return rsp, nil
+}
+
+type installmentPlanRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getInstallmentPlan(ctx *gin.Context) {
+	var uri installmentPlanRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	plan, err := server.store.GetInstallmentPlan(ctx, db.GetInstallmentPlanParams{
+		ID:     uri.ID,
+		UserID: authClaims(ctx).UserID,
+	})
+	if err != nil {
+		installmentErrorResponse(ctx, err)
+		return
+	}
+	rsp, err := server.installmentPlanResponses(ctx, []db.InstallmentPlan{plan})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp[0])
+}
+
+type updateInstallmentPlanRequest struct {
+	CategoryID   *int32  `json:"category_id" binding:"omitempty,min=1"`
+	Title        *string `json:"title" binding:"omitempty,min=1"`
+	Description  *string `json:"description"`
+	Amount       *string `json:"amount"`
+	Installments *int32  `json:"installments" binding:"omitempty,min=1,max=420"`
+	InterestRate *string `json:"interest_rate"`
+	RemainderOn  *string `json:"remainder_on" binding:"omitempty,oneof=first last"`
+}
+
+// updateInstallmentPlan changes the terms of an active plan. Installments
+// already paid are kept and the unpaid ones are created again from the
+// new terms.
+func (server *Server) updateInstallmentPlan(ctx *gin.Context) {
+	var uri installmentPlanRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateInstallmentPlanRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	today := recurrence.Date(time.Now())
+	var plan db.InstallmentPlan
+	var installments []db.Account
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetInstallmentPlanForUpdate(ctx, db.GetInstallmentPlanForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		if before.Status != planStatusActive {
+			return errPlanNotActive
+		}
+		_, err = transactionWallet(ctx, q, before.WalletID, userID, before.Currency)
+		if err != nil {
+			return err
+		}
+
+		arg := db.UpdateInstallmentPlanParams{
+			ID:           before.ID,
+			UserID:       userID,
+			CategoryID:   before.CategoryID,
+			Title:        before.Title,
+			Description:  before.Description,
+			Amount:       before.Amount,
+			Installments: before.Installments,
+			InterestRate: before.InterestRate,
+			RemainderOn:  before.RemainderOn,
+			Status:       before.Status,
+		}
+		if req.CategoryID != nil {
+			category, err := q.GetCategory(ctx, db.GetCategoryParams{
+				ID:     *req.CategoryID,
+				UserID: userID,
+			})
+			if err != nil {
+				return err
+			}
+			if category.Type != before.Type {
+				return errAccountTypeMismatch
+			}
+			arg.CategoryID = category.ID
+		}
+		if req.Title != nil {
+			arg.Title = *req.Title
+		}
+		if req.Description != nil {
+			arg.Description = *req.Description
+		}
+		if req.Amount != nil {
+			amount, err := parseAmount(*req.Amount, before.Currency)
+			if err != nil {
+				return err
+			}
+			if amount.Amount <= 0 {
+				return errPlanAmount
+			}
+			arg.Amount = amount.Amount
+		}
+		if req.Installments != nil {
+			arg.Installments = *req.Installments
+		}
+		if req.InterestRate != nil {
+			rate, err := money.ParseInterest(*req.InterestRate)
+			if err != nil {
+				return err
+			}
+			arg.InterestRate = money.FormatRate(new(big.Rat).Mul(rate, big.NewRat(100, 1)))
+		}
+		if req.RemainderOn != nil {
+			arg.RemainderOn = *req.RemainderOn
+		}
+
+		plan, err = q.UpdateInstallmentPlan(ctx, arg)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetInstallmentPlan,
+			TargetID:   plan.ID,
+			Action:     auditActionUpdate,
+			Before:     before,
+			After:      plan,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = removeUnpaidInstallments(ctx, q, plan, today)
+		if err != nil {
+			return err
+		}
+		paid, err := q.ListPlanInstallments(ctx, []int32{plan.ID})
+		if err != nil {
+			return err
+		}
+		schedule, err := installmentSchedule(plan, paid)
+		if err != nil {
+			return err
+		}
+		err = createInstallments(ctx, q, plan, schedule)
+		if err != nil {
+			return err
+		}
+		installments, err = q.ListPlanInstallments(ctx, []int32{plan.ID})
+		return err
+	})
+	if err != nil {
+		installmentErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newInstallmentPlanResponse(plan, installments, today))
+}
+
+type payOffInstallmentPlanRequest struct {
+	Date time.Time `json:"date"`
+}
+
+// payOffInstallmentPlan settles the unpaid installments at once on the
+// given date, today by default. Interest of the plan is taken off the
+// installments paid ahead of time, as card issuers must do.
+func (server *Server) payOffInstallmentPlan(ctx *gin.Context) {
+	var uri installmentPlanRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req payOffInstallmentPlanRequest
+	// The body is optional.
+	if ctx.Request.ContentLength != 0 {
+		err = ctx.ShouldBindJSON(&req)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+	payOffDate := recurrence.Date(balanceDate(req.Date))
+
+	userID := authClaims(ctx).UserID
+	var plan db.InstallmentPlan
+	var installments []db.Account
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetInstallmentPlanForUpdate(ctx, db.GetInstallmentPlanForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		if before.Status != planStatusActive {
+			return errPlanNotActive
+		}
+		_, err = transactionWallet(ctx, q, before.WalletID, userID, before.Currency)
+		if err != nil {
+			return err
+		}
+		rate, err := interestRate(before)
+		if err != nil {
+			return err
+		}
+
+		removed, err := removeUnpaidInstallments(ctx, q, before, payOffDate)
+		if err != nil {
+			return err
+		}
+		if len(removed) == 0 {
+			return errPlanAlreadyPaid
+		}
+
+		payOff := money.Money{Currency: before.Currency}
+		number := removed[0].InstallmentNumber.Int32
+		for _, installment := range removed {
+			discounted := money.Discount(money.Money{Amount: installment.Amount, Currency: installment.Currency}, rate, monthsBetween(payOffDate, installment.Date))
+			payOff, err = payOff.Add(discounted)
+			if err != nil {
+				return err
+			}
+			if installment.InstallmentNumber.Int32 < number {
+				number = installment.InstallmentNumber.Int32
+			}
+		}
+
+		account, err := q.CreateInstallment(ctx, db.CreateInstallmentParams{
+			UserID:            userID,
+			WalletID:          before.WalletID,
+			CategoryID:        before.CategoryID,
+			Title:             fmt.Sprintf("%s (payoff)", before.Title),
+			Type:              before.Type,
+			Description:       before.Description,
+			Amount:            payOff.Amount,
+			Currency:          payOff.Currency,
+			Date:              payOffDate,
+			InstallmentPlanID: before.ID,
+			InstallmentNumber: number,
+		})
+		if err != nil {
+			return err
+		}
+		err = checkEnvelopeMonths(ctx, q, account)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetAccount,
+			TargetID:   account.ID,
+			Action:     auditActionCreate,
+			After:      account,
+		})
+		if err != nil {
+			return err
+		}
+
+		plan, err = setPlanStatus(ctx, q, before, planStatusPaidOff)
+		if err != nil {
+			return err
+		}
+		installments, err = q.ListPlanInstallments(ctx, []int32{plan.ID})
+		return err
+	})
+	if err != nil {
+		installmentErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newInstallmentPlanResponse(plan, installments, recurrence.Date(time.Now())))
+}
+
+// cancelInstallmentPlan drops the unpaid installments, for purchases that
+// were returned or charged back. Paid ones stay in the history.
+func (server *Server) cancelInstallmentPlan(ctx *gin.Context) {
+	var uri installmentPlanRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	today := recurrence.Date(time.Now())
+	var plan db.InstallmentPlan
+	var installments []db.Account
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetInstallmentPlanForUpdate(ctx, db.GetInstallmentPlanForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		if before.Status != planStatusActive {
+			return errPlanNotActive
+		}
+
+		_, err = removeUnpaidInstallments(ctx, q, before, today)
+		if err != nil {
+			return err
+		}
+		plan, err = setPlanStatus(ctx, q, before, planStatusCancelled)
+		if err != nil {
+			return err
+		}
+		installments, err = q.ListPlanInstallments(ctx, []int32{plan.ID})
+		return err
+	})
+	if err != nil {
+		installmentErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newInstallmentPlanResponse(plan, installments, today))
+}
+
+func setPlanStatus(ctx *gin.Context, q *db.Queries, before db.InstallmentPlan, status string) (db.InstallmentPlan, error) {
+	plan, err := q.UpdateInstallmentPlan(ctx, db.UpdateInstallmentPlanParams{
+		ID:           before.ID,
+		UserID:       before.UserID,
+		CategoryID:   before.CategoryID,
+		Title:        before.Title,
+		Description:  before.Description,
+		Amount:       before.Amount,
+		Installments: before.Installments,
+		InterestRate: before.InterestRate,
+		RemainderOn:  before.RemainderOn,
+		Status:       status,
+	})
+	if err != nil {
+		return db.InstallmentPlan{}, err
+	}
+	return plan, recordAudit(ctx, q, auditEvent{
+		UserID:     before.UserID,
+		TargetType: auditTargetInstallmentPlan,
+		TargetID:   plan.ID,
+		Action:     auditActionUpdate,
+		Before:     before,
+		After:      plan,
+	})
+}
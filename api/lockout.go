@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/mail"
+	"github.com/GustavoNoronha0/gofinance-backend/throttle"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tokenPurposeAccountUnlock  = "account_unlock"
+	accountUnlockTokenDuration = 24 * time.Hour
+	loginAttemptStorePostgres  = "postgres"
+	loginAttemptStoreMemory    = "memory"
+)
+
+var (
+	errInvalidCredentials = errors.New("invalid username or password")
+	errTooManyAttempts    = errors.New("too many failed attempts, try again later")
+)
+
+func newLoginLimiter(config util.Config, store db.Querier) (*throttle.Limiter, error) {
+	switch config.LoginAttemptStore {
+	case loginAttemptStorePostgres:
+		return throttle.NewLimiter(throttle.NewPostgresStore(store)), nil
+	case loginAttemptStoreMemory:
+		return throttle.NewLimiter(throttle.NewMemoryStore()), nil
+	default:
+		return nil, fmt.Errorf("unknown login attempt store %q", config.LoginAttemptStore)
+	}
+}
+
+// checkLoginThrottle answers 429 and returns false while any of the keys is
+// still backing off from earlier failures.
+func (server *Server) checkLoginThrottle(ctx *gin.Context, keys ...string) bool {
+	wait, err := server.loginLimiter.Wait(ctx, keys...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if wait > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyAttempts))
+		return false
+	}
+	return true
+}
+
+// rejectLogin records the failure and sends the same answer whatever went
+// wrong, so callers can't learn which usernames exist.
+func (server *Server) rejectLogin(ctx *gin.Context, keys ...string) {
+	err := server.loginLimiter.Fail(ctx, keys...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+}
+
+// recordUserLoginFailure bumps the user's failure counter and, when it
+// reaches the limit, locks the account and emails an unlock link.
+func (server *Server) recordUserLoginFailure(ctx *gin.Context, user db.User) error {
+	lockedUntil := time.Now().Add(server.config.LoginLockoutDuration)
+	var row db.RecordUserLoginFailureRow
+	err := server.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		row, err = q.RecordUserLoginFailure(ctx, db.RecordUserLoginFailureParams{
+			ID:          user.ID,
+			MaxFailures: int32(server.config.LoginMaxFailures),
+			LockedUntil: lockedUntil,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordLoginFailure(ctx, q, user.ID, user.Username, "wrong_password")
+	})
+	if err != nil {
+		return err
+	}
+	if row.FailedLoginAttempts != int32(server.config.LoginMaxFailures) {
+		return nil
+	}
+
+	token, err := server.createUserToken(ctx, user.ID, tokenPurposeAccountUnlock, accountUnlockTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	return server.mailer.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Your gofinance account has been locked",
+		Body: fmt.Sprintf("Hi %s,\n\nWe locked your account after %d failed login attempts. It unlocks by itself at %s, or right away through the link below:\n\n%s/login/unlock?token=%s\n\nIf these attempts weren't you, consider changing your password.\n",
+			user.Username, row.FailedLoginAttempts, lockedUntil.Format(time.RFC1123), server.config.AppBaseURL, token),
+	})
+}
+
+type unlockAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (server *Server) unlockAccount(ctx *gin.Context) {
+	var req unlockAccountRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		userToken, err := consumeUserToken(ctx, q, req.Token, tokenPurposeAccountUnlock)
+		if err != nil {
+			return err
+		}
+		err = q.ResetUserLoginFailures(ctx, userToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Nobody is logged in; the link proves who unlocks.
+		return recordAudit(ctx, q, auditEvent{
+			ActorID:    userToken.UserID,
+			UserID:     userToken.UserID,
+			TargetType: auditTargetUser,
+			TargetID:   userToken.UserID,
+			Action:     auditActionUnlock,
+		})
+	})
+	if err != nil {
+		if err == errInvalidUserToken {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	totpIssuer           = "gofinance"
+	mfaChallengeDuration = 5 * time.Minute
+	recoveryCodesCount   = 10
+)
+
+var (
+	errTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	errTOTPNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	errInvalidMFACode     = errors.New("two-factor code is invalid")
+)
+
+func (server *Server) hasTOTPEnabled(ctx *gin.Context, userID int32) (bool, error) {
+	totp, err := server.store.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return totp.ConfirmedAt.Valid, nil
+}
+
+// verifyTOTP accepts a code only once: the matched time step must be newer
+// than the last one used by this user.
+func (server *Server) verifyTOTP(ctx *gin.Context, totp db.UserTotp, code string) (bool, error) {
+	step, ok := util.ValidateTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	rows, err := server.store.UseTOTPStep(ctx, db.UseTOTPStepParams{
+		UserID: totp.UserID,
+		Step:   step,
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+type mfaChallengeResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"mfa_token_expires_at"`
+}
+
+func (server *Server) sendMFAChallenge(ctx *gin.Context, user db.User) {
+	challenge, claims, err := server.keys.CreateMFAChallenge(user.ID, mfaChallengeDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mfaChallengeResponse{
+		MFARequired: true,
+		MFAToken:    challenge,
+		ExpiresAt:   claims.ExpiresAt.Time,
+	})
+}
+
+type loginMFARequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code" binding:"required_without=Code"`
+}
+
+func (server *Server) loginMFA(ctx *gin.Context) {
+	var req loginMFARequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	claims, err := server.keys.ValidateMFAChallenge(req.MFAToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	mfaKey := fmt.Sprintf("mfa:%d", claims.UserID)
+	if !server.checkLoginThrottle(ctx, mfaKey) {
+		return
+	}
+
+	totp, err := server.store.GetUserTOTP(ctx, claims.UserID)
+	if err != nil || !totp.ConfirmedAt.Valid {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errTOTPNotEnrolled))
+		return
+	}
+
+	var valid bool
+	if req.Code != "" {
+		valid, err = server.verifyTOTP(ctx, totp, req.Code)
+	} else {
+		var rows int64
+		rows, err = server.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+			UserID:   claims.UserID,
+			CodeHash: util.HashToken(util.NormalizeRecoveryCode(req.RecoveryCode)),
+		})
+		valid = rows == 1
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !valid {
+		err = recordLoginFailure(ctx, server.store, claims.UserID, "", "wrong_mfa_code")
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		err = server.loginLimiter.Fail(ctx, mfaKey)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+		return
+	}
+
+	err = server.loginLimiter.Reset(ctx, mfaKey)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = checkUserCanLogin(user)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.startSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type enrollTOTPResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+func (server *Server) enrollTOTP(ctx *gin.Context) {
+	claims := authClaims(ctx)
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.UpsertUserTOTP(ctx, db.UpsertUserTOTPParams{
+		UserID: claims.UserID,
+		Secret: secret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errTOTPAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollTOTPResponse{
+		Secret: secret,
+		URI:    util.TOTPURI(totpIssuer, claims.Username, secret),
+	})
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type confirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (server *Server) confirmTOTP(ctx *gin.Context) {
+	var req totpCodeRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	claims := authClaims(ctx)
+	totp, err := server.store.GetUserTOTP(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errTOTPNotEnrolled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if totp.ConfirmedAt.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(errTOTPAlreadyEnabled))
+		return
+	}
+
+	valid, err := server.verifyTOTP(ctx, totp, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !valid {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidMFACode))
+		return
+	}
+
+	codes, err := util.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Old codes are only replaced once the new ones are all stored.
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		err := q.DeleteRecoveryCodes(ctx, claims.UserID)
+		if err != nil {
+			return err
+		}
+		for _, code := range codes {
+			err = q.CreateRecoveryCode(ctx, db.CreateRecoveryCodeParams{
+				UserID:   claims.UserID,
+				CodeHash: util.HashToken(util.NormalizeRecoveryCode(code)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = q.ConfirmUserTOTP(ctx, claims.UserID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     claims.UserID,
+			TargetType: auditTargetUser,
+			TargetID:   claims.UserID,
+			Action:     auditActionMFAEnable,
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmTOTPResponse{RecoveryCodes: codes})
+}
+
+func (server *Server) disableTOTP(ctx *gin.Context) {
+	var req totpCodeRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	claims := authClaims(ctx)
+	totp, err := server.store.GetUserTOTP(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errTOTPNotEnrolled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	valid, err := server.verifyTOTP(ctx, totp, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !valid {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidMFACode))
+		return
+	}
+
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		err := q.DeleteRecoveryCodes(ctx, claims.UserID)
+		if err != nil {
+			return err
+		}
+		err = q.DeleteUserTOTP(ctx, claims.UserID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     claims.UserID,
+			TargetType: auditTargetUser,
+			TargetID:   claims.UserID,
+			Action:     auditActionMFADisable,
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
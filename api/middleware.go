@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload"
+)
+
+var (
+	errMissingAuthorization = errors.New("authorization header is not provided")
+	errInvalidAuthorization = errors.New("invalid authorization header format")
+	errSessionRequired      = errors.New("this route can't be used with a personal access token")
+	errMissingScope         = errors.New("token is missing the required scope")
+	errMissingRole          = errors.New("you don't have permission to use this route")
+)
+
+func (server *Server) authMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fields := strings.Fields(ctx.GetHeader(authorizationHeaderKey))
+		if len(fields) == 0 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errMissingAuthorization))
+			return
+		}
+		if len(fields) != 2 || strings.ToLower(fields[0]) != authorizationTypeBearer {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errInvalidAuthorization))
+			return
+		}
+
+		var claims *util.Claims
+		var err error
+		if strings.HasPrefix(fields[1], util.PersonalAccessTokenPrefix) {
+			claims, err = server.validatePersonalAccessToken(ctx, fields[1])
+		} else {
+			claims, err = server.keys.ValidateToken(ctx, fields[1], server.isSessionRevoked)
+		}
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		ctx.Set(authorizationPayloadKey, claims)
+		ctx.Next()
+	}
+}
+
+func (server *Server) isSessionRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	session, err := server.store.GetSession(ctx, sessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return true, nil
+		}
+		return false, err
+	}
+	return session.RevokedAt.Valid, nil
+}
+
+func authClaims(ctx *gin.Context) *util.Claims {
+	return ctx.MustGet(authorizationPayloadKey).(*util.Claims)
+}
+
+// requireVerifiedEmail keeps users that haven't confirmed their email
+// address away from the routes that change data.
+func requireVerifiedEmail() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !authClaims(ctx).EmailVerified {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errEmailNotVerified))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// requireSession limits a route to session tokens, so a leaked personal
+// access token can't be used to mint new credentials or change security
+// settings.
+func requireSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if authClaims(ctx).PersonalAccessTokenID != 0 {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errSessionRequired))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func requireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !authClaims(ctx).HasScope(scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errMissingScope))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func requireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !authClaims(ctx).HasRole(roles...) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errMissingRole))
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/GustavoNoronha0/gofinance-backend/money"
+)
+
+// amountError marks amounts from a request that can't be read in their
+// currency, so handlers answer them with 400 even when they show up inside
+// a transaction.
+type amountError struct {
+	err error
+}
+
+func (err amountError) Error() string {
+	return err.err.Error()
+}
+
+func (err amountError) Unwrap() error {
+	return err.err
+}
+
+func parseAmount(amount string, currency string) (money.Money, error) {
+	m, err := money.Parse(amount, strings.ToUpper(currency))
+	if err != nil {
+		return money.Money{}, amountError{err: err}
+	}
+	return m, nil
+}
+
+func formatAmount(amount int64, currency string) string {
+	return money.Money{Amount: amount, Currency: currency}.String()
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/notify"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	notificationKindBudgetThreshold = "budget_threshold"
+
+	// notificationDeliveryTimeout bounds one background delivery, all
+	// channels and notifications included.
+	notificationDeliveryTimeout = time.Minute
+)
+
+// budgetThresholds are the percentages of a budget we warn about, highest
+// first.
+var budgetThresholds = []int64{100, 80}
+
+type budgetThresholdData struct {
+	BudgetID   int32  `json:"budget_id"`
+	CategoryID int32  `json:"category_id"`
+	Period     string `json:"period"`
+	Threshold  int64  `json:"threshold"`
+	Planned    string `json:"planned"`
+	Spent      string `json:"spent"`
+	Currency   string `json:"currency"`
+}
+
+// checkBudgetThresholds raises a notification when an expense takes the
+// budget of its category past one of the thresholds. Only the highest
+// threshold crossed fires, and each fires once per category and month. It
+// returns the notifications created, so they can be delivered once the
+// transaction commits.
+func checkBudgetThresholds(ctx context.Context, q db.Querier, account db.Account) ([]db.Notification, error) {
+	if account.Type != "debit" {
+		return nil, nil
+	}
+
+	period := periodOf(account.Date)
+	lines, _, err := budgetStatus(ctx, q, account.UserID, period, sql.NullInt32{Int32: account.CategoryID, Valid: true})
+	if err != nil {
+		// Without a rate we can't tell how much of the budget is used,
+		// which shouldn't keep the entry from being saved.
+		var rateErr missingRateError
+		if errors.As(err, &rateErr) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var notifications []db.Notification
+	for _, line := range lines {
+		used := line.PercentUsed()
+		if used == nil {
+			continue
+		}
+		for _, threshold := range budgetThresholds {
+			if used.Cmp(big.NewRat(threshold, 1)) < 0 {
+				continue
+			}
+
+			notification, created, err := createBudgetNotification(ctx, q, line, threshold)
+			if err != nil {
+				return nil, err
+			}
+			if created {
+				notifications = append(notifications, notification)
+			}
+			break
+		}
+	}
+	return notifications, nil
+}
+
+func createBudgetNotification(ctx context.Context, q db.Querier, line budgetLine, threshold int64) (db.Notification, bool, error) {
+	budget := line.Budget
+	period := budget.Period.Format(periodLayout)
+	data, err := json.Marshal(budgetThresholdData{
+		BudgetID:   budget.ID,
+		CategoryID: budget.CategoryID,
+		Period:     period,
+		Threshold:  threshold,
+		Planned:    line.Planned.String(),
+		Spent:      line.Spent.String(),
+		Currency:   budget.Currency,
+	})
+	if err != nil {
+		return db.Notification{}, false, err
+	}
+
+	title := fmt.Sprintf("%s reached %d%% of its budget", budget.CategoryTitle, threshold)
+	if threshold >= 100 {
+		title = fmt.Sprintf("%s is over its budget", budget.CategoryTitle)
+	}
+	notification, err := q.CreateNotification(ctx, db.CreateNotificationParams{
+		UserID: budget.UserID,
+		Kind:   notificationKindBudgetThreshold,
+		Title:  title,
+		Body: fmt.Sprintf("You spent %s of the %s %s budgeted for %s in %s.",
+			line.Spent, line.Planned, budget.Currency, budget.CategoryTitle, period),
+		Data: data,
+		DedupKey: sql.NullString{
+			String: fmt.Sprintf("budget:%d:%s:%d", budget.CategoryID, period, threshold),
+			Valid:  true,
+		},
+	})
+	if err == sql.ErrNoRows {
+		// The threshold already fired this month.
+		return db.Notification{}, false, nil
+	}
+	if err != nil {
+		return db.Notification{}, false, err
+	}
+	return notification, true, nil
+}
+
+// deliverNotifications sends notifications that were stored for a user
+// through the configured channels. It runs in the background, on its own
+// context, so a slow webhook never holds up the request that triggered it;
+// failures are only logged, the notifications stay in the inbox either way.
+func (server *Server) deliverNotifications(userID int32, notifications []db.Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationDeliveryTimeout)
+		defer cancel()
+
+		user, err := server.store.GetUserById(ctx, userID)
+		if err != nil {
+			log.Printf("cannot deliver notifications to user %d: %v", userID, err)
+			return
+		}
+		recipient := notify.Recipient{UserID: user.ID, Username: user.Username}
+		if user.EmailVerifiedAt.Valid {
+			recipient.Email = user.Email
+		}
+
+		for _, notification := range notifications {
+			err = server.notifier.Deliver(ctx, recipient, notify.Notification{
+				ID:        notification.ID,
+				Kind:      notification.Kind,
+				Title:     notification.Title,
+				Body:      notification.Body,
+				Data:      notification.Data,
+				CreatedAt: notification.CreatedAt,
+			})
+			if err != nil {
+				log.Printf("cannot deliver notification to user %d: %v", userID, err)
+			}
+		}
+	}()
+}
+
+type notificationResponse struct {
+	ID        int64           `json:"id"`
+	Kind      string          `json:"kind"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data"`
+	Read      bool            `json:"read"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func newNotificationResponse(notification db.Notification) notificationResponse {
+	rsp := notificationResponse{
+		ID:        notification.ID,
+		Kind:      notification.Kind,
+		Title:     notification.Title,
+		Body:      notification.Body,
+		Data:      notification.Data,
+		Read:      notification.ReadAt.Valid,
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.ReadAt.Valid {
+		rsp.ReadAt = &notification.ReadAt.Time
+	}
+	return rsp
+}
+
+type listNotificationsRequest struct {
+	Unread   bool  `form:"unread"`
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+type listNotificationsResponse struct {
+	Unread        int64                  `json:"unread"`
+	Notifications []notificationResponse `json:"notifications"`
+}
+
+func (server *Server) listNotifications(ctx *gin.Context) {
+	var req listNotificationsRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	notifications, err := server.store.ListNotifications(ctx, db.ListNotificationsParams{
+		UserID:     userID,
+		UnreadOnly: req.Unread,
+		PageLimit:  req.PageSize,
+		PageOffset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	unread, err := server.store.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listNotificationsResponse{
+		Unread:        unread,
+		Notifications: make([]notificationResponse, len(notifications)),
+	}
+	for i, notification := range notifications {
+		rsp.Notifications[i] = newNotificationResponse(notification)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type markNotificationReadRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) markNotificationRead(ctx *gin.Context) {
+	var req markNotificationReadRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	notification, err := server.store.MarkNotificationRead(ctx, db.MarkNotificationReadParams{
+		ID:     req.ID,
+		UserID: authClaims(ctx).UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newNotificationResponse(notification))
+}
+
+func (server *Server) markAllNotificationsRead(ctx *gin.Context) {
+	marked, err := server.store.MarkAllNotificationsRead(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"marked": marked})
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/oidc"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcLoginDuration = 10 * time.Minute
+	oidcSecretSize    = 32
+)
+
+var (
+	errInvalidOIDCState      = errors.New("login request is invalid or has expired")
+	errOIDCEmailNotVerified  = errors.New("identity provider didn't verify the email address")
+	errOIDCEmailNotConfirmed = errors.New("an account with this email exists but its email was never verified; log in with your password and verify it first")
+)
+
+type startOIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// startOIDCLogin keeps the nonce and PKCE verifier on our side and gives
+// the client the provider URL to send the user to.
+func (server *Server) startOIDCLogin(ctx *gin.Context) {
+	state, err := util.RandomToken(oidcSecretSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	nonce, err := util.RandomToken(oidcSecretSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	verifier, err := util.RandomToken(oidcSecretSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.store.DeleteExpiredOIDCLogins(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.CreateOIDCLogin(ctx, db.CreateOIDCLoginParams{
+		StateHash:    util.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcLoginDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, startOIDCLoginResponse{
+		AuthorizationURL: server.oidcProvider.AuthCodeURL(state, nonce, oidc.CodeChallenge(verifier)),
+	})
+}
+
+type finishOIDCLoginRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+func (server *Server) finishOIDCLogin(ctx *gin.Context) {
+	var req finishOIDCLoginRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	login, err := server.store.ConsumeOIDCLogin(ctx, util.HashToken(req.State))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidOIDCState))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	token, err := server.oidcProvider.Exchange(ctx, req.Code, login.CodeVerifier)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	claims, err := server.oidcProvider.VerifyIDToken(ctx, token.IDToken, login.Nonce)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	user, err := server.oidcUser(ctx, claims)
+	if err != nil {
+		switch err {
+		case errOIDCEmailNotVerified, errOIDCEmailNotConfirmed:
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	err = checkUserCanLogin(user)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	mfaEnabled, err := server.hasTOTPEnabled(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if mfaEnabled {
+		server.sendMFAChallenge(ctx, user)
+		return
+	}
+
+	rsp, err := server.startSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// oidcUser finds the local user behind the provider identity. Identities
+// seen before are followed directly; otherwise the user is matched by
+// verified email, or created when nobody uses that email yet.
+func (server *Server) oidcUser(ctx *gin.Context, claims *oidc.IDTokenClaims) (db.User, error) {
+	issuer := server.oidcProvider.Metadata().Issuer
+
+	identity, err := server.store.GetUserIdentity(ctx, db.GetUserIdentityParams{
+		Issuer:  issuer,
+		Subject: claims.Subject,
+	})
+	if err == nil {
+		return server.store.GetUserById(ctx, identity.UserID)
+	}
+	if err != sql.ErrNoRows {
+		return db.User{}, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return db.User{}, errOIDCEmailNotVerified
+	}
+
+	// Hash outside the transaction, it is slow on purpose.
+	unusablePassword, err := util.RandomToken(oidcSecretSize)
+	if err != nil {
+		return db.User{}, err
+	}
+	passwordHashed, err := util.HashPassword(unusablePassword)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	var user db.User
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		user, err = q.GetUserByEmail(ctx, claims.Email)
+		switch {
+		case err == sql.ErrNoRows:
+			user, err = provisionOIDCUser(ctx, q, claims, passwordHashed)
+			if err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case !user.EmailVerifiedAt.Valid:
+			// Whoever signed up with this address never proved they own
+			// it, so handing the account to the provider identity could
+			// give it to an attacker that registered it first.
+			return errOIDCEmailNotConfirmed
+		}
+
+		identity, err := q.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+			Issuer:  issuer,
+			Subject: claims.Subject,
+			UserID:  user.ID,
+			Email:   claims.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			ActorID:    user.ID,
+			UserID:     user.ID,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID,
+			Action:     auditActionUpdate,
+			After:      gin.H{"identity_issuer": identity.Issuer, "identity_subject": identity.Subject},
+		})
+	})
+	return user, err
+}
+
+// provisionOIDCUser creates a user that can only log in through the
+// provider until they set a password with the reset flow.
+func provisionOIDCUser(ctx *gin.Context, q *db.Queries, claims *oidc.IDTokenClaims, passwordHashed string) (db.User, error) {
+	baseUsername := claims.PreferredUsername
+	if baseUsername == "" {
+		baseUsername = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+
+	username := baseUsername
+	for attempt := 0; ; attempt++ {
+		_, err := q.GetUser(ctx, username)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return db.User{}, err
+		}
+		if attempt == 3 {
+			return db.User{}, errUsernameOrEmailTaken
+		}
+		username = baseUsername + "-" + util.RandomString(4)
+	}
+
+	user, err := q.CreateUser(ctx, db.CreateUserParams{
+		Username: username,
+		Password: passwordHashed,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+
+	err = q.VerifyUserEmail(ctx, user.ID)
+	if err != nil {
+		return db.User{}, err
+	}
+	user, err = q.GetUserById(ctx, user.ID)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	err = recordAudit(ctx, q, auditEvent{
+		ActorID:    user.ID,
+		UserID:     user.ID,
+		TargetType: auditTargetUser,
+		TargetID:   user.ID,
+		Action:     auditActionCreate,
+		After:      newUserResponse(user),
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+	return user, nil
+}
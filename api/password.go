@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/mail"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPassword always answers 200 so it can't be used to find out which
+// email addresses have an account.
+func (server *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusOK, true)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.sendPasswordReset(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+func (server *Server) sendPasswordReset(ctx context.Context, user db.User) error {
+	token, err := server.createUserToken(ctx, user.ID, tokenPurposePasswordReset, passwordResetTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	return server.mailer.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your gofinance password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. If it was you, open the link below:\n\n%s/password/reset?token=%s\n\nThe link expires in %s. If you didn't ask for it, just ignore this email.\n",
+			user.Username, server.config.AppBaseURL, token, passwordResetTokenDuration),
+	})
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userToken, err := server.store.GetUserToken(ctx, db.GetUserTokenParams{
+		TokenHash: util.HashToken(req.Token),
+		Purpose:   tokenPurposePasswordReset,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidUserToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, userToken.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Check the new password before burning the token so a rejected
+	// password can be retried with the same link.
+	err = server.passwordPolicy.Validate(req.Password, user.Username, user.Email)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	passwordHashed, err := util.HashPassword(req.Password)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		_, err := consumeUserToken(ctx, q, req.Token, tokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		err = q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			ID:       userToken.UserID,
+			Password: passwordHashed,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidateUserTokens(ctx, db.InvalidateUserTokensParams{
+			UserID:  userToken.UserID,
+			Purpose: tokenPurposePasswordReset,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.ResetUserLoginFailures(ctx, userToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.RevokeUserSessions(ctx, userToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userToken.UserID,
+			TargetType: auditTargetUser,
+			TargetID:   userToken.UserID,
+			Action:     auditActionPasswordReset,
+		})
+	})
+	if err != nil {
+		if err == errInvalidUserToken {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
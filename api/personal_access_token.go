@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	scopeRead              = "read"
+	scopeCategoriesWrite   = "categories:write"
+	scopeTransactionsWrite = "transactions:write"
+
+	personalAccessTokenSize       = 32
+	personalAccessTokenPrefixSize = len(util.PersonalAccessTokenPrefix) + 6
+)
+
+func (server *Server) validatePersonalAccessToken(ctx context.Context, token string) (*util.Claims, error) {
+	storedToken, err := server.store.GetPersonalAccessTokenByHash(ctx, util.HashToken(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, util.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if storedToken.RevokedAt.Valid || storedToken.DisabledAt.Valid {
+		return nil, util.ErrRevokedToken
+	}
+	if time.Now().After(storedToken.ExpiresAt) {
+		return nil, util.ErrInvalidToken
+	}
+
+	err = server.store.TouchPersonalAccessToken(ctx, storedToken.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &util.Claims{
+		UserID:                storedToken.UserID,
+		Username:              storedToken.Username,
+		EmailVerified:         storedToken.EmailVerifiedAt.Valid,
+		Role:                  storedToken.Role,
+		PersonalAccessTokenID: storedToken.ID,
+		Scopes:                storedToken.Scopes,
+	}, nil
+}
+
+type personalAccessTokenResponse struct {
+	ID          int32      `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newPersonalAccessTokenResponse(token db.PersonalAccessToken) personalAccessTokenResponse {
+	rsp := personalAccessTokenResponse{
+		ID:          token.ID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      token.Scopes,
+		ExpiresAt:   token.ExpiresAt,
+		CreatedAt:   token.CreatedAt,
+	}
+	if token.LastUsedAt.Valid {
+		rsp.LastUsedAt = &token.LastUsedAt.Time
+	}
+	if token.RevokedAt.Valid {
+		rsp.RevokedAt = &token.RevokedAt.Time
+	}
+	return rsp
+}
+
+type createPersonalAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=read categories:write transactions:write"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+type createPersonalAccessTokenResponse struct {
+	personalAccessTokenResponse
+	Token string `json:"token"`
+}
+
+func (server *Server) createPersonalAccessToken(ctx *gin.Context) {
+	var req createPersonalAccessTokenRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	secret, err := util.RandomToken(personalAccessTokenSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	token := util.PersonalAccessTokenPrefix + secret
+
+	arg := db.CreatePersonalAccessTokenParams{
+		UserID:      authClaims(ctx).UserID,
+		Name:        req.Name,
+		TokenPrefix: token[:personalAccessTokenPrefixSize],
+		TokenHash:   util.HashToken(token),
+		Scopes:      req.Scopes,
+		ExpiresAt:   time.Now().AddDate(0, 0, req.ExpiresInDays),
+	}
+
+	var storedToken db.PersonalAccessToken
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		storedToken, err = q.CreatePersonalAccessToken(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     arg.UserID,
+			TargetType: auditTargetPersonalAccessToken,
+			TargetID:   storedToken.ID,
+			Action:     auditActionCreate,
+			After:      newPersonalAccessTokenResponse(storedToken),
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createPersonalAccessTokenResponse{
+		personalAccessTokenResponse: newPersonalAccessTokenResponse(storedToken),
+		Token:                       token,
+	})
+}
+
+func (server *Server) listPersonalAccessTokens(ctx *gin.Context) {
+	tokens, err := server.store.ListPersonalAccessTokens(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]personalAccessTokenResponse, len(tokens))
+	for i, token := range tokens {
+		rsp[i] = newPersonalAccessTokenResponse(token)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revokePersonalAccessTokenRequest struct {
+	ID int32 `uri:"id" binding:"required"`
+}
+
+func (server *Server) revokePersonalAccessToken(ctx *gin.Context) {
+	var req revokePersonalAccessTokenRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		rows, err := q.RevokePersonalAccessToken(ctx, db.RevokePersonalAccessTokenParams{
+			ID:     req.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return sql.ErrNoRows
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetPersonalAccessToken,
+			TargetID:   req.ID,
+			Action:     auditActionRevoke,
+		})
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
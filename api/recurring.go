@@ -0,0 +1,784 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/recurrence"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPreviewCount = 10
+
+var (
+	errNoOccurrences   = errors.New("recurrence rule has no occurrences")
+	errNotAnOccurrence = errors.New("date is not an occurrence of the recurring transaction")
+)
+
+type recurringResponse struct {
+	ID          int32      `json:"id"`
+	WalletID    int32      `json:"wallet_id"`
+	CategoryID  int32      `json:"category_id"`
+	Title       string     `json:"title"`
+	Type        string     `json:"type"`
+	Description string     `json:"description"`
+	Amount      string     `json:"amount"`
+	Currency    string     `json:"currency"`
+	Rule        string     `json:"rule"`
+	StartDate   time.Time  `json:"start_date"`
+	NextDate    *time.Time `json:"next_date"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newRecurringResponse(recurring db.RecurringTransaction) recurringResponse {
+	rsp := recurringResponse{
+		ID:          recurring.ID,
+		WalletID:    recurring.WalletID,
+		CategoryID:  recurring.CategoryID,
+		Title:       recurring.Title,
+		Type:        recurring.Type,
+		Description: recurring.Description,
+		Amount:      formatAmount(recurring.Amount, recurring.Currency),
+		Currency:    recurring.Currency,
+		Rule:        recurring.Rule,
+		StartDate:   recurring.StartDate,
+		CreatedAt:   recurring.CreatedAt,
+	}
+	if recurring.NextDate.Valid {
+		rsp.NextDate = &recurring.NextDate.Time
+	}
+	return rsp
+}
+
+func recurringErrorResponse(ctx *gin.Context, err error) {
+	var amountErr amountError
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case err == errAccountTypeMismatch, err == errWalletArchived, err == errWalletCurrencyMismatch,
+		err == errNoOccurrences, err == errNotAnOccurrence,
+		errors.Is(err, recurrence.ErrInvalidRule), errors.As(err, &amountErr):
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	case err == errEnvelopeDateClosed:
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+// recurringTemplate holds the transaction a series repeats, before it's
+// checked against the user's categories and wallets.
+type recurringTemplate struct {
+	WalletID    int32
+	CategoryID  int32
+	Title       string
+	Type        string
+	Description string
+	Amount      string
+	Currency    string
+	Rule        recurrence.Rule
+	StartDate   time.Time
+}
+
+// prepareRecurring validates the template the same way createAccount does
+// and finds the first occurrence of the series.
+func prepareRecurring(ctx *gin.Context, q *db.Queries, userID int32, template recurringTemplate) (db.CreateRecurringTransactionParams, error) {
+	category, err := q.GetCategory(ctx, db.GetCategoryParams{
+		ID:     template.CategoryID,
+		UserID: userID,
+	})
+	if err != nil {
+		return db.CreateRecurringTransactionParams{}, err
+	}
+	if category.Type != template.Type {
+		return db.CreateRecurringTransactionParams{}, errAccountTypeMismatch
+	}
+
+	wallet, err := transactionWallet(ctx, q, template.WalletID, userID, strings.ToUpper(template.Currency))
+	if err != nil {
+		return db.CreateRecurringTransactionParams{}, err
+	}
+	amount, err := parseAmount(template.Amount, wallet.Currency)
+	if err != nil {
+		return db.CreateRecurringTransactionParams{}, err
+	}
+
+	startDate := recurrence.Date(template.StartDate)
+	first, ok := template.Rule.Iterate(startDate).Next()
+	if !ok {
+		return db.CreateRecurringTransactionParams{}, errNoOccurrences
+	}
+
+	return db.CreateRecurringTransactionParams{
+		UserID:      userID,
+		WalletID:    wallet.ID,
+		CategoryID:  category.ID,
+		Title:       template.Title,
+		Type:        template.Type,
+		Description: template.Description,
+		Amount:      amount.Amount,
+		Currency:    amount.Currency,
+		Rule:        template.Rule.String(),
+		StartDate:   startDate,
+		NextDate:    sql.NullTime{Time: first, Valid: true},
+	}, nil
+}
+
+// materialiseRecurring creates the entries of a series that are due by
+// today and moves next_date past them. Skipped dates are left out and
+// occurrences that already exist are kept as they are, so running it
+// twice creates nothing new. Series whose wallet got archived wait until
+// it's restored. Dates in a closed envelope month are skipped, and stored
+// as exceptions so the series shows it.
+func materialiseRecurring(ctx context.Context, q *db.Queries, recurring db.RecurringTransaction, today time.Time) (db.RecurringTransaction, int, error) {
+	if !recurring.NextDate.Valid || recurring.NextDate.Time.After(today) {
+		return recurring, 0, nil
+	}
+
+	wallet, err := q.GetWallet(ctx, db.GetWalletParams{
+		ID:     recurring.WalletID,
+		UserID: recurring.UserID,
+	})
+	if err != nil {
+		return recurring, 0, err
+	}
+	if wallet.ArchivedAt.Valid {
+		return recurring, 0, nil
+	}
+
+	rule, err := recurrence.Parse(recurring.Rule)
+	if err != nil {
+		return recurring, 0, err
+	}
+	exceptions, err := q.ListRecurringExceptions(ctx, recurring.ID)
+	if err != nil {
+		return recurring, 0, err
+	}
+	skipped := make(map[time.Time]bool, len(exceptions))
+	for _, exception := range exceptions {
+		skipped[recurrence.Date(exception)] = true
+	}
+	closedUntil, envelopesClosed, err := envelopeClosedUntil(ctx, q, recurring.UserID)
+	if err != nil {
+		return recurring, 0, err
+	}
+
+	created := 0
+	for _, date := range rule.Between(recurring.StartDate, recurring.NextDate.Time, today) {
+		if skipped[date] {
+			continue
+		}
+		if envelopesClosed && !periodOf(date).After(closedUntil) {
+			err = q.CreateRecurringException(ctx, db.CreateRecurringExceptionParams{
+				RecurringID: recurring.ID,
+				Date:        date,
+			})
+			if err != nil {
+				return recurring, 0, err
+			}
+			continue
+		}
+		_, err := q.CreateRecurringOccurrence(ctx, db.CreateRecurringOccurrenceParams{
+			UserID:      recurring.UserID,
+			WalletID:    recurring.WalletID,
+			CategoryID:  recurring.CategoryID,
+			Title:       recurring.Title,
+			Type:        recurring.Type,
+			Description: recurring.Description,
+			Amount:      recurring.Amount,
+			Currency:    recurring.Currency,
+			Date:        date,
+			RecurringID: sql.NullInt32{Int32: recurring.ID, Valid: true},
+		})
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return recurring, 0, err
+		}
+		created++
+	}
+
+	next, ok := rule.After(recurring.StartDate, today)
+	recurring, err = q.UpdateRecurringSchedule(ctx, db.UpdateRecurringScheduleParams{
+		ID:       recurring.ID,
+		Rule:     recurring.Rule,
+		NextDate: sql.NullTime{Time: next, Valid: ok},
+	})
+	return recurring, created, err
+}
+
+// RunRecurringScheduler creates due occurrences of recurring transactions
+// every RecurringEvery until ctx is done. Each series is claimed with SKIP
+// LOCKED, so several server processes can run it side by side.
+func (server *Server) RunRecurringScheduler(ctx context.Context) {
+	ticker := time.NewTicker(server.config.RecurringEvery)
+	defer ticker.Stop()
+
+	for {
+		created, err := server.materialiseDueRecurring(ctx, time.Now())
+		if err != nil {
+			log.Printf("cannot create recurring transactions: %v", err)
+		} else if created > 0 {
+			log.Printf("created %d recurring transactions", created)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (server *Server) materialiseDueRecurring(ctx context.Context, now time.Time) (int, error) {
+	today := recurrence.Date(now)
+	ids, err := server.store.ListDueRecurringTransactions(ctx, today)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, id := range ids {
+		var created int
+		err := server.store.ExecTx(ctx, func(q *db.Queries) error {
+			recurring, err := q.ClaimDueRecurringTransaction(ctx, db.ClaimDueRecurringTransactionParams{
+				ID:    id,
+				Today: today,
+			})
+			if err == sql.ErrNoRows {
+				// Another process has it or already caught it up.
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			_, created, err = materialiseRecurring(ctx, q, recurring, today)
+			return err
+		})
+		if err != nil {
+			// One broken series shouldn't hold back the others.
+			log.Printf("cannot create occurrences of recurring transaction %d: %v", id, err)
+			continue
+		}
+		total += created
+	}
+	return total, nil
+}
+
+type createRecurringRequest struct {
+	WalletID    int32     `json:"wallet_id" binding:"required"`
+	CategoryID  int32     `json:"category_id" binding:"required"`
+	Title       string    `json:"title" binding:"required"`
+	Type        string    `json:"type" binding:"required,oneof=credit debit"`
+	Description string    `json:"description" binding:"required"`
+	Amount      string    `json:"amount" binding:"required"`
+	Currency    string    `json:"currency" binding:"omitempty,len=3"`
+	Rule        string    `json:"rule" binding:"required"`
+	StartDate   time.Time `json:"start_date" binding:"required"`
+}
+
+// createRecurring saves the series and creates the occurrences that are
+// already due, so a series starting in the past is caught up right away.
+func (server *Server) createRecurring(ctx *gin.Context) {
+	var req createRecurringRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rule, err := recurrence.Parse(req.Rule)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var recurring db.RecurringTransaction
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		arg, err := prepareRecurring(ctx, q, userID, recurringTemplate{
+			WalletID:    req.WalletID,
+			CategoryID:  req.CategoryID,
+			Title:       req.Title,
+			Type:        req.Type,
+			Description: req.Description,
+			Amount:      req.Amount,
+			Currency:    req.Currency,
+			Rule:        rule,
+			StartDate:   req.StartDate,
+		})
+		if err != nil {
+			return err
+		}
+
+		recurring, err = q.CreateRecurringTransaction(ctx, arg)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetRecurring,
+			TargetID:   recurring.ID,
+			Action:     auditActionCreate,
+			After:      recurring,
+		})
+		if err != nil {
+			return err
+		}
+
+		recurring, _, err = materialiseRecurring(ctx, q, recurring, recurrence.Date(time.Now()))
+		return err
+	})
+	if err != nil {
+		recurringErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newRecurringResponse(recurring))
+}
+
+func (server *Server) listRecurring(ctx *gin.Context) {
+	recurring, err := server.store.ListRecurringTransactions(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]recurringResponse, 0, len(recurring))
+	for _, series := range recurring {
+		rsp = append(rsp, newRecurringResponse(series))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type recurringRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getRecurring(ctx *gin.Context) {
+	var uri recurringRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	recurring, err := server.store.GetRecurringTransaction(ctx, db.GetRecurringTransactionParams{
+		ID:     uri.ID,
+		UserID: authClaims(ctx).UserID,
+	})
+	if err != nil {
+		recurringErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newRecurringResponse(recurring))
+}
+
+// deleteRecurring stops the series. Entries it already created stay in
+// the wallet as ordinary transactions.
+func (server *Server) deleteRecurring(ctx *gin.Context) {
+	var uri recurringRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetRecurringTransactionForUpdate(ctx, db.GetRecurringTransactionForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteRecurringTransaction(ctx, db.DeleteRecurringTransactionParams{
+			ID:     before.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetRecurring,
+			TargetID:   before.ID,
+			Action:     auditActionDelete,
+			Before:     before,
+		})
+	})
+	if err != nil {
+		recurringErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+type previewRecurringRequest struct {
+	Count int `form:"count" binding:"omitempty,min=1,max=100"`
+}
+
+type occurrenceResponse struct {
+	Date    time.Time `json:"date"`
+	Skipped bool      `json:"skipped"`
+}
+
+// previewRecurring lists the next occurrences from today on, including
+// the skipped ones so clients can offer to restore them.
+func (server *Server) previewRecurring(ctx *gin.Context) {
+	var uri recurringRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req previewRecurringRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Count == 0 {
+		req.Count = defaultPreviewCount
+	}
+
+	recurring, err := server.store.GetRecurringTransaction(ctx, db.GetRecurringTransactionParams{
+		ID:     uri.ID,
+		UserID: authClaims(ctx).UserID,
+	})
+	if err != nil {
+		recurringErrorResponse(ctx, err)
+		return
+	}
+	rule, err := recurrence.Parse(recurring.Rule)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	exceptions, err := server.store.ListRecurringExceptions(ctx, recurring.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	skipped := make(map[time.Time]bool, len(exceptions))
+	for _, exception := range exceptions {
+		skipped[recurrence.Date(exception)] = true
+	}
+
+	today := recurrence.Date(time.Now())
+	rsp := make([]occurrenceResponse, 0, req.Count)
+	it := rule.Iterate(recurring.StartDate)
+	for len(rsp) < req.Count {
+		date, ok := it.Next()
+		if !ok {
+			break
+		}
+		if date.Before(today) {
+			continue
+		}
+		rsp = append(rsp, occurrenceResponse{Date: date, Skipped: skipped[date]})
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type occurrenceRequest struct {
+	ID   int32     `uri:"id" binding:"required,min=1"`
+	Date time.Time `uri:"date" binding:"required" time_format:"2006-01-02"`
+}
+
+type skippedOccurrence struct {
+	Date time.Time `json:"date"`
+}
+
+// skipOccurrence leaves one date out of the series. If the scheduler
+// already created it, the entry is removed.
+func (server *Server) skipOccurrence(ctx *gin.Context) {
+	var uri occurrenceRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	date := recurrence.Date(uri.Date)
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		recurring, err := q.GetRecurringTransactionForUpdate(ctx, db.GetRecurringTransactionForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		rule, err := recurrence.Parse(recurring.Rule)
+		if err != nil {
+			return err
+		}
+		if !rule.Includes(recurring.StartDate, date) {
+			return errNotAnOccurrence
+		}
+
+		err = q.CreateRecurringException(ctx, db.CreateRecurringExceptionParams{
+			RecurringID: recurring.ID,
+			Date:        date,
+		})
+		if err != nil {
+			return err
+		}
+		removed, err := q.DeleteRecurringOccurrence(ctx, db.DeleteRecurringOccurrenceParams{
+			RecurringID:    recurring.ID,
+			OccurrenceDate: date,
+		})
+		if err != nil {
+			return err
+		}
+		err = checkEnvelopeMonths(ctx, q, removed...)
+		if err != nil {
+			return err
+		}
+		err = auditDeletedAccounts(ctx, q, removed)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetRecurring,
+			TargetID:   recurring.ID,
+			Action:     auditActionSkip,
+			After:      skippedOccurrence{Date: date},
+		})
+	})
+	if err != nil {
+		recurringErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+func auditDeletedAccounts(ctx *gin.Context, q *db.Queries, accounts []db.Account) error {
+	for _, account := range accounts {
+		err := recordAudit(ctx, q, auditEvent{
+			UserID:     account.UserID,
+			TargetType: auditTargetAccount,
+			TargetID:   account.ID,
+			Action:     auditActionDelete,
+			Before:     account,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type updateFollowingRequest struct {
+	WalletID    *int32  `json:"wallet_id" binding:"omitempty,min=1"`
+	CategoryID  *int32  `json:"category_id" binding:"omitempty,min=1"`
+	Title       *string `json:"title" binding:"omitempty,min=1"`
+	Type        *string `json:"type" binding:"omitempty,oneof=credit debit"`
+	Description *string `json:"description"`
+	Amount      *string `json:"amount"`
+	Rule        *string `json:"rule"`
+}
+
+// updateFollowing edits an occurrence and all the ones after it. The
+// series is split in two: the old one ends the day before and a new one
+// starts on the date with the changes. Entries already created on or after
+// the date are replaced by the new series, so they pick up the changes too.
+func (server *Server) updateFollowing(ctx *gin.Context) {
+	var uri occurrenceRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateFollowingRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	date := recurrence.Date(uri.Date)
+	var following db.RecurringTransaction
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetRecurringTransactionForUpdate(ctx, db.GetRecurringTransactionForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		rule, err := recurrence.Parse(before.Rule)
+		if err != nil {
+			return err
+		}
+		if !rule.Includes(before.StartDate, date) {
+			return errNotAnOccurrence
+		}
+		earlier := rule.CountBefore(before.StartDate, date)
+
+		template := recurringTemplate{
+			WalletID:    before.WalletID,
+			CategoryID:  before.CategoryID,
+			Title:       before.Title,
+			Type:        before.Type,
+			Description: before.Description,
+			Amount:      formatAmount(before.Amount, before.Currency),
+			// Without a new amount the wallet must keep the currency.
+			Currency:  before.Currency,
+			Rule:      rule,
+			StartDate: date,
+		}
+		if template.Rule.Count > 0 {
+			template.Rule.Count -= earlier
+		}
+		if req.WalletID != nil {
+			template.WalletID = *req.WalletID
+		}
+		if req.CategoryID != nil {
+			template.CategoryID = *req.CategoryID
+		}
+		if req.Title != nil {
+			template.Title = *req.Title
+		}
+		if req.Type != nil {
+			template.Type = *req.Type
+		}
+		if req.Description != nil {
+			template.Description = *req.Description
+		}
+		if req.Amount != nil {
+			template.Amount = *req.Amount
+			template.Currency = ""
+		}
+		if req.Rule != nil {
+			template.Rule, err = recurrence.Parse(*req.Rule)
+			if err != nil {
+				return err
+			}
+		}
+
+		arg, err := prepareRecurring(ctx, q, userID, template)
+		if err != nil {
+			return err
+		}
+
+		removed, err := q.DeleteRecurringOccurrencesFrom(ctx, db.DeleteRecurringOccurrencesFromParams{
+			RecurringID:    before.ID,
+			OccurrenceDate: date,
+		})
+		if err != nil {
+			return err
+		}
+		err = checkEnvelopeMonths(ctx, q, removed...)
+		if err != nil {
+			return err
+		}
+		err = auditDeletedAccounts(ctx, q, removed)
+		if err != nil {
+			return err
+		}
+
+		// The skipped dates are copied before the old series ends, since
+		// ending it from its first occurrence deletes it and its exceptions.
+		following, err = q.CreateRecurringTransaction(ctx, arg)
+		if err != nil {
+			return err
+		}
+		err = q.CopyRecurringExceptions(ctx, db.CopyRecurringExceptionsParams{
+			NewRecurringID: following.ID,
+			RecurringID:    before.ID,
+			FromDate:       date,
+		})
+		if err != nil {
+			return err
+		}
+		err = endRecurring(ctx, q, before, rule, date, earlier)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetRecurring,
+			TargetID:   following.ID,
+			Action:     auditActionCreate,
+			After:      following,
+		})
+		if err != nil {
+			return err
+		}
+
+		following, _, err = materialiseRecurring(ctx, q, following, recurrence.Date(time.Now()))
+		return err
+	})
+	if err != nil {
+		recurringErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newRecurringResponse(following))
+}
+
+// endRecurring cuts a series short before date. A series with nothing left
+// before the date is deleted.
+func endRecurring(ctx *gin.Context, q *db.Queries, recurring db.RecurringTransaction, rule recurrence.Rule, date time.Time, earlier int) error {
+	if earlier == 0 {
+		_, err := q.DeleteRecurringTransaction(ctx, db.DeleteRecurringTransactionParams{
+			ID:     recurring.ID,
+			UserID: recurring.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     recurring.UserID,
+			TargetType: auditTargetRecurring,
+			TargetID:   recurring.ID,
+			Action:     auditActionDelete,
+			Before:     recurring,
+		})
+	}
+
+	if rule.Count > 0 {
+		rule.Count = earlier
+	} else {
+		rule.Until = date.AddDate(0, 0, -1)
+	}
+	nextDate := recurring.NextDate
+	if nextDate.Valid && !nextDate.Time.Before(date) {
+		nextDate = sql.NullTime{}
+	}
+
+	ended, err := q.UpdateRecurringSchedule(ctx, db.UpdateRecurringScheduleParams{
+		ID:       recurring.ID,
+		Rule:     rule.String(),
+		NextDate: nextDate,
+	})
+	if err != nil {
+		return err
+	}
+	return recordAudit(ctx, q, auditEvent{
+		UserID:     recurring.UserID,
+		TargetType: auditTargetRecurring,
+		TargetID:   recurring.ID,
+		Action:     auditActionUpdate,
+		Before:     recurring,
+		After:      ended,
+	})
+}
@@ -1,13 +1,31 @@
 package api
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/mail"
+	"github.com/GustavoNoronha0/gofinance-backend/notify"
+	"github.com/GustavoNoronha0/gofinance-backend/oidc"
+	"github.com/GustavoNoronha0/gofinance-backend/throttle"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
 	"github.com/gin-gonic/gin"
 )
 
 type Server struct {
-	store  *db.SQLStore
+	config util.Config
+	store  db.Store
+	keys   *util.KeyManager
+	mailer mail.Mailer
 	router *gin.Engine
+
+	notifier       *notify.Dispatcher
+	loginLimiter   *throttle.Limiter
+	passwordPolicy *util.PasswordPolicy
+	oidcProvider   *oidc.Provider
 }
 
 func CORSConfig() gin.HandlerFunc {
@@ -15,7 +33,7 @@ func CORSConfig() gin.HandlerFunc {
 		context.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		context.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		context.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		context.Writer.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, GET, PUT")
+		context.Writer.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, GET, PUT, PATCH")
 
 		if context.Request.Method == "OPTIONS" {
 			context.AbortWithStatus(204)
@@ -26,33 +44,245 @@ func CORSConfig() gin.HandlerFunc {
 	}
 }
 
-func NewServer(store *db.SQLStore) *Server {
-	server := &Server{store: store}
+func NewServer(config util.Config, store db.Store) (*Server, error) {
+	keys, err := util.LoadKeyManager(config.TokenKeys)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load token keys: %w", err)
+	}
+
+	mailer, err := newMailer(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create mailer: %w", err)
+	}
+
+	notifier, err := newNotifier(config, mailer)
+	if err != nil {
+		return nil, err
+	}
+
+	loginLimiter, err := newLoginLimiter(config, store)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordPolicy, err := util.LoadPasswordPolicy(config.PasswordMinLength, config.PasswordMaxLength, config.PasswordBreachedList)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcProvider, err := newOIDCProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		config:         config,
+		store:          store,
+		keys:           keys,
+		mailer:         mailer,
+		notifier:       notifier,
+		loginLimiter:   loginLimiter,
+		passwordPolicy: passwordPolicy,
+		oidcProvider:   oidcProvider,
+	}
 	router := gin.Default()
 	router.Use(CORSConfig())
 
 	router.POST("/user", server.createUser)
-	router.GET("/user/:username", server.getUser)
-	router.GET("/user/id/:id", server.getUserById)
-
-	router.POST("/category", server.createCategory)
-	router.GET("/category/id/:id", server.getCategory)
-	router.GET("/category", server.getCategories)
-	router.DELETE("/category/:id", server.deleteCategory)
-	router.PUT("/category/:id", server.updateCategory)
-
-	router.POST("/account", server.createAccount)
-	router.GET("/account/id/:id", server.getAccount)
-	router.GET("/account", server.getAccounts)
-	router.GET("/account/graph/:user_id/:type", server.getAccountGraph)
-	router.GET("/account/reports/:user_id/:type", server.getAccountReports)
-	router.DELETE("/account/:id", server.deleteAccount)
-	router.PUT("/account/:id", server.updateAccount)
 
 	router.POST("/login", server.login)
+	router.POST("/login/mfa", server.loginMFA)
+	router.POST("/login/unlock", server.unlockAccount)
+	router.POST("/token/refresh", server.refreshToken)
+	router.GET("/.well-known/jwks.json", server.getJWKS)
+	router.POST("/password/forgot", server.forgotPassword)
+	router.POST("/password/reset", server.resetPassword)
+	router.POST("/email/verify", server.verifyEmail)
+
+	if server.oidcProvider != nil {
+		router.GET("/oidc/login", server.startOIDCLogin)
+		router.POST("/oidc/callback", server.finishOIDCLogin)
+	}
+
+	authRoutes := router.Group("/", server.authMiddleware())
+
+	sessionRoutes := authRoutes.Group("/", requireSession())
+
+	sessionRoutes.PATCH("/me", server.updateMe)
+	sessionRoutes.POST("/me/password", server.changePassword)
+	sessionRoutes.DELETE("/me", server.deleteMe)
+	sessionRoutes.POST("/me/restore", server.restoreMe)
+	sessionRoutes.GET("/me/export", server.exportMe)
+
+	sessionRoutes.POST("/logout", server.logout)
+	sessionRoutes.POST("/logout/all", server.logoutAll)
+	sessionRoutes.POST("/email/verify/resend", server.resendEmailVerification)
+	sessionRoutes.POST("/mfa/totp/enroll", server.enrollTOTP)
+	sessionRoutes.POST("/mfa/totp/confirm", server.confirmTOTP)
+	sessionRoutes.POST("/mfa/totp/disable", server.disableTOTP)
+
+	sessionRoutes.POST("/tokens", server.createPersonalAccessToken)
+	sessionRoutes.GET("/tokens", server.listPersonalAccessTokens)
+	sessionRoutes.DELETE("/tokens/:id", server.revokePersonalAccessToken)
+
+	sessionRoutes.POST("/notifications/read", server.markAllNotificationsRead)
+	sessionRoutes.POST("/notifications/:id/read", server.markNotificationRead)
+
+	adminRoutes := sessionRoutes.Group("/admin", requireRole(util.RoleAdmin, util.RoleSupportReadonly))
+
+	adminRoutes.GET("/users", server.listUsers)
+	adminRoutes.GET("/users/:id", server.getUser)
+	adminRoutes.GET("/users/:id/stats", server.getUserStats)
+
+	adminWriteRoutes := adminRoutes.Group("/", requireRole(util.RoleAdmin))
+
+	adminWriteRoutes.PUT("/users/:id/role", server.updateUserRole)
+	adminWriteRoutes.POST("/users/:id/disable", server.disableUser)
+	adminWriteRoutes.POST("/users/:id/enable", server.enableUser)
+	adminWriteRoutes.POST("/users/:id/password-reset", server.forcePasswordReset)
+	adminWriteRoutes.POST("/exchange-rates", server.importExchangeRates)
+
+	readRoutes := authRoutes.Group("/", requireScope(scopeRead))
+
+	readRoutes.GET("/me", server.getMe)
+	readRoutes.GET("/audit", server.listAuditEvents)
+
+	readRoutes.GET("/category/id/:id", server.getCategory)
+	readRoutes.GET("/category", server.getCategories)
+
+	readRoutes.GET("/account/id/:id", server.getAccount)
+	readRoutes.GET("/account", server.getAccounts)
+	readRoutes.GET("/account/graph/:user_id/:type", server.getAccountGraph)
+	readRoutes.GET("/account/reports/:user_id/:type", server.getAccountReports)
+	readRoutes.GET("/account/cashflow", server.getCashFlow)
+
+	readRoutes.GET("/wallets", server.listWallets)
+	readRoutes.GET("/wallets/:id", server.getWallet)
+
+	readRoutes.GET("/transfers", server.listTransfers)
+	readRoutes.GET("/transfers/:id", server.getTransfer)
+
+	readRoutes.GET("/recurring", server.listRecurring)
+	readRoutes.GET("/recurring/:id", server.getRecurring)
+	readRoutes.GET("/recurring/:id/preview", server.previewRecurring)
+
+	readRoutes.GET("/installments", server.listInstallmentPlans)
+	readRoutes.GET("/installments/:id", server.getInstallmentPlan)
+
+	readRoutes.GET("/budgets", server.listBudgets)
+	readRoutes.GET("/budgets/:period/status", server.getBudgetStatus)
+
+	readRoutes.GET("/envelopes/:period", server.getEnvelopes)
+
+	readRoutes.GET("/goals", server.listGoals)
+	readRoutes.GET("/goals/:id", server.getGoal)
+
+	readRoutes.GET("/notifications", server.listNotifications)
+
+	verifiedRoutes := authRoutes.Group("/", requireVerifiedEmail())
+
+	categoryWriteRoutes := verifiedRoutes.Group("/", requireScope(scopeCategoriesWrite))
+
+	categoryWriteRoutes.POST("/category", server.createCategory)
+	categoryWriteRoutes.DELETE("/category/:id", server.deleteCategory)
+	categoryWriteRoutes.PUT("/category/:id", server.updateCategory)
+
+	categoryWriteRoutes.POST("/budgets", server.createBudget)
+	categoryWriteRoutes.PATCH("/budgets/:id", server.updateBudget)
+	categoryWriteRoutes.DELETE("/budgets/:id", server.deleteBudget)
+
+	categoryWriteRoutes.PUT("/envelopes/mode", server.setEnvelopeMode)
+	categoryWriteRoutes.PUT("/envelopes/:period/:category_id", server.assignEnvelope)
+	categoryWriteRoutes.POST("/envelopes/:period/move", server.moveEnvelope)
+	categoryWriteRoutes.POST("/envelopes/:period/close", server.closeEnvelopeMonth)
+
+	transactionWriteRoutes := verifiedRoutes.Group("/", requireScope(scopeTransactionsWrite))
+
+	transactionWriteRoutes.POST("/account", server.createAccount)
+	transactionWriteRoutes.DELETE("/account/:id", server.deleteAccount)
+	transactionWriteRoutes.PUT("/account/:id", server.updateAccount)
+
+	transactionWriteRoutes.POST("/wallets", server.createWallet)
+	transactionWriteRoutes.PATCH("/wallets/:id", server.updateWallet)
+	transactionWriteRoutes.DELETE("/wallets/:id", server.deleteWallet)
+
+	transactionWriteRoutes.POST("/transfers", server.createTransfer)
+	transactionWriteRoutes.PUT("/transfers/:id", server.updateTransfer)
+	transactionWriteRoutes.DELETE("/transfers/:id", server.deleteTransfer)
+
+	transactionWriteRoutes.POST("/recurring", server.createRecurring)
+	transactionWriteRoutes.DELETE("/recurring/:id", server.deleteRecurring)
+	transactionWriteRoutes.POST("/recurring/:id/occurrences/:date/skip", server.skipOccurrence)
+	transactionWriteRoutes.PUT("/recurring/:id/occurrences/:date", server.updateFollowing)
+
+	transactionWriteRoutes.POST("/installments", server.createInstallmentPlan)
+	transactionWriteRoutes.PATCH("/installments/:id", server.updateInstallmentPlan)
+	transactionWriteRoutes.POST("/installments/:id/payoff", server.payOffInstallmentPlan)
+	transactionWriteRoutes.POST("/installments/:id/cancel", server.cancelInstallmentPlan)
+
+	transactionWriteRoutes.POST("/goals", server.createGoal)
+	transactionWriteRoutes.PATCH("/goals/:id", server.updateGoal)
+	transactionWriteRoutes.DELETE("/goals/:id", server.deleteGoal)
+	transactionWriteRoutes.POST("/goals/:id/contributions", server.contributeToGoal)
 
 	server.router = router
-	return server
+	return server, nil
+}
+
+// newOIDCProvider returns nil when single sign-on isn't configured.
+func newOIDCProvider(config util.Config) (*oidc.Provider, error) {
+	if config.OIDCIssuerURL == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, err := oidc.Discover(ctx, oidc.Config{
+		IssuerURL:    config.OIDCIssuerURL,
+		ClientID:     config.OIDCClientID,
+		ClientSecret: config.OIDCClientSecret,
+		RedirectURL:  config.OIDCRedirectURL,
+		Scopes:       []string{"email", "profile"},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set up oidc: %w", err)
+	}
+	return provider, nil
+}
+
+func newMailer(config util.Config) (mail.Mailer, error) {
+	switch config.Mailer {
+	case "smtp":
+		return mail.NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom), nil
+	case "file":
+		return mail.NewFileMailer(config.MailDir, config.MailFrom)
+	case "memory":
+		return mail.NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", config.Mailer)
+	}
+}
+
+// newNotifier sets up the channels notifications are sent through besides
+// the app itself.
+func newNotifier(config util.Config, mailer mail.Mailer) (*notify.Dispatcher, error) {
+	var channels []notify.Channel
+	for _, name := range strings.Split(config.NotificationChannels, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "email":
+			channels = append(channels, notify.NewEmailChannel(mailer))
+		case "webhook":
+			if config.NotificationWebhookURL == "" {
+				return nil, fmt.Errorf("webhook notifications need NOTIFICATION_WEBHOOK_URL")
+			}
+			channels = append(channels, notify.NewWebhookChannel(config.NotificationWebhookURL, config.NotificationWebhookSecret))
+		default:
+			return nil, fmt.Errorf("unknown notification channel %q", name)
+		}
+	}
+	return notify.NewDispatcher(channels...), nil
 }
 
 func (server *Server) Start(address string) error {
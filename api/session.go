@@ -0,0 +1,155 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errInvalidRefreshToken = errors.New("refresh token is invalid")
+	errReusedRefreshToken  = errors.New("refresh token was already used, session has been revoked")
+)
+
+type sessionResponse struct {
+	ID        string     `json:"id"`
+	UserAgent string     `json:"user_agent"`
+	ClientIP  string     `json:"client_ip"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func newSessionResponse(session db.Session) sessionResponse {
+	rsp := sessionResponse{
+		ID:        session.ID.String(),
+		UserAgent: session.UserAgent,
+		ClientIP:  session.ClientIp,
+		CreatedAt: session.CreatedAt,
+	}
+	if session.RevokedAt.Valid {
+		rsp.RevokedAt = &session.RevokedAt.Time
+	}
+	return rsp
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (server *Server) refreshToken(ctx *gin.Context) {
+	var req refreshTokenRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	storedToken, err := server.store.GetRefreshToken(ctx, util.HashToken(req.RefreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidRefreshToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if storedToken.SessionRevokedAt.Valid || time.Now().After(storedToken.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidRefreshToken))
+		return
+	}
+
+	rows, err := server.store.UseRefreshToken(ctx, storedToken.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		// The token was rotated before, so whoever presents it now may have
+		// stolen it: kill the whole session family.
+		_, err = server.store.RevokeSession(ctx, db.RevokeSessionParams{
+			ID:     storedToken.SessionID,
+			UserID: storedToken.UserID,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errReusedRefreshToken))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, storedToken.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = checkUserCanLogin(user)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.issueTokens(ctx, user, storedToken.SessionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) logout(ctx *gin.Context) {
+	claims := authClaims(ctx)
+	err := server.store.ExecTx(ctx, func(q *db.Queries) error {
+		_, err := q.RevokeSession(ctx, db.RevokeSessionParams{
+			ID:     claims.SessionID,
+			UserID: claims.UserID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     claims.UserID,
+			TargetType: auditTargetSession,
+			Action:     auditActionLogout,
+			Before:     gin.H{"session_id": claims.SessionID},
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+func (server *Server) logoutAll(ctx *gin.Context) {
+	userID := authClaims(ctx).UserID
+	err := server.store.ExecTx(ctx, func(q *db.Queries) error {
+		revoked, err := q.RevokeUserSessions(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetSession,
+			Action:     auditActionLogout,
+			Before:     gin.H{"sessions": revoked},
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
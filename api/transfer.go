@@ -0,0 +1,523 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/money"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	postingKindSource      = "source"
+	postingKindDestination = "destination"
+	postingKindFee         = "fee"
+	postingKindExchange    = "exchange"
+)
+
+var (
+	errSameWallet            = errors.New("transfer needs two different wallets")
+	errTransferAmount        = errors.New("transfer amount must be greater than zero")
+	errNegativeTransferFee   = errors.New("transfer fee can't be negative")
+	errTransferRateNotNeeded = errors.New("rate is only used between wallets in different currencies")
+)
+
+type transferRequest struct {
+	FromWalletID int32     `json:"from_wallet_id" binding:"required,min=1"`
+	ToWalletID   int32     `json:"to_wallet_id" binding:"required,min=1"`
+	Amount       string    `json:"amount" binding:"required"`
+	Fee          string    `json:"fee"`
+	Rate         string    `json:"rate"`
+	Date         time.Time `json:"date" binding:"required"`
+	Description  string    `json:"description"`
+}
+
+type postingResponse struct {
+	ID       int64  `json:"id"`
+	WalletID *int32 `json:"wallet_id"`
+	Kind     string `json:"kind"`
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type transferResponse struct {
+	ID               int32             `json:"id"`
+	Description      string            `json:"description"`
+	Date             time.Time         `json:"date"`
+	FromWalletID     int32             `json:"from_wallet_id"`
+	ToWalletID       int32             `json:"to_wallet_id"`
+	Amount           string            `json:"amount"`
+	Currency         string            `json:"currency"`
+	Fee              string            `json:"fee"`
+	ReceivedAmount   string            `json:"received_amount"`
+	ReceivedCurrency string            `json:"received_currency"`
+	Rate             *string           `json:"rate"`
+	Postings         []postingResponse `json:"postings"`
+	CreatedAt        time.Time         `json:"created_at"`
+}
+
+// newTransferResponse reads the sent amount, fee and received amount back
+// from the postings of the transfer.
+func newTransferResponse(transfer db.Transfer, postings []db.Posting) transferResponse {
+	rsp := transferResponse{
+		ID:          transfer.ID,
+		Description: transfer.Description,
+		Date:        transfer.Date,
+		Postings:    make([]postingResponse, 0, len(postings)),
+		CreatedAt:   transfer.CreatedAt,
+	}
+	if transfer.Rate.Valid {
+		rsp.Rate = &transfer.Rate.String
+	}
+
+	var source, fee, destination money.Money
+	for _, posting := range postings {
+		amount := money.Money{Amount: posting.Amount, Currency: posting.Currency}
+		switch posting.Kind {
+		case postingKindSource:
+			rsp.FromWalletID = posting.WalletID.Int32
+			source = amount
+		case postingKindDestination:
+			rsp.ToWalletID = posting.WalletID.Int32
+			destination = amount
+		case postingKindFee:
+			fee = amount
+		}
+
+		postingRsp := postingResponse{
+			ID:       posting.ID,
+			Kind:     posting.Kind,
+			Amount:   amount.String(),
+			Currency: posting.Currency,
+		}
+		if posting.WalletID.Valid {
+			postingRsp.WalletID = &posting.WalletID.Int32
+		}
+		rsp.Postings = append(rsp.Postings, postingRsp)
+	}
+
+	fee.Currency = source.Currency
+	rsp.Amount = money.Money{Amount: -source.Amount - fee.Amount, Currency: source.Currency}.String()
+	rsp.Currency = source.Currency
+	rsp.Fee = fee.String()
+	rsp.ReceivedAmount = destination.String()
+	rsp.ReceivedCurrency = destination.Currency
+	return rsp
+}
+
+type transferPosting struct {
+	WalletID int32
+	Kind     string
+	Amount   money.Money
+}
+
+// transferPostings splits a transfer into postings that add up to zero in
+// every currency. The source wallet pays the amount and the fee; across
+// currencies the amount goes through an exchange leg that takes it in the
+// source currency and gives it out converted at rate.
+func transferPostings(from, to db.Wallet, amount, fee money.Money, rate *big.Rat) ([]transferPosting, error) {
+	sent, err := amount.Add(fee)
+	if err != nil {
+		return nil, err
+	}
+
+	postings := []transferPosting{{
+		WalletID: from.ID,
+		Kind:     postingKindSource,
+		Amount:   money.Money{Amount: -sent.Amount, Currency: sent.Currency},
+	}}
+	if fee.Amount != 0 {
+		postings = append(postings, transferPosting{Kind: postingKindFee, Amount: fee})
+	}
+
+	received := amount
+	if from.Currency != to.Currency {
+		received, err = money.Convert(amount, to.Currency, rate)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings,
+			transferPosting{Kind: postingKindExchange, Amount: amount},
+			transferPosting{Kind: postingKindExchange, Amount: money.Money{Amount: -received.Amount, Currency: received.Currency}},
+		)
+	}
+
+	return append(postings, transferPosting{
+		WalletID: to.ID,
+		Kind:     postingKindDestination,
+		Amount:   received,
+	}), nil
+}
+
+// prepareTransfer validates req and turns it into postings, along with
+// the rate used between the wallets if they differ in currency.
+func prepareTransfer(ctx *gin.Context, q *db.Queries, userID int32, req transferRequest) ([]transferPosting, sql.NullString, error) {
+	if req.FromWalletID == req.ToWalletID {
+		return nil, sql.NullString{}, errSameWallet
+	}
+	from, err := transactionWallet(ctx, q, req.FromWalletID, userID, "")
+	if err != nil {
+		return nil, sql.NullString{}, err
+	}
+	to, err := transactionWallet(ctx, q, req.ToWalletID, userID, "")
+	if err != nil {
+		return nil, sql.NullString{}, err
+	}
+
+	amount, err := parseAmount(req.Amount, from.Currency)
+	if err != nil {
+		return nil, sql.NullString{}, err
+	}
+	if amount.Amount <= 0 {
+		return nil, sql.NullString{}, errTransferAmount
+	}
+	if req.Fee == "" {
+		req.Fee = "0"
+	}
+	fee, err := parseAmount(req.Fee, from.Currency)
+	if err != nil {
+		return nil, sql.NullString{}, err
+	}
+	if fee.Amount < 0 {
+		return nil, sql.NullString{}, errNegativeTransferFee
+	}
+
+	rate, err := transferRate(ctx, q, from, to, req)
+	if err != nil {
+		return nil, sql.NullString{}, err
+	}
+
+	postings, err := transferPostings(from, to, amount, fee, rate)
+	if err != nil {
+		return nil, sql.NullString{}, err
+	}
+	if rate == nil {
+		return postings, sql.NullString{}, nil
+	}
+	return postings, sql.NullString{String: money.FormatRate(rate), Valid: true}, nil
+}
+
+func createPostings(ctx context.Context, q *db.Queries, transferID int32, postings []transferPosting) ([]db.Posting, error) {
+	created := make([]db.Posting, 0, len(postings))
+	for _, posting := range postings {
+		row, err := q.CreatePosting(ctx, db.CreatePostingParams{
+			TransferID: transferID,
+			WalletID:   sql.NullInt32{Int32: posting.WalletID, Valid: posting.WalletID != 0},
+			Kind:       posting.Kind,
+			Amount:     posting.Amount.Amount,
+			Currency:   posting.Amount.Currency,
+		})
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, row)
+	}
+	return created, nil
+}
+
+// transferRate takes the rate given in the request or else the stored
+// exchange rate in effect on the transfer date.
+func transferRate(ctx context.Context, q *db.Queries, from, to db.Wallet, req transferRequest) (*big.Rat, error) {
+	if from.Currency == to.Currency {
+		if req.Rate != "" {
+			return nil, errTransferRateNotNeeded
+		}
+		return nil, nil
+	}
+
+	if req.Rate != "" {
+		rate, err := money.ParseRate(req.Rate)
+		if err != nil {
+			return nil, amountError{err: err}
+		}
+		return rate, nil
+	}
+
+	rate, err := newCurrencyConverter(q, to.Currency).rate(ctx, from.Currency, req.Date)
+	if err != nil {
+		return nil, err
+	}
+	return rate.Rate, nil
+}
+
+func transferErrorResponse(ctx *gin.Context, err error) {
+	var amountErr amountError
+	var missingRate missingRateError
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case err == errSameWallet, err == errTransferAmount, err == errNegativeTransferFee,
+		err == errTransferRateNotNeeded, err == errWalletArchived, errors.As(err, &amountErr):
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	case errors.As(err, &missingRate):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+func (server *Server) createTransfer(ctx *gin.Context) {
+	var req transferRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var rsp transferResponse
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		postings, rate, err := prepareTransfer(ctx, q, userID, req)
+		if err != nil {
+			return err
+		}
+
+		transfer, err := q.CreateTransfer(ctx, db.CreateTransferParams{
+			UserID:      userID,
+			Description: req.Description,
+			Date:        req.Date,
+			Rate:        rate,
+		})
+		if err != nil {
+			return err
+		}
+		created, err := createPostings(ctx, q, transfer.ID, postings)
+		if err != nil {
+			return err
+		}
+		rsp = newTransferResponse(transfer, created)
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetTransfer,
+			TargetID:   transfer.ID,
+			Action:     auditActionCreate,
+			After:      rsp,
+		})
+	})
+	if err != nil {
+		transferErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type transferIDRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var uri transferIDRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, db.GetTransferParams{
+		ID:     uri.ID,
+		UserID: authClaims(ctx).UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	postings, err := server.store.ListTransferPostings(ctx, []int32{transfer.ID})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferResponse(transfer, postings))
+}
+
+type listTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfers, err := server.store.ListTransfers(ctx, db.ListTransfersParams{
+		UserID: authClaims(ctx).UserID,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.transferResponses(ctx, transfers)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// transferResponses loads the postings of all transfers at once.
+func (server *Server) transferResponses(ctx context.Context, transfers []db.Transfer) ([]transferResponse, error) {
+	transferIDs := make([]int32, 0, len(transfers))
+	for _, transfer := range transfers {
+		transferIDs = append(transferIDs, transfer.ID)
+	}
+	postings, err := server.store.ListTransferPostings(ctx, transferIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := make([]transferResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		rsp = append(rsp, newTransferResponse(transfer, postingsOf(postings, transfer.ID)))
+	}
+	return rsp, nil
+}
+
+func postingsOf(postings []db.Posting, transferID int32) []db.Posting {
+	var found []db.Posting
+	for _, posting := range postings {
+		if posting.TransferID == transferID {
+			found = append(found, posting)
+		}
+	}
+	return found
+}
+
+// updateTransfer replaces the transfer as a whole: its postings are
+// written again from the request.
+func (server *Server) updateTransfer(ctx *gin.Context) {
+	var uri transferIDRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req transferRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var rsp transferResponse
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetTransferForUpdate(ctx, db.GetTransferForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		beforePostings, err := q.ListTransferPostings(ctx, []int32{before.ID})
+		if err != nil {
+			return err
+		}
+
+		postings, rate, err := prepareTransfer(ctx, q, userID, req)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteTransferPostings(ctx, before.ID)
+		if err != nil {
+			return err
+		}
+		created, err := createPostings(ctx, q, before.ID, postings)
+		if err != nil {
+			return err
+		}
+
+		transfer, err := q.UpdateTransfer(ctx, db.UpdateTransferParams{
+			ID:          before.ID,
+			UserID:      userID,
+			Description: req.Description,
+			Date:        req.Date,
+			Rate:        rate,
+		})
+		if err != nil {
+			return err
+		}
+		rsp = newTransferResponse(transfer, created)
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetTransfer,
+			TargetID:   transfer.ID,
+			Action:     auditActionUpdate,
+			Before:     newTransferResponse(before, beforePostings),
+			After:      rsp,
+		})
+	})
+	if err != nil {
+		transferErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) deleteTransfer(ctx *gin.Context) {
+	var uri transferIDRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetTransferForUpdate(ctx, db.GetTransferForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		beforePostings, err := q.ListTransferPostings(ctx, []int32{before.ID})
+		if err != nil {
+			return err
+		}
+
+		// Postings go with the transfer.
+		_, err = q.DeleteTransfer(ctx, db.DeleteTransferParams{
+			ID:     before.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetTransfer,
+			TargetID:   before.ID,
+			Action:     auditActionDelete,
+			Before:     newTransferResponse(before, beforePostings),
+		})
+	})
+	if err != nil {
+		transferErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
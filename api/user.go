@@ -1,16 +1,83 @@
 package api
 
 import (
-	"bytes"
-	"crypto/sha512"
 	"database/sql"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/mail"
+	"github.com/GustavoNoronha0/gofinance-backend/money"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/lib/pq"
 )
 
+var (
+	errUsernameOrEmailTaken = errors.New("username or email is already in use")
+	errWrongCurrentPassword = errors.New("current password is wrong")
+	errInvalidTimezone      = errors.New("timezone must be an IANA name like America/Sao_Paulo")
+)
+
+// userResponse is what the API shows of a user. It never carries the
+// password hash.
+type userResponse struct {
+	ID            int32  `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	BaseCurrency  string `json:"base_currency"`
+	// EnvelopeStart is the first month of envelope budgeting, when it's on.
+	EnvelopeStart       *string    `json:"envelope_start,omitempty"`
+	Timezone            string     `json:"timezone"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+func newUserResponse(user db.User) userResponse {
+	rsp := userResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		Role:          user.Role,
+		BaseCurrency:  user.BaseCurrency,
+		Timezone:      user.Timezone,
+		CreatedAt:     user.CreatedAt,
+	}
+	if user.EnvelopeStart.Valid {
+		start := user.EnvelopeStart.Time.Format(periodLayout)
+		rsp.EnvelopeStart = &start
+	}
+	if user.DeletionScheduledAt.Valid {
+		rsp.DeletionScheduledAt = &user.DeletionScheduledAt.Time
+	}
+	return rsp
+}
+
+// loadTimezone reads the time zone a user lives in. The server's own zone
+// isn't one of them.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errInvalidTimezone
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errInvalidTimezone
+	}
+	return location, nil
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code.Name() == "unique_violation"
+}
+
 type createUserRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
@@ -22,74 +89,264 @@ func (server *Server) createUser(ctx *gin.Context) {
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = server.passwordPolicy.Validate(req.Password, req.Username, req.Email)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	hashedInput := sha512.Sum512_256([]byte(req.Password))
-	trimmedHash := bytes.Trim(hashedInput[:], "\x00")
-	preparedPassword := string(trimmedHash)
-	passwordHashInBytes, err := bcrypt.GenerateFromPassword([]byte(preparedPassword), bcrypt.DefaultCost)
+	passwordHashed, err := util.HashPassword(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
-	var passwordHashed = string(passwordHashInBytes)
 	arg := db.CreateUserParams{
 		Username: req.Username,
 		Password: passwordHashed,
 		Email:    req.Email,
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	var user db.User
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		user, err = q.CreateUser(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     user.ID,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID,
+			Action:     auditActionCreate,
+			After:      newUserResponse(user),
+		})
+	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, errorResponse(errUsernameOrEmailTaken))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.sendEmailVerification(ctx, user)
+	if err != nil {
+		log.Printf("cannot send verification email to user %d: %v", user.ID, err)
 	}
 
-	ctx.JSON(http.StatusOK, user)
+	ctx.JSON(http.StatusOK, newUserResponse(user))
 }
 
-type getUserRequest struct {
-	Username string `uri:"username" binding:"required"`
+func (server *Server) getMe(ctx *gin.Context) {
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
 }
 
-func (server *Server) getUser(ctx *gin.Context) {
-	var req getUserRequest
-	err := ctx.ShouldBindUri(&req)
+type updateMeRequest struct {
+	Username     *string `json:"username" binding:"omitempty,min=1"`
+	Email        *string `json:"email" binding:"omitempty,email"`
+	BaseCurrency *string `json:"base_currency" binding:"omitempty,len=3"`
+	Timezone     *string `json:"timezone"`
+}
+
+// updateMeResponse carries a new session when the email changed, since
+// the tokens of the old ones still said it was verified.
+type updateMeResponse struct {
+	userResponse
+	Session *loginResponse `json:"session,omitempty"`
+}
+
+func (server *Server) updateMe(ctx *gin.Context) {
+	var req updateMeRequest
+	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	user, err := server.store.GetUser(ctx, req.Username)
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateUserProfileParams{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		BaseCurrency:    user.BaseCurrency,
+		Timezone:        user.Timezone,
+	}
+	if req.Username != nil {
+		arg.Username = *req.Username
+	}
+	if req.BaseCurrency != nil {
+		arg.BaseCurrency = strings.ToUpper(*req.BaseCurrency)
+		if !money.IsCurrency(arg.BaseCurrency) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(money.UnknownCurrencyError{Code: arg.BaseCurrency}))
+			return
+		}
+	}
+	if req.Timezone != nil {
+		_, err = loadTimezone(*req.Timezone)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		arg.Timezone = *req.Timezone
+	}
+	emailChanged := req.Email != nil && *req.Email != user.Email
+	if emailChanged {
+		arg.Email = *req.Email
+		arg.EmailVerifiedAt = sql.NullTime{}
+	}
+
+	var updated db.User
+	var session db.Session
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		updated, err = q.UpdateUserProfile(ctx, arg)
+		if err != nil {
+			return err
+		}
+		if emailChanged {
+			_, err = q.RevokeUserSessions(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			session, err = q.CreateSession(ctx, db.CreateSessionParams{
+				UserID:    user.ID,
+				UserAgent: ctx.Request.UserAgent(),
+				ClientIp:  ctx.ClientIP(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     user.ID,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID,
+			Action:     auditActionUpdate,
+			Before:     newUserResponse(user),
+			After:      newUserResponse(updated),
+		})
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, errorResponse(errUsernameOrEmailTaken))
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, user)
+	if emailChanged {
+		err = server.sendEmailVerification(ctx, updated)
+		if err != nil {
+			log.Printf("cannot send verification email to user %d: %v", updated.ID, err)
+		}
+
+		err = server.mailer.Send(ctx, mail.Message{
+			To:      []string{user.Email},
+			Subject: "Your gofinance email address was changed",
+			Body: fmt.Sprintf("Hi %s,\n\nThe email address of your account was changed to %s. If you didn't do this, reset your password right away.\n",
+				updated.Username, updated.Email),
+		})
+		if err != nil {
+			log.Printf("cannot send email change notice to user %d: %v", updated.ID, err)
+		}
+	}
+
+	rsp := updateMeResponse{userResponse: newUserResponse(updated)}
+	if emailChanged {
+		rsp.Session, err = server.issueTokens(ctx, updated, session.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
 }
 
-type getUserByIdRequest struct {
-	ID int32 `uri:"id" binding:"required"`
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
 }
 
-func (server *Server) getUserById(ctx *gin.Context) {
-	var req getUserByIdRequest
-	err := ctx.ShouldBindUri(&req)
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	claims := authClaims(ctx)
+	user, err := server.store.GetUserById(ctx, claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = util.CheckPassword(req.CurrentPassword, user.Password)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errWrongCurrentPassword))
+		return
+	}
+
+	err = server.passwordPolicy.Validate(req.NewPassword, user.Username, user.Email)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	user, err := server.store.GetUserById(ctx, req.ID)
+	passwordHashed, err := util.HashPassword(req.NewPassword)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		err := q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			ID:       user.ID,
+			Password: passwordHashed,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.RevokeOtherUserSessions(ctx, db.RevokeOtherUserSessionsParams{
+			UserID: user.ID,
+			ID:     claims.SessionID,
+		})
+		if err != nil {
+			return err
 		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     user.ID,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID,
+			Action:     auditActionPasswordChange,
+		})
+	})
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, user)
+	ctx.JSON(http.StatusOK, true)
 }
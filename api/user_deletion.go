@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/mail"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/gin-gonic/gin"
+)
+
+var errNoDeletionScheduled = errors.New("no account deletion is scheduled")
+
+type deleteMeRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// deleteMe only schedules the deletion. Until the grace period is over the
+// user can still log in, export their data or restore the account.
+func (server *Server) deleteMe(ctx *gin.Context) {
+	var req deleteMeRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, authClaims(ctx).UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = util.CheckPassword(req.Password, user.Password)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errWrongCurrentPassword))
+		return
+	}
+
+	before := user
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		user, err = q.ScheduleUserDeletion(ctx, db.ScheduleUserDeletionParams{
+			ID:                  before.ID,
+			DeletionScheduledAt: time.Now().Add(server.config.DeletionGracePeriod),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.RevokeUserPersonalAccessTokens(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     user.ID,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID,
+			Action:     auditActionDelete,
+			Before:     newUserResponse(before),
+			After:      newUserResponse(user),
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.mailer.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Your gofinance account will be deleted",
+		Body: fmt.Sprintf("Hi %s,\n\nYour account and all of its data will be deleted on %s. Until then you can log in and restore it.\n",
+			user.Username, user.DeletionScheduledAt.Time.Format(time.RFC1123)),
+	})
+	if err != nil {
+		log.Printf("cannot send deletion notice to user %d: %v", user.ID, err)
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (server *Server) restoreMe(ctx *gin.Context) {
+	userID := authClaims(ctx).UserID
+	err := server.store.ExecTx(ctx, func(q *db.Queries) error {
+		restored, err := q.CancelUserDeletion(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if restored == 0 {
+			return errNoDeletionScheduled
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetUser,
+			TargetID:   userID,
+			Action:     auditActionRestore,
+		})
+	})
+	if err != nil {
+		if err == errNoDeletionScheduled {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.getMe(ctx)
+}
+
+// RunDeletionPurger removes, every interval, the users whose deletion grace
+// period is over. Their data goes with them through ON DELETE CASCADE.
+func (server *Server) RunDeletionPurger(ctx context.Context) {
+	ticker := time.NewTicker(server.config.DeletionPurgeEvery)
+	defer ticker.Stop()
+
+	for {
+		purged, err := server.store.PurgeDeletedUsers(ctx)
+		if err != nil {
+			log.Printf("cannot purge deleted users: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d deleted users", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
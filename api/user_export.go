@@ -0,0 +1,523 @@
+package api
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type exportProfile struct {
+	User        userResponse `json:"user"`
+	MFAEnabled  bool         `json:"mfa_enabled"`
+	GeneratedAt time.Time    `json:"generated_at"`
+}
+
+// exportMe streams a ZIP with everything we store about the user. Once the
+// first byte is written the status can't change anymore, so later errors
+// are only logged and leave a truncated archive.
+func (server *Server) exportMe(ctx *gin.Context) {
+	userID := authClaims(ctx).UserID
+
+	user, err := server.store.GetUserById(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	mfaEnabled, err := server.hasTOTPEnabled(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	categories, err := server.store.ListUserCategories(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	wallets, err := server.store.ListUserWallets(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	accounts, err := server.store.ListUserAccounts(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	transfers, err := server.userTransfers(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	recurring, err := server.store.ListRecurringTransactions(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	plans, err := server.store.ListInstallmentPlans(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	installmentPlans, err := server.installmentPlanResponses(ctx, plans)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	budgets, err := server.store.ListBudgets(ctx, db.ListBudgetsParams{UserID: userID})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	notifications, err := server.store.ListUserNotifications(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	envelopeAssignments, err := server.store.ListUserEnvelopeAssignments(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	envelopeCloses, err := server.store.ListUserEnvelopeCloses(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	envelopeCloseBalances, err := server.store.ListUserEnvelopeCloseBalances(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	goals, err := server.store.ListGoals(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	sessions, err := server.store.ListUserSessions(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	identities, err := server.store.ListUserIdentities(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	tokens, err := server.store.ListUserPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	auditEvents, err := server.store.ListUserAuditEvents(ctx, sql.NullInt32{Int32: userID, Valid: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	now := time.Now()
+	filename := fmt.Sprintf("gofinance-export-%s-%s.zip", user.Username, now.Format("20060102"))
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	archive := zip.NewWriter(ctx.Writer)
+	err = writeExport(archive, exportData{
+		Profile: exportProfile{
+			User:        newUserResponse(user),
+			MFAEnabled:  mfaEnabled,
+			GeneratedAt: now,
+		},
+		Categories: categories,
+		Wallets:    wallets,
+		Accounts:   accounts,
+		Transfers:  transfers,
+		Recurring:  recurring,
+		Budgets:    budgets,
+
+		InstallmentPlans: installmentPlans,
+		Notifications:    notifications,
+
+		EnvelopeAssignments: envelopeAssignments,
+		EnvelopeCloses:      newEnvelopeCloseResponses(envelopeCloses, envelopeCloseBalances),
+
+		Goals: goals,
+
+		Sessions:             sessions,
+		Identities:           identities,
+		PersonalAccessTokens: tokens,
+		AuditEvents:          auditEvents,
+	})
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		log.Printf("cannot write export of user %d: %v", userID, err)
+	}
+}
+
+type exportData struct {
+	Profile    exportProfile
+	Categories []db.Category
+	Wallets    []db.Wallet
+	Accounts   []db.Account
+	Transfers  []transferResponse
+	Recurring  []db.RecurringTransaction
+	Budgets    []db.Budget
+
+	InstallmentPlans []installmentPlanResponse
+	Notifications    []db.Notification
+
+	EnvelopeAssignments []db.EnvelopeAssignment
+	EnvelopeCloses      []envelopeCloseResponse
+
+	Goals []db.Goal
+
+	Sessions             []db.Session
+	Identities           []db.UserIdentity
+	PersonalAccessTokens []db.PersonalAccessToken
+	AuditEvents          []db.AuditEvent
+}
+
+func writeExport(archive *zip.Writer, data exportData) error {
+	if err := writeJSONFile(archive, "profile.json", data.Profile); err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "categories.json", data.Categories); err != nil {
+		return err
+	}
+	walletResponses := make([]walletResponse, 0, len(data.Wallets))
+	for _, wallet := range data.Wallets {
+		walletResponses = append(walletResponses, newWalletResponse(wallet))
+	}
+	if err := writeJSONFile(archive, "wallets.json", walletResponses); err != nil {
+		return err
+	}
+	accountResponses := make([]accountResponse, 0, len(data.Accounts))
+	for _, account := range data.Accounts {
+		accountResponses = append(accountResponses, newAccountResponse(account))
+	}
+	if err := writeJSONFile(archive, "accounts.json", accountResponses); err != nil {
+		return err
+	}
+	recurringResponses := make([]recurringResponse, 0, len(data.Recurring))
+	for _, recurring := range data.Recurring {
+		recurringResponses = append(recurringResponses, newRecurringResponse(recurring))
+	}
+	if err := writeJSONFile(archive, "recurring.json", recurringResponses); err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "installments.json", data.InstallmentPlans); err != nil {
+		return err
+	}
+	budgetResponses := make([]budgetResponse, 0, len(data.Budgets))
+	for _, budget := range data.Budgets {
+		budgetResponses = append(budgetResponses, newBudgetResponse(budget))
+	}
+	if err := writeJSONFile(archive, "budgets.json", budgetResponses); err != nil {
+		return err
+	}
+	notificationResponses := make([]notificationResponse, 0, len(data.Notifications))
+	for _, notification := range data.Notifications {
+		notificationResponses = append(notificationResponses, newNotificationResponse(notification))
+	}
+	if err := writeJSONFile(archive, "notifications.json", notificationResponses); err != nil {
+		return err
+	}
+	assignmentResponses := make([]envelopeAssignmentResponse, 0, len(data.EnvelopeAssignments))
+	for _, assignment := range data.EnvelopeAssignments {
+		assignmentResponses = append(assignmentResponses, newEnvelopeAssignmentResponse(assignment))
+	}
+	if err := writeJSONFile(archive, "envelope_assignments.json", assignmentResponses); err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "envelope_closes.json", data.EnvelopeCloses); err != nil {
+		return err
+	}
+	goalRecords := make([]goalRecord, 0, len(data.Goals))
+	for _, goal := range data.Goals {
+		goalRecords = append(goalRecords, newGoalRecord(goal))
+	}
+	if err := writeJSONFile(archive, "goals.json", goalRecords); err != nil {
+		return err
+	}
+	sessionResponses := make([]sessionResponse, 0, len(data.Sessions))
+	for _, session := range data.Sessions {
+		sessionResponses = append(sessionResponses, newSessionResponse(session))
+	}
+	if err := writeJSONFile(archive, "sessions.json", sessionResponses); err != nil {
+		return err
+	}
+	if err := writeJSONFile(archive, "identities.json", data.Identities); err != nil {
+		return err
+	}
+	// Only what the token list shows; the hashes stay out of the archive.
+	tokenResponses := make([]personalAccessTokenResponse, 0, len(data.PersonalAccessTokens))
+	for _, token := range data.PersonalAccessTokens {
+		tokenResponses = append(tokenResponses, newPersonalAccessTokenResponse(token))
+	}
+	if err := writeJSONFile(archive, "personal_access_tokens.json", tokenResponses); err != nil {
+		return err
+	}
+	auditResponses := make([]auditEventResponse, 0, len(data.AuditEvents))
+	for _, event := range data.AuditEvents {
+		auditResponses = append(auditResponses, newAuditEventResponse(event))
+	}
+	if err := writeJSONFile(archive, "audit_events.json", auditResponses); err != nil {
+		return err
+	}
+
+	categoryRows := [][]string{{"id", "title", "type", "description", "created_at"}}
+	for _, category := range data.Categories {
+		categoryRows = append(categoryRows, []string{
+			strconv.Itoa(int(category.ID)),
+			category.Title,
+			category.Type,
+			category.Description,
+			category.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "categories.csv", categoryRows); err != nil {
+		return err
+	}
+
+	walletRows := [][]string{{"id", "name", "kind", "currency", "opening_balance", "archived_at", "created_at"}}
+	for _, wallet := range data.Wallets {
+		archivedAt := ""
+		if wallet.ArchivedAt.Valid {
+			archivedAt = wallet.ArchivedAt.Time.Format(time.RFC3339)
+		}
+		walletRows = append(walletRows, []string{
+			strconv.Itoa(int(wallet.ID)),
+			wallet.Name,
+			wallet.Kind,
+			wallet.Currency,
+			formatAmount(wallet.OpeningBalance, wallet.Currency),
+			archivedAt,
+			wallet.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "wallets.csv", walletRows); err != nil {
+		return err
+	}
+
+	accountRows := [][]string{{"id", "wallet_id", "category_id", "goal_id", "title", "type", "description", "amount", "currency", "date", "created_at"}}
+	for _, account := range data.Accounts {
+		goalID := ""
+		if account.GoalID.Valid {
+			goalID = strconv.Itoa(int(account.GoalID.Int32))
+		}
+		accountRows = append(accountRows, []string{
+			strconv.Itoa(int(account.ID)),
+			strconv.Itoa(int(account.WalletID)),
+			strconv.Itoa(int(account.CategoryID)),
+			goalID,
+			account.Title,
+			account.Type,
+			account.Description,
+			formatAmount(account.Amount, account.Currency),
+			account.Currency,
+			account.Date.Format("2006-01-02"),
+			account.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "accounts.csv", accountRows); err != nil {
+		return err
+	}
+
+	budgetRows := [][]string{{"id", "category_id", "period", "amount", "currency", "rollover", "created_at"}}
+	for _, budget := range data.Budgets {
+		budgetRows = append(budgetRows, []string{
+			strconv.Itoa(int(budget.ID)),
+			strconv.Itoa(int(budget.CategoryID)),
+			budget.Period.Format(periodLayout),
+			formatAmount(budget.Amount, budget.Currency),
+			budget.Currency,
+			strconv.FormatBool(budget.Rollover),
+			budget.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "budgets.csv", budgetRows); err != nil {
+		return err
+	}
+
+	assignmentRows := [][]string{{"id", "category_id", "period", "amount", "currency", "created_at"}}
+	for _, assignment := range data.EnvelopeAssignments {
+		assignmentRows = append(assignmentRows, []string{
+			strconv.Itoa(int(assignment.ID)),
+			strconv.Itoa(int(assignment.CategoryID)),
+			assignment.Period.Format(periodLayout),
+			formatAmount(assignment.Amount, assignment.Currency),
+			assignment.Currency,
+			assignment.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "envelope_assignments.csv", assignmentRows); err != nil {
+		return err
+	}
+
+	goalRows := [][]string{{"id", "title", "target_amount", "currency", "target_date", "wallet_id", "category_id", "created_at"}}
+	for _, goal := range data.Goals {
+		targetDate, walletID, categoryID := "", "", ""
+		if goal.TargetDate.Valid {
+			targetDate = goal.TargetDate.Time.Format("2006-01-02")
+		}
+		if goal.WalletID.Valid {
+			walletID = strconv.Itoa(int(goal.WalletID.Int32))
+		}
+		if goal.CategoryID.Valid {
+			categoryID = strconv.Itoa(int(goal.CategoryID.Int32))
+		}
+		goalRows = append(goalRows, []string{
+			strconv.Itoa(int(goal.ID)),
+			goal.Title,
+			formatAmount(goal.TargetAmount, goal.Currency),
+			goal.Currency,
+			targetDate,
+			walletID,
+			categoryID,
+			goal.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "goals.csv", goalRows); err != nil {
+		return err
+	}
+
+	sessionRows := [][]string{{"id", "user_agent", "client_ip", "revoked_at", "created_at"}}
+	for _, session := range data.Sessions {
+		sessionRows = append(sessionRows, []string{
+			session.ID.String(),
+			session.UserAgent,
+			session.ClientIp,
+			formatNullTime(session.RevokedAt),
+			session.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "sessions.csv", sessionRows); err != nil {
+		return err
+	}
+
+	identityRows := [][]string{{"issuer", "subject", "email", "created_at"}}
+	for _, identity := range data.Identities {
+		identityRows = append(identityRows, []string{
+			identity.Issuer,
+			identity.Subject,
+			identity.Email,
+			identity.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "identities.csv", identityRows); err != nil {
+		return err
+	}
+
+	tokenRows := [][]string{{"id", "name", "token_prefix", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at"}}
+	for _, token := range data.PersonalAccessTokens {
+		tokenRows = append(tokenRows, []string{
+			strconv.Itoa(int(token.ID)),
+			token.Name,
+			token.TokenPrefix,
+			strings.Join(token.Scopes, " "),
+			token.ExpiresAt.Format(time.RFC3339),
+			formatNullTime(token.LastUsedAt),
+			formatNullTime(token.RevokedAt),
+			token.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "personal_access_tokens.csv", tokenRows); err != nil {
+		return err
+	}
+
+	auditRows := [][]string{{"id", "actor_user_id", "target_type", "target_id", "action", "client_ip", "user_agent", "created_at"}}
+	for _, event := range data.AuditEvents {
+		actorID, targetID := "", ""
+		if event.ActorUserID.Valid {
+			actorID = strconv.Itoa(int(event.ActorUserID.Int32))
+		}
+		if event.TargetID.Valid {
+			targetID = strconv.Itoa(int(event.TargetID.Int32))
+		}
+		auditRows = append(auditRows, []string{
+			strconv.FormatInt(event.ID, 10),
+			actorID,
+			event.TargetType,
+			targetID,
+			event.Action,
+			event.ClientIp,
+			event.UserAgent,
+			event.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVFile(archive, "audit_events.csv", auditRows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(archive, "transfers.json", data.Transfers); err != nil {
+		return err
+	}
+	postingRows := [][]string{{"transfer_id", "date", "description", "wallet_id", "kind", "amount", "currency"}}
+	for _, transfer := range data.Transfers {
+		for _, posting := range transfer.Postings {
+			walletID := ""
+			if posting.WalletID != nil {
+				walletID = strconv.Itoa(int(*posting.WalletID))
+			}
+			postingRows = append(postingRows, []string{
+				strconv.Itoa(int(transfer.ID)),
+				transfer.Date.Format("2006-01-02"),
+				transfer.Description,
+				walletID,
+				posting.Kind,
+				posting.Amount,
+				posting.Currency,
+			})
+		}
+	}
+	return writeCSVFile(archive, "transfers.csv", postingRows)
+}
+
+func (server *Server) userTransfers(ctx context.Context, userID int32) ([]transferResponse, error) {
+	transfers, err := server.store.ListUserTransfers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return server.transferResponses(ctx, transfers)
+}
+
+// formatNullTime leaves times that aren't set empty in CSV files.
+func formatNullTime(value sql.NullTime) string {
+	if !value.Valid {
+		return ""
+	}
+	return value.Time.Format(time.RFC3339)
+}
+
+func writeJSONFile(archive *zip.Writer, name string, value interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func writeCSVFile(archive *zip.Writer, name string, rows [][]string) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
@@ -0,0 +1,369 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+var (
+	errWalletArchived         = errors.New("wallet is archived")
+	errWalletHasTransactions  = errors.New("wallet still has transactions; archive it instead")
+	errWalletCurrencyMismatch = errors.New("currency is different of wallet currency")
+)
+
+type walletResponse struct {
+	ID             int32      `json:"id"`
+	Name           string     `json:"name"`
+	Kind           string     `json:"kind"`
+	Currency       string     `json:"currency"`
+	OpeningBalance string     `json:"opening_balance"`
+	Archived       bool       `json:"archived"`
+	ArchivedAt     *time.Time `json:"archived_at,omitempty"`
+	Balance        string     `json:"balance,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func newWalletResponse(wallet db.Wallet) walletResponse {
+	rsp := walletResponse{
+		ID:             wallet.ID,
+		Name:           wallet.Name,
+		Kind:           wallet.Kind,
+		Currency:       wallet.Currency,
+		OpeningBalance: formatAmount(wallet.OpeningBalance, wallet.Currency),
+		Archived:       wallet.ArchivedAt.Valid,
+		CreatedAt:      wallet.CreatedAt,
+	}
+	if wallet.ArchivedAt.Valid {
+		rsp.ArchivedAt = &wallet.ArchivedAt.Time
+	}
+	return rsp
+}
+
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code.Name() == "foreign_key_violation"
+}
+
+// balanceDate defaults balance queries to today.
+func balanceDate(asOf time.Time) time.Time {
+	if asOf.IsZero() {
+		return time.Now()
+	}
+	return asOf
+}
+
+type createWalletRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Kind           string `json:"kind" binding:"required,oneof=checking savings credit_card cash investment other"`
+	Currency       string `json:"currency" binding:"required,len=3"`
+	OpeningBalance string `json:"opening_balance"`
+}
+
+func (server *Server) createWallet(ctx *gin.Context) {
+	var req createWalletRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.OpeningBalance == "" {
+		req.OpeningBalance = "0"
+	}
+	openingBalance, err := parseAmount(req.OpeningBalance, req.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var wallet db.Wallet
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		wallet, err = q.CreateWallet(ctx, db.CreateWalletParams{
+			UserID:         userID,
+			Name:           req.Name,
+			Kind:           req.Kind,
+			Currency:       openingBalance.Currency,
+			OpeningBalance: openingBalance.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetWallet,
+			TargetID:   wallet.ID,
+			Action:     auditActionCreate,
+			After:      wallet,
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newWalletResponse(wallet)
+	rsp.Balance = rsp.OpeningBalance
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type listWalletsRequest struct {
+	IncludeArchived bool      `form:"include_archived"`
+	AsOf            time.Time `form:"as_of" time_format:"2006-01-02"`
+}
+
+// listWallets returns the wallets with their balances at the end of the
+// as_of day, today by default.
+func (server *Server) listWallets(ctx *gin.Context) {
+	var req listWalletsRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	wallets, err := server.store.ListWallets(ctx, db.ListWalletsParams{
+		AsOf:            balanceDate(req.AsOf),
+		UserID:          authClaims(ctx).UserID,
+		IncludeArchived: req.IncludeArchived,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]walletResponse, 0, len(wallets))
+	for _, wallet := range wallets {
+		walletRsp := newWalletResponse(db.Wallet{
+			ID:             wallet.ID,
+			UserID:         wallet.UserID,
+			Name:           wallet.Name,
+			Kind:           wallet.Kind,
+			Currency:       wallet.Currency,
+			OpeningBalance: wallet.OpeningBalance,
+			ArchivedAt:     wallet.ArchivedAt,
+			CreatedAt:      wallet.CreatedAt,
+		})
+		walletRsp.Balance = formatAmount(wallet.Balance, wallet.Currency)
+		rsp = append(rsp, walletRsp)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type walletRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+type getWalletRequest struct {
+	AsOf time.Time `form:"as_of" time_format:"2006-01-02"`
+}
+
+func (server *Server) getWallet(ctx *gin.Context) {
+	var uri walletRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req getWalletRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	wallet, err := server.store.GetWallet(ctx, db.GetWalletParams{
+		ID:     uri.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	balance, err := server.store.GetWalletBalance(ctx, db.GetWalletBalanceParams{
+		AsOf:   balanceDate(req.AsOf),
+		ID:     wallet.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newWalletResponse(wallet)
+	rsp.Balance = formatAmount(balance, wallet.Currency)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateWalletRequest struct {
+	Name           *string `json:"name" binding:"omitempty,min=1"`
+	Kind           *string `json:"kind" binding:"omitempty,oneof=checking savings credit_card cash investment other"`
+	OpeningBalance *string `json:"opening_balance"`
+	Archived       *bool   `json:"archived"`
+}
+
+// updateWallet changes everything but the currency, which the wallet
+// transactions are recorded in.
+func (server *Server) updateWallet(ctx *gin.Context) {
+	var uri walletRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateWalletRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	var wallet db.Wallet
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetWalletForUpdate(ctx, db.GetWalletForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		arg := db.UpdateWalletParams{
+			ID:             before.ID,
+			UserID:         userID,
+			Name:           before.Name,
+			Kind:           before.Kind,
+			OpeningBalance: before.OpeningBalance,
+			ArchivedAt:     before.ArchivedAt,
+		}
+		if req.Name != nil {
+			arg.Name = *req.Name
+		}
+		if req.Kind != nil {
+			arg.Kind = *req.Kind
+		}
+		if req.OpeningBalance != nil {
+			openingBalance, err := parseAmount(*req.OpeningBalance, before.Currency)
+			if err != nil {
+				return err
+			}
+			arg.OpeningBalance = openingBalance.Amount
+		}
+		if req.Archived != nil && *req.Archived != before.ArchivedAt.Valid {
+			arg.ArchivedAt = sql.NullTime{Time: time.Now(), Valid: *req.Archived}
+		}
+
+		wallet, err = q.UpdateWallet(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetWallet,
+			TargetID:   wallet.ID,
+			Action:     auditActionUpdate,
+			Before:     before,
+			After:      wallet,
+		})
+	})
+	if err != nil {
+		var amountErr amountError
+		switch {
+		case err == sql.ErrNoRows:
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.As(err, &amountErr):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWalletResponse(wallet))
+}
+
+// deleteWallet only removes wallets without transactions, so balances
+// and reports never lose entries behind the user's back.
+func (server *Server) deleteWallet(ctx *gin.Context) {
+	var uri walletRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID := authClaims(ctx).UserID
+	err = server.store.ExecTx(ctx, func(q *db.Queries) error {
+		before, err := q.GetWalletForUpdate(ctx, db.GetWalletForUpdateParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteWallet(ctx, db.DeleteWalletParams{
+			ID:     uri.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, auditEvent{
+			UserID:     userID,
+			TargetType: auditTargetWallet,
+			TargetID:   before.ID,
+			Action:     auditActionDelete,
+			Before:     before,
+		})
+	})
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case isForeignKeyViolation(err):
+			ctx.JSON(http.StatusConflict, errorResponse(errWalletHasTransactions))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, true)
+}
+
+// transactionWallet loads the wallet a transaction is recorded in and
+// makes sure new entries can go there.
+func transactionWallet(ctx *gin.Context, q *db.Queries, walletID, userID int32, currency string) (db.Wallet, error) {
+	wallet, err := q.GetWallet(ctx, db.GetWalletParams{
+		ID:     walletID,
+		UserID: userID,
+	})
+	if err != nil {
+		return db.Wallet{}, err
+	}
+	if wallet.ArchivedAt.Valid {
+		return db.Wallet{}, errWalletArchived
+	}
+	if currency != "" && currency != wallet.Currency {
+		return db.Wallet{}, errWalletCurrencyMismatch
+	}
+	return wallet, nil
+}
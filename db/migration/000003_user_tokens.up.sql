@@ -0,0 +1,17 @@
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamptz;
+
+UPDATE "users" SET "email_verified_at" = "created_at";
+
+CREATE TABLE "user_tokens" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "purpose" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "user_tokens" ("user_id", "purpose");
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE "user_totp" (
+  "user_id" int PRIMARY KEY NOT NULL,
+  "secret" varchar NOT NULL,
+  "confirmed_at" timestamptz,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_totp" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("user_id", "code_hash");
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE "users" ADD COLUMN "failed_login_attempts" int NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN "locked_until" timestamptz;
+
+CREATE TABLE "login_attempts" (
+  "key" varchar PRIMARY KEY NOT NULL,
+  "failures" int NOT NULL,
+  "last_failure_at" timestamptz NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- Usernames weren't unique before, so later duplicates are renamed to
+-- username-id to let the index be created. The oldest account keeps the name.
+UPDATE "users" AS u
+SET "username" = u."username" || '-' || u."id"
+WHERE EXISTS (
+  SELECT 1 FROM "users" AS older
+  WHERE older."username" = u."username" AND older."id" < u."id"
+);
+
+CREATE UNIQUE INDEX "users_username_key" ON "users" ("username");
\ No newline at end of file
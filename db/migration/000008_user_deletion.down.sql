@@ -0,0 +1,25 @@
+ALTER TABLE "categories" DROP CONSTRAINT "categories_user_id_fkey";
+ALTER TABLE "categories" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_user_id_fkey";
+ALTER TABLE "accounts" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "sessions" DROP CONSTRAINT "sessions_user_id_fkey";
+ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "refresh_tokens" DROP CONSTRAINT "refresh_tokens_session_id_fkey";
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("session_id") REFERENCES "sessions" ("id");
+
+ALTER TABLE "user_tokens" DROP CONSTRAINT "user_tokens_user_id_fkey";
+ALTER TABLE "user_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "user_totp" DROP CONSTRAINT "user_totp_user_id_fkey";
+ALTER TABLE "user_totp" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "recovery_codes" DROP CONSTRAINT "recovery_codes_user_id_fkey";
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "personal_access_tokens" DROP CONSTRAINT "personal_access_tokens_user_id_fkey";
+ALTER TABLE "personal_access_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deletion_scheduled_at";
\ No newline at end of file
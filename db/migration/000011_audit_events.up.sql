@@ -0,0 +1,18 @@
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "user_id" int,
+  "actor_user_id" int,
+  "target_type" varchar NOT NULL,
+  "target_id" int,
+  "action" varchar NOT NULL,
+  "before" jsonb NOT NULL DEFAULT 'null',
+  "after" jsonb NOT NULL DEFAULT 'null',
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "audit_events" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "audit_events" ADD FOREIGN KEY ("actor_user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "audit_events" ("user_id", "created_at");
@@ -0,0 +1,6 @@
+-- Amounts are truncated back to whole units and the currency is lost.
+ALTER TABLE "accounts" ADD COLUMN "value" integer;
+UPDATE "accounts" SET "value" = ("amount" / 100)::integer;
+ALTER TABLE "accounts" ALTER COLUMN "value" SET NOT NULL;
+ALTER TABLE "accounts" DROP COLUMN "currency";
+ALTER TABLE "accounts" DROP COLUMN "amount";
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- Values used to be whole units of an implicit BRL, so they are scaled to
+-- centavos here.
+ALTER TABLE "accounts" ADD COLUMN "amount" bigint;
+ALTER TABLE "accounts" ADD COLUMN "currency" varchar(3) NOT NULL DEFAULT 'BRL';
+UPDATE "accounts" SET "amount" = "value"::bigint * 100;
+ALTER TABLE "accounts" ALTER COLUMN "amount" SET NOT NULL;
+ALTER TABLE "accounts" ALTER COLUMN "currency" DROP DEFAULT;
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_currency_check" CHECK ("currency" ~ '^[A-Z]{3}$');
+ALTER TABLE "accounts" DROP COLUMN "value";
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "exchange_rates";
+ALTER TABLE "users" DROP COLUMN "base_currency";
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE "users" ADD COLUMN "base_currency" varchar(3) NOT NULL DEFAULT 'BRL';
+ALTER TABLE "users" ADD CONSTRAINT "users_base_currency_check" CHECK ("base_currency" ~ '^[A-Z]{3}$');
+
+-- One unit of base_currency buys rate units of quote_currency on date.
+CREATE TABLE "exchange_rates" (
+  "base_currency" varchar(3) NOT NULL,
+  "quote_currency" varchar(3) NOT NULL,
+  "date" date NOT NULL,
+  "rate" numeric NOT NULL CHECK ("rate" > 0),
+  "source" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("base_currency", "quote_currency", "date")
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE "accounts" DROP COLUMN "wallet_id";
+DROP TABLE IF EXISTS "wallets";
\ No newline at end of file
@@ -0,0 +1,29 @@
+CREATE TABLE "wallets" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "name" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "opening_balance" bigint NOT NULL DEFAULT 0,
+  "archived_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "wallets_kind_check" CHECK ("kind" IN ('checking', 'savings', 'credit_card', 'cash', 'investment', 'other')),
+  CONSTRAINT "wallets_currency_check" CHECK ("currency" ~ '^[A-Z]{3}$')
+);
+
+ALTER TABLE "wallets" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "wallets" ("user_id");
+
+-- Existing entries go to one wallet per user and currency.
+INSERT INTO "wallets" ("user_id", "name", "kind", "currency")
+SELECT DISTINCT "user_id", 'Main ' || "currency", 'other', "currency" FROM "accounts";
+
+ALTER TABLE "accounts" ADD COLUMN "wallet_id" int;
+UPDATE "accounts" a SET "wallet_id" = w."id"
+FROM "wallets" w
+WHERE w."user_id" = a."user_id" AND w."currency" = a."currency";
+ALTER TABLE "accounts" ALTER COLUMN "wallet_id" SET NOT NULL;
+ALTER TABLE "accounts" ADD FOREIGN KEY ("wallet_id") REFERENCES "wallets" ("id");
+
+CREATE INDEX ON "accounts" ("wallet_id", "date");
\ No newline at end of file
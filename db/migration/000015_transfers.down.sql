@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "postings";
+DROP FUNCTION IF EXISTS check_postings_balanced();
+DROP FUNCTION IF EXISTS assert_transfer_balanced(int);
+DROP TABLE IF EXISTS "transfers";
\ No newline at end of file
@@ -0,0 +1,64 @@
+CREATE TABLE "transfers" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "description" varchar NOT NULL,
+  "date" date NOT NULL,
+  "rate" numeric,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "transfers" ("user_id", "date");
+
+-- Source and destination postings move money in and out of wallets. Fees
+-- and the currency exchange leg of cross-currency transfers have no wallet,
+-- they only keep each currency balanced.
+CREATE TABLE "postings" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "transfer_id" int NOT NULL,
+  "wallet_id" int,
+  "kind" varchar NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  CONSTRAINT "postings_kind_check" CHECK ("kind" IN ('source', 'destination', 'fee', 'exchange')),
+  CONSTRAINT "postings_wallet_check" CHECK (("kind" IN ('source', 'destination')) = ("wallet_id" IS NOT NULL))
+);
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id") ON DELETE CASCADE;
+ALTER TABLE "postings" ADD FOREIGN KEY ("wallet_id") REFERENCES "wallets" ("id");
+
+CREATE INDEX ON "postings" ("transfer_id");
+CREATE INDEX ON "postings" ("wallet_id");
+
+CREATE FUNCTION assert_transfer_balanced(checked_transfer_id int) RETURNS void AS $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM "postings"
+    WHERE "transfer_id" = checked_transfer_id
+    GROUP BY "currency"
+    HAVING SUM("amount") <> 0
+  ) THEN
+    RAISE EXCEPTION 'postings of transfer % do not sum to zero', checked_transfer_id
+      USING ERRCODE = 'check_violation';
+  END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION check_postings_balanced() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') THEN
+    PERFORM assert_transfer_balanced(OLD."transfer_id");
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    PERFORM assert_transfer_balanced(NEW."transfer_id");
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Checked at commit, so a transfer can be written one posting at a time.
+CREATE CONSTRAINT TRIGGER "postings_balanced"
+AFTER INSERT OR UPDATE OR DELETE ON "postings"
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION check_postings_balanced();
\ No newline at end of file
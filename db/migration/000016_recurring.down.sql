@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "accounts_recurring_occurrence_key";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "occurrence_date";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "recurring_id";
+DROP TABLE IF EXISTS "recurring_exceptions";
+DROP TABLE IF EXISTS "recurring_transactions";
\ No newline at end of file
@@ -0,0 +1,37 @@
+CREATE TABLE "recurring_transactions" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "wallet_id" int NOT NULL,
+  "category_id" int NOT NULL,
+  "title" varchar NOT NULL,
+  "type" varchar NOT NULL,
+  "description" varchar NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "rule" varchar NOT NULL,
+  "start_date" date NOT NULL,
+  "next_date" date,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("wallet_id") REFERENCES "wallets" ("id");
+ALTER TABLE "recurring_transactions" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id");
+
+CREATE INDEX ON "recurring_transactions" ("next_date");
+
+-- Occurrences the user skipped, so the scheduler doesn't create them.
+CREATE TABLE "recurring_exceptions" (
+  "recurring_id" int NOT NULL,
+  "date" date NOT NULL,
+  PRIMARY KEY ("recurring_id", "date")
+);
+
+ALTER TABLE "recurring_exceptions" ADD FOREIGN KEY ("recurring_id") REFERENCES "recurring_transactions" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "accounts" ADD COLUMN "recurring_id" int;
+ALTER TABLE "accounts" ADD COLUMN "occurrence_date" date;
+ALTER TABLE "accounts" ADD FOREIGN KEY ("recurring_id") REFERENCES "recurring_transactions" ("id") ON DELETE SET NULL;
+
+-- Each occurrence is created once, however often the scheduler runs.
+CREATE UNIQUE INDEX "accounts_recurring_occurrence_key" ON "accounts" ("recurring_id", "occurrence_date");
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "accounts_installment_key";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "installment_number";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "installment_plan_id";
+DROP TABLE IF EXISTS "installment_plans";
\ No newline at end of file
@@ -0,0 +1,34 @@
+CREATE TABLE "installment_plans" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "wallet_id" int NOT NULL,
+  "category_id" int NOT NULL,
+  "title" varchar NOT NULL,
+  "type" varchar NOT NULL,
+  "description" varchar NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "installments" int NOT NULL,
+  "first_due_date" date NOT NULL,
+  "interest_rate" numeric NOT NULL DEFAULT 0,
+  "remainder_on" varchar NOT NULL DEFAULT 'last',
+  "status" varchar NOT NULL DEFAULT 'active',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "installment_plans_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "installment_plans_installments_check" CHECK ("installments" >= 1),
+  CONSTRAINT "installment_plans_interest_rate_check" CHECK ("interest_rate" >= 0),
+  CONSTRAINT "installment_plans_remainder_on_check" CHECK ("remainder_on" IN ('first', 'last')),
+  CONSTRAINT "installment_plans_status_check" CHECK ("status" IN ('active', 'paid_off', 'cancelled'))
+);
+
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("wallet_id") REFERENCES "wallets" ("id");
+ALTER TABLE "installment_plans" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id");
+
+CREATE INDEX ON "installment_plans" ("user_id");
+
+ALTER TABLE "accounts" ADD COLUMN "installment_plan_id" int;
+ALTER TABLE "accounts" ADD COLUMN "installment_number" int;
+ALTER TABLE "accounts" ADD FOREIGN KEY ("installment_plan_id") REFERENCES "installment_plans" ("id") ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX "accounts_installment_key" ON "accounts" ("installment_plan_id", "installment_number");
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "budgets";
\ No newline at end of file
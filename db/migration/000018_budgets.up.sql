@@ -0,0 +1,20 @@
+-- A budget caps what a user plans to spend on an expense category in one
+-- month. Periods are stored as the first day of the month.
+CREATE TABLE "budgets" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "category_id" int NOT NULL,
+  "period" date NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "rollover" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "budgets_amount_check" CHECK ("amount" >= 0),
+  CONSTRAINT "budgets_currency_check" CHECK ("currency" ~ '^[A-Z]{3}$'),
+  CONSTRAINT "budgets_period_check" CHECK ("period" = date_trunc('month', "period")::date)
+);
+
+ALTER TABLE "budgets" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "budgets" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "budgets_user_category_period_key" ON "budgets" ("user_id", "category_id", "period");
\ No newline at end of file
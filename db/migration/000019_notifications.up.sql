@@ -0,0 +1,18 @@
+-- Notifications are kept per user with their read state. dedup_key lets an
+-- event fire only once, e.g. one budget threshold per category and month.
+CREATE TABLE "notifications" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "kind" varchar NOT NULL,
+  "title" varchar NOT NULL,
+  "body" text NOT NULL,
+  "data" jsonb NOT NULL DEFAULT '{}',
+  "dedup_key" varchar,
+  "read_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "notifications_user_dedup_key" ON "notifications" ("user_id", "dedup_key");
+CREATE INDEX ON "notifications" ("user_id", "created_at");
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "envelope_close_balances";
+DROP TABLE IF EXISTS "envelope_closes";
+DROP TABLE IF EXISTS "envelope_assignments";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "envelope_start";
\ No newline at end of file
@@ -0,0 +1,53 @@
+-- Envelope budgeting is on for users with an envelope_start, the first month
+-- their income goes into envelopes.
+ALTER TABLE "users" ADD COLUMN "envelope_start" date;
+ALTER TABLE "users" ADD CONSTRAINT "users_envelope_start_check" CHECK ("envelope_start" = date_trunc('month', "envelope_start")::date);
+
+-- What a user put into the envelope of an expense category in one month.
+-- Negative amounts take money back out to be assigned elsewhere.
+CREATE TABLE "envelope_assignments" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "category_id" int NOT NULL,
+  "period" date NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "envelope_assignments_currency_check" CHECK ("currency" ~ '^[A-Z]{3}$'),
+  CONSTRAINT "envelope_assignments_period_check" CHECK ("period" = date_trunc('month', "period")::date)
+);
+
+ALTER TABLE "envelope_assignments" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "envelope_assignments" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "envelope_assignments_user_category_period_key" ON "envelope_assignments" ("user_id", "category_id", "period");
+
+-- Closed months can no longer have money assigned, so what they carry into
+-- the next month stays put.
+CREATE TABLE "envelope_closes" (
+  "user_id" int NOT NULL,
+  "period" date NOT NULL,
+  "closed_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "period")
+);
+
+ALTER TABLE "envelope_closes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- What each envelope had left when its month closed, in the user's base
+-- currency then. The row without a category is the money that was still
+-- ready to assign. Later months start from these balances, so nothing
+-- recorded afterwards changes what a closed month carried forward.
+CREATE TABLE "envelope_close_balances" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "period" date NOT NULL,
+  "category_id" int,
+  "balance" bigint NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  CONSTRAINT "envelope_close_balances_currency_check" CHECK ("currency" ~ '^[A-Z]{3}$')
+);
+
+ALTER TABLE "envelope_close_balances" ADD FOREIGN KEY ("user_id", "period") REFERENCES "envelope_closes" ("user_id", "period") ON DELETE CASCADE;
+ALTER TABLE "envelope_close_balances" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "envelope_close_balances_user_period_category_key" ON "envelope_close_balances" ("user_id", "period", COALESCE("category_id", 0));
\ No newline at end of file
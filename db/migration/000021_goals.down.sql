@@ -0,0 +1,2 @@
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "goal_id";
+DROP TABLE IF EXISTS "goals";
\ No newline at end of file
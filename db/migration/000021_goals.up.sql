@@ -0,0 +1,27 @@
+-- A goal is an amount a user is saving towards, optionally by a date.
+-- Contributions are entries linked to the goal, by default in its wallet
+-- and category.
+CREATE TABLE "goals" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "title" varchar NOT NULL,
+  "target_amount" bigint NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "target_date" date,
+  "wallet_id" int,
+  "category_id" int,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "goals_target_amount_check" CHECK ("target_amount" > 0),
+  CONSTRAINT "goals_currency_check" CHECK ("currency" ~ '^[A-Z]{3}$')
+);
+
+ALTER TABLE "goals" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "goals" ADD FOREIGN KEY ("wallet_id") REFERENCES "wallets" ("id") ON DELETE SET NULL;
+ALTER TABLE "goals" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "goals" ("user_id");
+
+ALTER TABLE "accounts" ADD COLUMN "goal_id" int;
+ALTER TABLE "accounts" ADD FOREIGN KEY ("goal_id") REFERENCES "goals" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "accounts" ("goal_id", "date");
\ No newline at end of file
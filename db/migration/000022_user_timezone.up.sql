@@ -0,0 +1,3 @@
+-- The IANA time zone the user lives in, e.g. America/Sao_Paulo. It decides
+-- which day "now" is for them and where reports start and end their days.
+ALTER TABLE "users" ADD COLUMN "timezone" varchar NOT NULL DEFAULT 'UTC';
\ No newline at end of file
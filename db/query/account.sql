@@ -1,28 +1,32 @@
 -- name: CreateAccount :one
 INSERT INTO accounts (
   user_id,
+  wallet_id,
   category_id,
   title,
   type,
   description,
-  value,
+  amount,
+  currency,
   date
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
 ) RETURNING *;
 
 -- name: GetAccount :one
 SELECT * FROM accounts
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND user_id = $2 LIMIT 1;
 
 -- name: GetAccounts :many
 SELECT
   a.id,
   a.user_id,
+  a.wallet_id,
   a.title,
   a.type,
   a.description,
-  a.value,
+  a.amount,
+  a.currency,
   a.date,
   a.created_at,
   c.title as category_title
@@ -40,23 +44,61 @@ AND
   LOWER(a.description) LIKE CONCAT('%', LOWER(@description::text), '%')
 AND
   a.category_id = COALESCE(sqlc.narg('category_id'), a.category_id)
+AND
+  a.wallet_id = COALESCE(sqlc.narg('wallet_id'), a.wallet_id)
 AND
   a.date = COALESCE(sqlc.narg('date'), a.date);
 
--- name: GetAccountsReports :one
-SELECT SUM(value) AS sum_value FROM accounts
-where user_id = $1 and type = $2;
+-- name: GetAccountsReports :many
+SELECT currency, date, SUM(amount)::bigint AS sum_amount, COUNT(*) AS count FROM accounts
+where user_id = $1 and type = $2
+GROUP BY currency, date
+ORDER BY date, currency;
 
 -- name: GetAccountsGraph :one
 SELECT COUNT(*) FROM accounts
 where user_id = $1 and type = $2;
 
+-- name: GetCashFlow :many
+SELECT
+  buckets.bucket,
+  accounts.category_id,
+  accounts.type,
+  accounts.currency,
+  accounts.date,
+  COALESCE(SUM(accounts.amount), 0)::bigint AS sum_amount
+FROM (
+  SELECT generate_series(
+    date_trunc(@granularity::text, (@from_date::date)::timestamp),
+    (@to_date::date)::timestamp,
+    ('1 ' || @granularity::text)::interval
+  )::date AS bucket
+) AS buckets
+LEFT JOIN accounts
+  ON accounts.user_id = @user_id
+  AND accounts.date >= buckets.bucket
+  AND accounts.date < buckets.bucket + ('1 ' || @granularity::text)::interval
+  AND accounts.date >= @from_date::date
+  AND accounts.date <= @to_date::date
+GROUP BY buckets.bucket, accounts.category_id, accounts.type, accounts.currency, accounts.date
+ORDER BY buckets.bucket, accounts.date, accounts.category_id, accounts.type, accounts.currency;
+
 -- name: UpdateAccount :one
 UPDATE accounts
-SET title = $2, description = $3, value = $4
-WHERE id = $1
+SET title = $3, description = $4, amount = $5, currency = $6, wallet_id = $7
+WHERE id = $1 AND user_id = $2
 RETURNING *;
 
--- name: DeleteAccount :exec
+-- name: DeleteAccount :execrows
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListUserAccounts :many
+SELECT * FROM accounts
+WHERE user_id = $1
+ORDER BY date, id;
+
+-- name: GetAccountForUpdate :one
+SELECT * FROM accounts
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE;
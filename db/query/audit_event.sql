@@ -0,0 +1,31 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  user_id,
+  actor_user_id,
+  target_type,
+  target_id,
+  action,
+  before,
+  after,
+  client_ip,
+  user_agent
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE
+  user_id = sqlc.arg(user_id)::int
+  AND (sqlc.narg(target_type)::varchar IS NULL OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::int IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(action)::varchar IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+ORDER BY id DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: ListUserAuditEvents :many
+SELECT * FROM audit_events
+WHERE user_id = $1
+ORDER BY id;
\ No newline at end of file
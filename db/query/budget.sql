@@ -0,0 +1,47 @@
+-- name: CreateBudget :one
+INSERT INTO budgets (
+  user_id,
+  category_id,
+  period,
+  amount,
+  currency,
+  rollover
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetBudgetForUpdate :one
+SELECT * FROM budgets
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE;
+
+-- name: UpdateBudget :one
+UPDATE budgets
+SET amount = $3, rollover = $4
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteBudget :execrows
+DELETE FROM budgets
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListBudgets :many
+SELECT * FROM budgets
+WHERE user_id = @user_id
+AND period = COALESCE(sqlc.narg('period'), period)
+ORDER BY period DESC, category_id;
+
+-- name: ListBudgetsUntil :many
+SELECT b.*, c.title AS category_title FROM budgets b
+JOIN categories c ON c.id = b.category_id
+WHERE b.user_id = @user_id AND b.period <= @period::date
+AND (sqlc.narg('category_id')::int IS NULL OR b.category_id = sqlc.narg('category_id'))
+ORDER BY b.category_id, b.period;
+
+-- name: GetBudgetSpending :many
+SELECT category_id, currency, date, SUM(amount)::bigint AS sum_amount FROM accounts
+WHERE user_id = @user_id AND type = 'debit'
+AND date >= @from_date::date AND date < @to_date::date
+AND category_id = ANY(@category_ids::int[])
+GROUP BY category_id, currency, date
+ORDER BY date, category_id, currency;
\ No newline at end of file
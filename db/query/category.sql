@@ -10,7 +10,7 @@ INSERT INTO categories (
 
 -- name: GetCategory :one
 SELECT * FROM categories
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND user_id = $2 LIMIT 1;
 
 -- name: GetCategories :many
 SELECT * FROM categories
@@ -25,10 +25,20 @@ AND
 
 -- name: UpdateCategories :one
 UPDATE categories
-SET title = $2, description = $3
-WHERE id = $1
+SET title = $3, description = $4
+WHERE id = $1 AND user_id = $2
 RETURNING *;
 
--- name: DeleteCategories :exec
+-- name: DeleteCategories :execrows
 DELETE FROM categories
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListUserCategories :many
+SELECT * FROM categories
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetCategoryForUpdate :one
+SELECT * FROM categories
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE;
@@ -0,0 +1,89 @@
+-- name: SetEnvelopeAssignment :one
+INSERT INTO envelope_assignments (
+  user_id,
+  category_id,
+  period,
+  amount,
+  currency
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, category_id, period)
+DO UPDATE SET amount = EXCLUDED.amount, currency = EXCLUDED.currency
+RETURNING *;
+
+-- name: GetEnvelopeAssignmentForUpdate :one
+SELECT * FROM envelope_assignments
+WHERE user_id = $1 AND category_id = $2 AND period = $3 LIMIT 1
+FOR UPDATE;
+
+-- name: ListEnvelopeAssignments :many
+SELECT * FROM envelope_assignments
+WHERE user_id = @user_id
+AND period >= @from_period::date AND period <= @to_period::date
+ORDER BY period, category_id;
+
+-- name: GetEnvelopeActivity :many
+SELECT category_id, type, currency, date, SUM(amount)::bigint AS sum_amount FROM accounts
+WHERE user_id = @user_id
+AND date >= @from_date::date AND date < @to_date::date
+GROUP BY category_id, type, currency, date
+ORDER BY date, category_id, type, currency;
+
+-- name: CreateEnvelopeClose :one
+INSERT INTO envelope_closes (
+  user_id,
+  period
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetLastEnvelopeClose :one
+SELECT * FROM envelope_closes
+WHERE user_id = $1
+ORDER BY period DESC
+LIMIT 1;
+
+-- name: GetEnvelopeCloseBefore :one
+SELECT * FROM envelope_closes
+WHERE user_id = @user_id AND period < @period::date
+ORDER BY period DESC
+LIMIT 1;
+
+-- name: GetEnvelopeClosedUntil :one
+SELECT c.period FROM envelope_closes c
+JOIN users u ON u.id = c.user_id
+WHERE c.user_id = $1 AND u.envelope_start IS NOT NULL
+ORDER BY c.period DESC
+LIMIT 1;
+
+-- name: CreateEnvelopeCloseBalance :one
+INSERT INTO envelope_close_balances (
+  user_id,
+  period,
+  category_id,
+  balance,
+  currency
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListEnvelopeCloseBalances :many
+SELECT * FROM envelope_close_balances
+WHERE user_id = $1 AND period = $2
+ORDER BY category_id NULLS FIRST;
+
+-- name: ListUserEnvelopeAssignments :many
+SELECT * FROM envelope_assignments
+WHERE user_id = $1
+ORDER BY period, category_id;
+
+-- name: ListUserEnvelopeCloses :many
+SELECT * FROM envelope_closes
+WHERE user_id = $1
+ORDER BY period;
+
+-- name: ListUserEnvelopeCloseBalances :many
+SELECT * FROM envelope_close_balances
+WHERE user_id = $1
+ORDER BY period, category_id NULLS FIRST;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- name: UpsertExchangeRate :one
+INSERT INTO exchange_rates (
+  base_currency,
+  quote_currency,
+  date,
+  rate,
+  source
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (base_currency, quote_currency, date)
+DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source
+RETURNING *;
+
+-- name: GetExchangeRate :one
+SELECT * FROM exchange_rates
+WHERE
+  ((base_currency = @from_currency AND quote_currency = @to_currency)
+  OR (base_currency = @to_currency AND quote_currency = @from_currency))
+AND
+  date <= @date
+ORDER BY date DESC
+LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,58 @@
+-- name: CreateGoal :one
+INSERT INTO goals (
+  user_id,
+  title,
+  target_amount,
+  currency,
+  target_date,
+  wallet_id,
+  category_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetGoal :one
+SELECT * FROM goals
+WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: GetGoalForUpdate :one
+SELECT * FROM goals
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE;
+
+-- name: ListGoals :many
+SELECT * FROM goals
+WHERE user_id = $1
+ORDER BY target_date NULLS LAST, id;
+
+-- name: UpdateGoal :one
+UPDATE goals
+SET title = $3, target_amount = $4, target_date = $5, wallet_id = $6, category_id = $7
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteGoal :execrows
+DELETE FROM goals
+WHERE id = $1 AND user_id = $2;
+
+-- name: CreateGoalContribution :one
+INSERT INTO accounts (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  date,
+  goal_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, @goal_id::int
+) RETURNING *;
+
+-- name: GetGoalContributions :many
+SELECT goal_id::int AS goal_id, currency, date, SUM(amount)::bigint AS sum_amount FROM accounts
+WHERE user_id = @user_id AND goal_id = ANY(@goal_ids::int[])
+GROUP BY goal_id, currency, date
+ORDER BY goal_id, date, currency;
\ No newline at end of file
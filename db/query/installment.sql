@@ -0,0 +1,65 @@
+-- name: CreateInstallmentPlan :one
+INSERT INTO installment_plans (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  installments,
+  first_due_date,
+  interest_rate,
+  remainder_on
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: GetInstallmentPlan :one
+SELECT * FROM installment_plans
+WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: GetInstallmentPlanForUpdate :one
+SELECT * FROM installment_plans
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE;
+
+-- name: ListInstallmentPlans :many
+SELECT * FROM installment_plans
+WHERE user_id = $1
+ORDER BY first_due_date DESC, id DESC;
+
+-- name: UpdateInstallmentPlan :one
+UPDATE installment_plans
+SET category_id = $3, title = $4, description = $5, amount = $6, installments = $7,
+  interest_rate = $8, remainder_on = $9, status = $10
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: CreateInstallment :one
+INSERT INTO accounts (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  date,
+  installment_plan_id,
+  installment_number
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, @installment_plan_id::int, @installment_number::int
+) RETURNING *;
+
+-- name: ListPlanInstallments :many
+SELECT * FROM accounts
+WHERE installment_plan_id = ANY(@plan_ids::int[])
+ORDER BY installment_plan_id, installment_number;
+
+-- name: DeleteUnpaidInstallments :many
+DELETE FROM accounts
+WHERE installment_plan_id = @installment_plan_id::int AND date > @paid_until::date
+RETURNING *;
\ No newline at end of file
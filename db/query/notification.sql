@@ -0,0 +1,41 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (
+  user_id,
+  kind,
+  title,
+  body,
+  data,
+  dedup_key
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (user_id, dedup_key) DO NOTHING
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE user_id = @user_id
+AND (NOT @unread_only::bool OR read_at IS NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_limit
+OFFSET @page_offset;
+
+-- name: ListUserNotifications :many
+SELECT * FROM notifications
+WHERE user_id = $1
+ORDER BY created_at, id;
+
+-- name: CountUnreadNotifications :one
+SELECT count(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :one
+UPDATE notifications
+SET read_at = COALESCE(read_at, now())
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = now()
+WHERE user_id = $1 AND read_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,101 @@
+-- name: CreateRecurringTransaction :one
+INSERT INTO recurring_transactions (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  rule,
+  start_date,
+  next_date
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING *;
+
+-- name: GetRecurringTransaction :one
+SELECT * FROM recurring_transactions
+WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: GetRecurringTransactionForUpdate :one
+SELECT * FROM recurring_transactions
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE;
+
+-- name: ListRecurringTransactions :many
+SELECT * FROM recurring_transactions
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: UpdateRecurringSchedule :one
+UPDATE recurring_transactions
+SET rule = $2, next_date = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteRecurringTransaction :execrows
+DELETE FROM recurring_transactions
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListDueRecurringTransactions :many
+SELECT r.id FROM recurring_transactions r
+JOIN wallets w ON w.id = r.wallet_id AND w.archived_at IS NULL
+WHERE r.next_date <= @today::date
+ORDER BY r.next_date, r.id;
+
+-- name: ClaimDueRecurringTransaction :one
+SELECT * FROM recurring_transactions r
+WHERE r.id = $1 AND r.next_date <= @today::date
+  AND EXISTS (
+    SELECT 1 FROM wallets w
+    WHERE w.id = r.wallet_id AND w.archived_at IS NULL
+  )
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateRecurringException :exec
+INSERT INTO recurring_exceptions (
+  recurring_id,
+  date
+) VALUES (
+  $1, $2
+) ON CONFLICT DO NOTHING;
+
+-- name: ListRecurringExceptions :many
+SELECT date FROM recurring_exceptions
+WHERE recurring_id = $1
+ORDER BY date;
+
+-- name: CopyRecurringExceptions :exec
+INSERT INTO recurring_exceptions (recurring_id, date)
+SELECT @new_recurring_id::int, e.date FROM recurring_exceptions e
+WHERE e.recurring_id = @recurring_id AND e.date >= @from_date::date;
+
+-- name: CreateRecurringOccurrence :one
+INSERT INTO accounts (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  date,
+  recurring_id,
+  occurrence_date
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $9
+) ON CONFLICT (recurring_id, occurrence_date) DO NOTHING
+RETURNING *;
+
+-- name: DeleteRecurringOccurrence :many
+DELETE FROM accounts
+WHERE recurring_id = @recurring_id::int AND occurrence_date = @occurrence_date::date
+RETURNING *;
+
+-- name: DeleteRecurringOccurrencesFrom :many
+DELETE FROM accounts
+WHERE recurring_id = @recurring_id::int AND occurrence_date >= @occurrence_date::date
+RETURNING *;
\ No newline at end of file
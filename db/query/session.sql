@@ -0,0 +1,62 @@
+-- name: CreateSession :one
+INSERT INTO sessions (
+  user_id,
+  user_agent,
+  client_ip
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1 LIMIT 1;
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = now()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :execrows
+UPDATE sessions
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (
+  session_id,
+  token_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetRefreshToken :one
+SELECT
+  rt.id,
+  rt.session_id,
+  rt.expires_at,
+  rt.used_at,
+  s.user_id,
+  s.revoked_at AS session_revoked_at
+FROM
+  refresh_tokens rt
+JOIN
+  sessions s ON s.id = rt.session_id
+WHERE
+  rt.token_hash = $1
+LIMIT 1;
+
+-- name: UseRefreshToken :execrows
+UPDATE refresh_tokens
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL;
+
+-- name: RevokeOtherUserSessions :execrows
+UPDATE sessions
+SET revoked_at = now()
+WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL;
+
+-- name: ListUserSessions :many
+SELECT * FROM sessions
+WHERE user_id = $1
+ORDER BY created_at;
\ No newline at end of file
@@ -0,0 +1,60 @@
+-- name: CreateTransfer :one
+INSERT INTO transfers (
+  user_id,
+  description,
+  date,
+  rate
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetTransfer :one
+SELECT * FROM transfers
+WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE;
+
+-- name: UpdateTransfer :one
+UPDATE transfers
+SET description = $3, date = $4, rate = $5
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteTransfer :execrows
+DELETE FROM transfers
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListTransfers :many
+SELECT * FROM transfers
+WHERE user_id = $1
+ORDER BY date DESC, id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ListUserTransfers :many
+SELECT * FROM transfers
+WHERE user_id = $1
+ORDER BY date, id;
+
+-- name: CreatePosting :one
+INSERT INTO postings (
+  transfer_id,
+  wallet_id,
+  kind,
+  amount,
+  currency
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListTransferPostings :many
+SELECT * FROM postings
+WHERE transfer_id = ANY(@transfer_ids::int[])
+ORDER BY transfer_id, id;
+
+-- name: DeleteTransferPostings :exec
+DELETE FROM postings
+WHERE transfer_id = $1;
\ No newline at end of file
@@ -13,4 +13,111 @@ WHERE username = $1 LIMIT 1;
 
 -- name: GetUserById :one
 SELECT * FROM users
-WHERE id = $1 LIMIT 1;
\ No newline at end of file
+WHERE id = $1 LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2, password_reset_required = false
+WHERE id = $1;
+
+-- name: VerifyUserEmail :exec
+UPDATE users
+SET email_verified_at = now()
+WHERE id = $1 AND email_verified_at IS NULL;
+
+-- name: RecordUserLoginFailure :one
+UPDATE users
+SET
+  failed_login_attempts = failed_login_attempts + 1,
+  locked_until = CASE
+    WHEN failed_login_attempts + 1 >= @max_failures::int THEN @locked_until::timestamptz
+    ELSE locked_until
+  END
+WHERE id = @id
+RETURNING failed_login_attempts, locked_until;
+
+-- name: ResetUserLoginFailures :exec
+UPDATE users
+SET failed_login_attempts = 0, locked_until = NULL
+WHERE id = $1;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET username = $2, email = $3, email_verified_at = $4, base_currency = $5, timezone = $6
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserEnvelopeStart :one
+UPDATE users
+SET envelope_start = sqlc.narg(envelope_start)
+WHERE id = @id
+RETURNING *;
+
+-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = sqlc.arg(deletion_scheduled_at)::timestamptz
+WHERE id = $1
+RETURNING *;
+
+-- name: CancelUserDeletion :execrows
+UPDATE users
+SET deletion_scheduled_at = NULL
+WHERE id = $1 AND deletion_scheduled_at IS NOT NULL;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deletion_scheduled_at <= now();
+
+-- name: ListUsers :many
+SELECT * FROM users
+WHERE
+  (sqlc.narg(search)::text IS NULL OR username ILIKE '%' || sqlc.narg(search) || '%' OR email ILIKE '%' || sqlc.narg(search) || '%')
+  AND (sqlc.narg(role)::varchar IS NULL OR role = sqlc.narg(role))
+ORDER BY id
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE id = $1
+RETURNING *;
+
+-- name: EnableUser :one
+UPDATE users
+SET disabled_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: RequireUserPasswordReset :one
+UPDATE users
+SET password_reset_required = true
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserUsageStats :one
+SELECT
+  u.id AS user_id,
+  (SELECT count(*) FROM categories c WHERE c.user_id = u.id)::bigint AS categories,
+  (SELECT count(*) FROM accounts a WHERE a.user_id = u.id)::bigint AS accounts,
+  (SELECT count(*) FROM sessions s WHERE s.user_id = u.id AND s.revoked_at IS NULL)::bigint AS active_sessions,
+  (SELECT count(*) FROM personal_access_tokens t WHERE t.user_id = u.id AND t.revoked_at IS NULL)::bigint AS personal_access_tokens,
+  last_account.created_at AS last_account_at,
+  last_session.created_at AS last_login_at
+FROM users u
+LEFT JOIN accounts last_account ON last_account.id = (
+  SELECT a.id FROM accounts a WHERE a.user_id = u.id ORDER BY a.created_at DESC LIMIT 1
+)
+LEFT JOIN sessions last_session ON last_session.id = (
+  SELECT s.id FROM sessions s WHERE s.user_id = u.id ORDER BY s.created_at DESC LIMIT 1
+)
+WHERE u.id = $1;
@@ -0,0 +1,23 @@
+-- name: CreateUserToken :one
+INSERT INTO user_tokens (
+  user_id,
+  purpose,
+  token_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetUserToken :one
+SELECT * FROM user_tokens
+WHERE token_hash = $1 AND purpose = $2 LIMIT 1;
+
+-- name: UseUserToken :execrows
+UPDATE user_tokens
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL AND expires_at > now();
+
+-- name: InvalidateUserTokens :exec
+UPDATE user_tokens
+SET used_at = now()
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,77 @@
+-- name: CreateWallet :one
+INSERT INTO wallets (
+  user_id,
+  name,
+  kind,
+  currency,
+  opening_balance
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetWallet :one
+SELECT * FROM wallets
+WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: GetWalletForUpdate :one
+SELECT * FROM wallets
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE;
+
+-- name: UpdateWallet :one
+UPDATE wallets
+SET name = $3, kind = $4, opening_balance = $5, archived_at = $6
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteWallet :execrows
+DELETE FROM wallets
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListUserWallets :many
+SELECT * FROM wallets
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetWalletBalance :one
+SELECT
+  (w.opening_balance
+  + COALESCE((
+    SELECT SUM(CASE a.type WHEN 'credit' THEN a.amount WHEN 'debit' THEN -a.amount ELSE 0 END)
+    FROM accounts a
+    WHERE a.wallet_id = w.id AND a.date <= @as_of::date
+  ), 0)
+  + COALESCE((
+    SELECT SUM(p.amount)
+    FROM postings p
+    JOIN transfers t ON t.id = p.transfer_id
+    WHERE p.wallet_id = w.id AND t.date <= @as_of::date
+  ), 0))::bigint AS balance
+FROM
+  wallets w
+WHERE
+  w.id = @id AND w.user_id = @user_id;
+
+-- name: ListWallets :many
+SELECT
+  w.*,
+  (w.opening_balance
+  + COALESCE((
+    SELECT SUM(CASE a.type WHEN 'credit' THEN a.amount WHEN 'debit' THEN -a.amount ELSE 0 END)
+    FROM accounts a
+    WHERE a.wallet_id = w.id AND a.date <= @as_of::date
+  ), 0)
+  + COALESCE((
+    SELECT SUM(p.amount)
+    FROM postings p
+    JOIN transfers t ON t.id = p.transfer_id
+    WHERE p.wallet_id = w.id AND t.date <= @as_of::date
+  ), 0))::bigint AS balance
+FROM
+  wallets w
+WHERE
+  w.user_id = @user_id
+AND
+  (@include_archived::boolean OR w.archived_at IS NULL)
+ORDER BY
+  w.name, w.id;
\ No newline at end of file
@@ -14,35 +14,41 @@ import (
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
   user_id,
+  wallet_id,
   category_id,
   title,
   type,
   description,
-  value,
+  amount,
+  currency,
   date
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, user_id, category_id, title, type, description, value, date, created_at
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id
 `
 
 type CreateAccountParams struct {
 	UserID      int32     `json:"user_id"`
+	WalletID    int32     `json:"wallet_id"`
 	CategoryID  int32     `json:"category_id"`
 	Title       string    `json:"title"`
 	Type        string    `json:"type"`
 	Description string    `json:"description"`
-	Value       int32     `json:"value"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
 	Date        time.Time `json:"date"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
 	row := q.db.QueryRowContext(ctx, createAccount,
 		arg.UserID,
+		arg.WalletID,
 		arg.CategoryID,
 		arg.Title,
 		arg.Type,
 		arg.Description,
-		arg.Value,
+		arg.Amount,
+		arg.Currency,
 		arg.Date,
 	)
 	var i Account
@@ -53,30 +59,50 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Title,
 		&i.Type,
 		&i.Description,
-		&i.Value,
 		&i.Date,
 		&i.CreatedAt,
+		&i.Amount,
+		&i.Currency,
+		&i.WalletID,
+		&i.RecurringID,
+		&i.OccurrenceDate,
+		&i.InstallmentPlanID,
+		&i.InstallmentNumber,
+		&i.GoalID,
 	)
 	return i, err
 }
 
-const deleteAccount = `-- name: DeleteAccount :exec
+const deleteAccount = `-- name: DeleteAccount :execrows
 DELETE FROM accounts
-WHERE id = $1
+WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteAccount(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, id)
-	return err
+type DeleteAccountParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteAccount(ctx context.Context, arg DeleteAccountParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAccount, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, user_id, category_id, title, type, description, value, date, created_at FROM accounts
-WHERE id = $1 LIMIT 1
+SELECT id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id FROM accounts
+WHERE id = $1 AND user_id = $2 LIMIT 1
 `
 
-func (q *Queries) GetAccount(ctx context.Context, id int32) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccount, id)
+type GetAccountParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetAccount(ctx context.Context, arg GetAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccount, arg.ID, arg.UserID)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -85,9 +111,51 @@ func (q *Queries) GetAccount(ctx context.Context, id int32) (Account, error) {
 		&i.Title,
 		&i.Type,
 		&i.Description,
-		&i.Value,
 		&i.Date,
 		&i.CreatedAt,
+		&i.Amount,
+		&i.Currency,
+		&i.WalletID,
+		&i.RecurringID,
+		&i.OccurrenceDate,
+		&i.InstallmentPlanID,
+		&i.InstallmentNumber,
+		&i.GoalID,
+	)
+	return i, err
+}
+
+const getAccountForUpdate = `-- name: GetAccountForUpdate :one
+SELECT id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id FROM accounts
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE
+`
+
+type GetAccountForUpdateParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetAccountForUpdate(ctx context.Context, arg GetAccountForUpdateParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountForUpdate, arg.ID, arg.UserID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Date,
+		&i.CreatedAt,
+		&i.Amount,
+		&i.Currency,
+		&i.WalletID,
+		&i.RecurringID,
+		&i.OccurrenceDate,
+		&i.InstallmentPlanID,
+		&i.InstallmentNumber,
+		&i.GoalID,
 	)
 	return i, err
 }
@@ -96,10 +164,12 @@ const getAccounts = `-- name: GetAccounts :many
 SELECT
   a.id,
   a.user_id,
+  a.wallet_id,
   a.title,
   a.type,
   a.description,
-  a.value,
+  a.amount,
+  a.currency,
   a.date,
   a.created_at,
   c.title as category_title
@@ -118,7 +188,9 @@ AND
 AND
   a.category_id = COALESCE($5, a.category_id)
 AND
-  a.date = COALESCE($6, a.date)
+  a.wallet_id = COALESCE($6, a.wallet_id)
+AND
+  a.date = COALESCE($7, a.date)
 `
 
 type GetAccountsParams struct {
@@ -127,16 +199,19 @@ type GetAccountsParams struct {
 	Title       string        `json:"title"`
 	Description string        `json:"description"`
 	CategoryID  sql.NullInt32 `json:"category_id"`
+	WalletID    sql.NullInt32 `json:"wallet_id"`
 	Date        sql.NullTime  `json:"date"`
 }
 
 type GetAccountsRow struct {
 	ID            int32          `json:"id"`
 	UserID        int32          `json:"user_id"`
+	WalletID      int32          `json:"wallet_id"`
 	Title         string         `json:"title"`
 	Type          string         `json:"type"`
 	Description   string         `json:"description"`
-	Value         int32          `json:"value"`
+	Amount        int64          `json:"amount"`
+	Currency      string         `json:"currency"`
 	Date          time.Time      `json:"date"`
 	CreatedAt     time.Time      `json:"created_at"`
 	CategoryTitle sql.NullString `json:"category_title"`
@@ -149,6 +224,7 @@ func (q *Queries) GetAccounts(ctx context.Context, arg GetAccountsParams) ([]Get
 		arg.Title,
 		arg.Description,
 		arg.CategoryID,
+		arg.WalletID,
 		arg.Date,
 	)
 	if err != nil {
@@ -161,10 +237,12 @@ func (q *Queries) GetAccounts(ctx context.Context, arg GetAccountsParams) ([]Get
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
+			&i.WalletID,
 			&i.Title,
 			&i.Type,
 			&i.Description,
-			&i.Value,
+			&i.Amount,
+			&i.Currency,
 			&i.Date,
 			&i.CreatedAt,
 			&i.CategoryTitle,
@@ -199,9 +277,11 @@ func (q *Queries) GetAccountsGraph(ctx context.Context, arg GetAccountsGraphPara
 	return count, err
 }
 
-const getAccountsReports = `-- name: GetAccountsReports :one
-SELECT SUM(value) AS sum_value FROM accounts
+const getAccountsReports = `-- name: GetAccountsReports :many
+SELECT currency, date, SUM(amount)::bigint AS sum_amount, COUNT(*) AS count FROM accounts
 where user_id = $1 and type = $2
+GROUP BY currency, date
+ORDER BY date, currency
 `
 
 type GetAccountsReportsParams struct {
@@ -209,33 +289,189 @@ type GetAccountsReportsParams struct {
 	Type   string `json:"type"`
 }
 
-func (q *Queries) GetAccountsReports(ctx context.Context, arg GetAccountsReportsParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getAccountsReports, arg.UserID, arg.Type)
-	var sum_value int64
-	err := row.Scan(&sum_value)
-	return sum_value, err
+type GetAccountsReportsRow struct {
+	Currency  string    `json:"currency"`
+	Date      time.Time `json:"date"`
+	SumAmount int64     `json:"sum_amount"`
+	Count     int64     `json:"count"`
+}
+
+func (q *Queries) GetAccountsReports(ctx context.Context, arg GetAccountsReportsParams) ([]GetAccountsReportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAccountsReports, arg.UserID, arg.Type)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAccountsReportsRow{}
+	for rows.Next() {
+		var i GetAccountsReportsRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.Date,
+			&i.SumAmount,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCashFlow = `-- name: GetCashFlow :many
+SELECT
+  buckets.bucket,
+  accounts.category_id,
+  accounts.type,
+  accounts.currency,
+  accounts.date,
+  COALESCE(SUM(accounts.amount), 0)::bigint AS sum_amount
+FROM (
+  SELECT generate_series(
+    date_trunc($1::text, ($2::date)::timestamp),
+    ($3::date)::timestamp,
+    ('1 ' || $1::text)::interval
+  )::date AS bucket
+) AS buckets
+LEFT JOIN accounts
+  ON accounts.user_id = $4
+  AND accounts.date >= buckets.bucket
+  AND accounts.date < buckets.bucket + ('1 ' || $1::text)::interval
+  AND accounts.date >= $2::date
+  AND accounts.date <= $3::date
+GROUP BY buckets.bucket, accounts.category_id, accounts.type, accounts.currency, accounts.date
+ORDER BY buckets.bucket, accounts.date, accounts.category_id, accounts.type, accounts.currency
+`
+
+type GetCashFlowParams struct {
+	Granularity string    `json:"granularity"`
+	FromDate    time.Time `json:"from_date"`
+	ToDate      time.Time `json:"to_date"`
+	UserID      int32     `json:"user_id"`
+}
+
+type GetCashFlowRow struct {
+	Bucket     time.Time      `json:"bucket"`
+	CategoryID sql.NullInt32  `json:"category_id"`
+	Type       sql.NullString `json:"type"`
+	Currency   sql.NullString `json:"currency"`
+	Date       sql.NullTime   `json:"date"`
+	SumAmount  int64          `json:"sum_amount"`
+}
+
+func (q *Queries) GetCashFlow(ctx context.Context, arg GetCashFlowParams) ([]GetCashFlowRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCashFlow,
+		arg.Granularity,
+		arg.FromDate,
+		arg.ToDate,
+		arg.UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetCashFlowRow{}
+	for rows.Next() {
+		var i GetCashFlowRow
+		if err := rows.Scan(
+			&i.Bucket,
+			&i.CategoryID,
+			&i.Type,
+			&i.Currency,
+			&i.Date,
+			&i.SumAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserAccounts = `-- name: ListUserAccounts :many
+SELECT id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id FROM accounts
+WHERE user_id = $1
+ORDER BY date, id
+`
+
+func (q *Queries) ListUserAccounts(ctx context.Context, userID int32) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAccounts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Type,
+			&i.Description,
+			&i.Date,
+			&i.CreatedAt,
+			&i.Amount,
+			&i.Currency,
+			&i.WalletID,
+			&i.RecurringID,
+			&i.OccurrenceDate,
+			&i.InstallmentPlanID,
+			&i.InstallmentNumber,
+			&i.GoalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
-SET title = $2, description = $3, value = $4
-WHERE id = $1
-RETURNING id, user_id, category_id, title, type, description, value, date, created_at
+SET title = $3, description = $4, amount = $5, currency = $6, wallet_id = $7
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id
 `
 
 type UpdateAccountParams struct {
 	ID          int32  `json:"id"`
+	UserID      int32  `json:"user_id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
-	Value       int32  `json:"value"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	WalletID    int32  `json:"wallet_id"`
 }
 
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
 	row := q.db.QueryRowContext(ctx, updateAccount,
 		arg.ID,
+		arg.UserID,
 		arg.Title,
 		arg.Description,
-		arg.Value,
+		arg.Amount,
+		arg.Currency,
+		arg.WalletID,
 	)
 	var i Account
 	err := row.Scan(
@@ -245,9 +481,16 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Title,
 		&i.Type,
 		&i.Description,
-		&i.Value,
 		&i.Date,
 		&i.CreatedAt,
+		&i.Amount,
+		&i.Currency,
+		&i.WalletID,
+		&i.RecurringID,
+		&i.OccurrenceDate,
+		&i.InstallmentPlanID,
+		&i.InstallmentNumber,
+		&i.GoalID,
 	)
 	return i, err
 }
@@ -12,13 +12,16 @@ import (
 
 func createRandomAccount(t *testing.T) Account {
 	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.UserID)
 	arg := CreateAccountParams{
 		UserID:      category.UserID,
+		WalletID:    wallet.ID,
 		CategoryID:  category.ID,
 		Title:       util.RandomString(12),
 		Type:        category.Type,
 		Description: util.RandomString(20),
-		Value:       10,
+		Amount:      1234,
+		Currency:    "BRL",
 		Date:        time.Now(),
 	}
 
@@ -27,8 +30,10 @@ func createRandomAccount(t *testing.T) Account {
 	require.NotEmpty(t, account)
 
 	require.Equal(t, arg.UserID, account.UserID)
+	require.Equal(t, arg.WalletID, account.WalletID)
 	require.Equal(t, arg.CategoryID, account.CategoryID)
-	require.Equal(t, arg.Value, account.Value)
+	require.Equal(t, arg.Amount, account.Amount)
+	require.Equal(t, arg.Currency, account.Currency)
 	require.Equal(t, arg.Title, account.Title)
 	require.Equal(t, arg.Type, account.Type)
 	require.Equal(t, arg.Description, account.Description)
@@ -45,13 +50,17 @@ func TestCreateAccount(t *testing.T) {
 
 func TestGetAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+	account2, err := testQueries.GetAccount(context.Background(), GetAccountParams{
+		ID:     account1.ID,
+		UserID: account1.UserID,
+	})
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
 	require.Equal(t, account1.UserID, account2.UserID)
 	require.Equal(t, account1.CategoryID, account2.CategoryID)
-	require.Equal(t, account1.Value, account2.Value)
+	require.Equal(t, account1.Amount, account2.Amount)
+	require.Equal(t, account1.Currency, account2.Currency)
 	require.Equal(t, account1.Title, account2.Title)
 	require.Equal(t, account1.Type, account2.Type)
 	require.Equal(t, account1.Description, account2.Description)
@@ -60,10 +69,37 @@ func TestGetAccount(t *testing.T) {
 	require.NotEmpty(t, account2.CreatedAt)
 }
 
+func TestGetAccountOfAnotherUser(t *testing.T) {
+	account := createRandomAccount(t)
+	otherUser := createRandomUser(t)
+
+	_, err := testQueries.GetAccount(context.Background(), GetAccountParams{
+		ID:     account.ID,
+		UserID: otherUser.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func TestDeleteAccount(t *testing.T) {
 	account := createRandomAccount(t)
-	err := testQueries.DeleteAccount(context.Background(), account.ID)
+	rows, err := testQueries.DeleteAccount(context.Background(), DeleteAccountParams{
+		ID:     account.ID,
+		UserID: account.UserID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
+
+func TestDeleteAccountOfAnotherUser(t *testing.T) {
+	account := createRandomAccount(t)
+	otherUser := createRandomUser(t)
+
+	rows, err := testQueries.DeleteAccount(context.Background(), DeleteAccountParams{
+		ID:     account.ID,
+		UserID: otherUser.ID,
+	})
 	require.NoError(t, err)
+	require.Zero(t, rows)
 }
 
 func TestUpdateAccount(t *testing.T) {
@@ -71,9 +107,12 @@ func TestUpdateAccount(t *testing.T) {
 
 	arg := UpdateAccountParams{
 		ID:          account1.ID,
+		UserID:      account1.UserID,
 		Title:       util.RandomString(12),
 		Description: util.RandomString(20),
-		Value:       15,
+		Amount:      50000000000,
+		Currency:    "USD",
+		WalletID:    account1.WalletID,
 	}
 
 	account2, err := testQueries.UpdateAccount(context.Background(), arg)
@@ -83,7 +122,8 @@ func TestUpdateAccount(t *testing.T) {
 	require.Equal(t, account1.ID, account2.ID)
 	require.Equal(t, arg.Title, account2.Title)
 	require.Equal(t, arg.Description, account2.Description)
-	require.Equal(t, arg.Value, account2.Value)
+	require.Equal(t, arg.Amount, account2.Amount)
+	require.Equal(t, arg.Currency, account2.Currency)
 	require.Equal(t, account1.CreatedAt, account2.CreatedAt)
 }
 
@@ -114,7 +154,8 @@ func TestListAccounts(t *testing.T) {
 		require.Equal(t, lastAccount.UserID, account.UserID)
 		require.Equal(t, lastAccount.Title, account.Title)
 		require.Equal(t, lastAccount.Description, account.Description)
-		require.Equal(t, lastAccount.Value, account.Value)
+		require.Equal(t, lastAccount.Amount, account.Amount)
+		require.Equal(t, lastAccount.Currency, account.Currency)
 		require.NotEmpty(t, lastAccount.CreatedAt)
 		require.NotEmpty(t, lastAccount.Date)
 	}
@@ -130,7 +171,10 @@ func TestListGetReports(t *testing.T) {
 
 	sumValue, err := testQueries.GetAccountsReports(context.Background(), arg)
 	require.NoError(t, err)
-	require.NotEmpty(t, sumValue)
+	require.Len(t, sumValue, 1)
+	require.Equal(t, lastAccount.Currency, sumValue[0].Currency)
+	require.Equal(t, lastAccount.Amount, sumValue[0].SumAmount)
+	require.Equal(t, int64(1), sumValue[0].Count)
 }
 
 func TestListGetGraph(t *testing.T) {
@@ -145,3 +189,53 @@ func TestListGetGraph(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, graphValue)
 }
+
+func TestListUserAccounts(t *testing.T) {
+	account := createRandomAccount(t)
+
+	accounts, err := testQueries.ListUserAccounts(context.Background(), account.UserID)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+}
+
+func TestGetCashFlowFillsEmptyBuckets(t *testing.T) {
+	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.UserID)
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, date := range []time.Time{january.AddDate(0, 0, 4), january.AddDate(0, 0, 20), january.AddDate(0, 2, 9)} {
+		_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			UserID:      category.UserID,
+			WalletID:    wallet.ID,
+			CategoryID:  category.ID,
+			Title:       util.RandomString(12),
+			Type:        category.Type,
+			Description: util.RandomString(20),
+			Amount:      1000,
+			Currency:    "BRL",
+			Date:        date,
+		})
+		require.NoError(t, err)
+	}
+
+	rows, err := testQueries.GetCashFlow(context.Background(), GetCashFlowParams{
+		UserID:      category.UserID,
+		Granularity: "month",
+		FromDate:    january.AddDate(0, 0, 10),
+		ToDate:      january.AddDate(0, 3, 0).AddDate(0, 0, -1),
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	require.WithinDuration(t, january, rows[0].Bucket, 0)
+	require.True(t, rows[0].Date.Valid)
+	require.Equal(t, int64(1000), rows[0].SumAmount)
+
+	require.WithinDuration(t, january.AddDate(0, 1, 0), rows[1].Bucket, 0)
+	require.False(t, rows[1].Date.Valid)
+	require.Zero(t, rows[1].SumAmount)
+
+	require.WithinDuration(t, january.AddDate(0, 2, 0), rows[2].Bucket, 0)
+	require.Equal(t, category.ID, rows[2].CategoryID.Int32)
+	require.Equal(t, category.Type, rows[2].Type.String)
+}
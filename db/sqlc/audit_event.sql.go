@@ -0,0 +1,178 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: audit_event.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  user_id,
+  actor_user_id,
+  target_type,
+  target_id,
+  action,
+  before,
+  after,
+  client_ip,
+  user_agent
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, user_id, actor_user_id, target_type, target_id, action, before, after, client_ip, user_agent, created_at
+`
+
+type CreateAuditEventParams struct {
+	UserID      sql.NullInt32   `json:"user_id"`
+	ActorUserID sql.NullInt32   `json:"actor_user_id"`
+	TargetType  string          `json:"target_type"`
+	TargetID    sql.NullInt32   `json:"target_id"`
+	Action      string          `json:"action"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	ClientIp    string          `json:"client_ip"`
+	UserAgent   string          `json:"user_agent"`
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error) {
+	row := q.db.QueryRowContext(ctx, createAuditEvent,
+		arg.UserID,
+		arg.ActorUserID,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Action,
+		arg.Before,
+		arg.After,
+		arg.ClientIp,
+		arg.UserAgent,
+	)
+	var i AuditEvent
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ActorUserID,
+		&i.TargetType,
+		&i.TargetID,
+		&i.Action,
+		&i.Before,
+		&i.After,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, user_id, actor_user_id, target_type, target_id, action, before, after, client_ip, user_agent, created_at FROM audit_events
+WHERE
+  user_id = $1::int
+  AND ($2::varchar IS NULL OR target_type = $2)
+  AND ($3::int IS NULL OR target_id = $3)
+  AND ($4::varchar IS NULL OR action = $4)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+ORDER BY id DESC
+LIMIT $8 OFFSET $7
+`
+
+type ListAuditEventsParams struct {
+	UserID     int32          `json:"user_id"`
+	TargetType sql.NullString `json:"target_type"`
+	TargetID   sql.NullInt32  `json:"target_id"`
+	Action     sql.NullString `json:"action"`
+	FromTime   sql.NullTime   `json:"from_time"`
+	ToTime     sql.NullTime   `json:"to_time"`
+	PageOffset int32          `json:"page_offset"`
+	PageLimit  int32          `json:"page_limit"`
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.UserID,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Action,
+		arg.FromTime,
+		arg.ToTime,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditEvent{}
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ActorUserID,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Action,
+			&i.Before,
+			&i.After,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserAuditEvents = `-- name: ListUserAuditEvents :many
+SELECT id, user_id, actor_user_id, target_type, target_id, action, before, after, client_ip, user_agent, created_at FROM audit_events
+WHERE user_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListUserAuditEvents(ctx context.Context, userID sql.NullInt32) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAuditEvents, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditEvent{}
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ActorUserID,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Action,
+			&i.Before,
+			&i.After,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
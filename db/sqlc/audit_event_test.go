@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAuditEvent(t *testing.T, account Account, action string) AuditEvent {
+	after, err := json.Marshal(account)
+	require.NoError(t, err)
+
+	arg := CreateAuditEventParams{
+		UserID:      sql.NullInt32{Int32: account.UserID, Valid: true},
+		ActorUserID: sql.NullInt32{Int32: account.UserID, Valid: true},
+		TargetType:  "account",
+		TargetID:    sql.NullInt32{Int32: account.ID, Valid: true},
+		Action:      action,
+		Before:      json.RawMessage("null"),
+		After:       after,
+		ClientIp:    "127.0.0.1",
+		UserAgent:   "go-test",
+	}
+
+	event, err := testQueries.CreateAuditEvent(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.UserID, event.UserID)
+	require.Equal(t, arg.TargetID, event.TargetID)
+	require.Equal(t, arg.Action, event.Action)
+	require.JSONEq(t, string(arg.After), string(event.After))
+	require.NotZero(t, event.CreatedAt)
+
+	return event
+}
+
+func TestListAuditEvents(t *testing.T) {
+	account := createRandomAccount(t)
+	created := createRandomAuditEvent(t, account, "create")
+	deleted := createRandomAuditEvent(t, account, "delete")
+
+	events, err := testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		UserID:    account.UserID,
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, deleted.ID, events[0].ID)
+	require.Equal(t, created.ID, events[1].ID)
+
+	events, err = testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		UserID:     account.UserID,
+		TargetType: sql.NullString{String: "account", Valid: true},
+		TargetID:   sql.NullInt32{Int32: account.ID, Valid: true},
+		Action:     sql.NullString{String: "create", Valid: true},
+		FromTime:   sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+		PageLimit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, created.ID, events[0].ID)
+
+	events, err = testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		UserID:    createRandomUser(t).ID,
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestListUserAuditEvents(t *testing.T) {
+	account := createRandomAccount(t)
+	created := createRandomAuditEvent(t, account, "create")
+	deleted := createRandomAuditEvent(t, account, "delete")
+
+	events, err := testQueries.ListUserAuditEvents(context.Background(), sql.NullInt32{Int32: account.UserID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, created.ID, events[0].ID)
+	require.Equal(t, deleted.ID, events[1].ID)
+}
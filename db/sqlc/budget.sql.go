@@ -0,0 +1,298 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: budget.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const createBudget = `-- name: CreateBudget :one
+INSERT INTO budgets (
+  user_id,
+  category_id,
+  period,
+  amount,
+  currency,
+  rollover
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, user_id, category_id, period, amount, currency, rollover, created_at
+`
+
+type CreateBudgetParams struct {
+	UserID     int32     `json:"user_id"`
+	CategoryID int32     `json:"category_id"`
+	Period     time.Time `json:"period"`
+	Amount     int64     `json:"amount"`
+	Currency   string    `json:"currency"`
+	Rollover   bool      `json:"rollover"`
+}
+
+func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Budget, error) {
+	row := q.db.QueryRowContext(ctx, createBudget,
+		arg.UserID,
+		arg.CategoryID,
+		arg.Period,
+		arg.Amount,
+		arg.Currency,
+		arg.Rollover,
+	)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Period,
+		&i.Amount,
+		&i.Currency,
+		&i.Rollover,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteBudget = `-- name: DeleteBudget :execrows
+DELETE FROM budgets
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteBudgetParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteBudget(ctx context.Context, arg DeleteBudgetParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBudget, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getBudgetForUpdate = `-- name: GetBudgetForUpdate :one
+SELECT id, user_id, category_id, period, amount, currency, rollover, created_at FROM budgets
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE
+`
+
+type GetBudgetForUpdateParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetBudgetForUpdate(ctx context.Context, arg GetBudgetForUpdateParams) (Budget, error) {
+	row := q.db.QueryRowContext(ctx, getBudgetForUpdate, arg.ID, arg.UserID)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Period,
+		&i.Amount,
+		&i.Currency,
+		&i.Rollover,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getBudgetSpending = `-- name: GetBudgetSpending :many
+SELECT category_id, currency, date, SUM(amount)::bigint AS sum_amount FROM accounts
+WHERE user_id = $1 AND type = 'debit'
+AND date >= $2::date AND date < $3::date
+AND category_id = ANY($4::int[])
+GROUP BY category_id, currency, date
+ORDER BY date, category_id, currency
+`
+
+type GetBudgetSpendingParams struct {
+	UserID      int32     `json:"user_id"`
+	FromDate    time.Time `json:"from_date"`
+	ToDate      time.Time `json:"to_date"`
+	CategoryIds []int32   `json:"category_ids"`
+}
+
+type GetBudgetSpendingRow struct {
+	CategoryID int32     `json:"category_id"`
+	Currency   string    `json:"currency"`
+	Date       time.Time `json:"date"`
+	SumAmount  int64     `json:"sum_amount"`
+}
+
+func (q *Queries) GetBudgetSpending(ctx context.Context, arg GetBudgetSpendingParams) ([]GetBudgetSpendingRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBudgetSpending,
+		arg.UserID,
+		arg.FromDate,
+		arg.ToDate,
+		pq.Array(arg.CategoryIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetBudgetSpendingRow{}
+	for rows.Next() {
+		var i GetBudgetSpendingRow
+		if err := rows.Scan(
+			&i.CategoryID,
+			&i.Currency,
+			&i.Date,
+			&i.SumAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBudgets = `-- name: ListBudgets :many
+SELECT id, user_id, category_id, period, amount, currency, rollover, created_at FROM budgets
+WHERE user_id = $1
+AND period = COALESCE($2, period)
+ORDER BY period DESC, category_id
+`
+
+type ListBudgetsParams struct {
+	UserID int32        `json:"user_id"`
+	Period sql.NullTime `json:"period"`
+}
+
+func (q *Queries) ListBudgets(ctx context.Context, arg ListBudgetsParams) ([]Budget, error) {
+	rows, err := q.db.QueryContext(ctx, listBudgets, arg.UserID, arg.Period)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Budget{}
+	for rows.Next() {
+		var i Budget
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CategoryID,
+			&i.Period,
+			&i.Amount,
+			&i.Currency,
+			&i.Rollover,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBudgetsUntil = `-- name: ListBudgetsUntil :many
+SELECT b.id, b.user_id, b.category_id, b.period, b.amount, b.currency, b.rollover, b.created_at, c.title AS category_title FROM budgets b
+JOIN categories c ON c.id = b.category_id
+WHERE b.user_id = $1 AND b.period <= $2::date
+AND ($3::int IS NULL OR b.category_id = $3)
+ORDER BY b.category_id, b.period
+`
+
+type ListBudgetsUntilParams struct {
+	UserID     int32         `json:"user_id"`
+	Period     time.Time     `json:"period"`
+	CategoryID sql.NullInt32 `json:"category_id"`
+}
+
+type ListBudgetsUntilRow struct {
+	ID            int32     `json:"id"`
+	UserID        int32     `json:"user_id"`
+	CategoryID    int32     `json:"category_id"`
+	Period        time.Time `json:"period"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	Rollover      bool      `json:"rollover"`
+	CreatedAt     time.Time `json:"created_at"`
+	CategoryTitle string    `json:"category_title"`
+}
+
+func (q *Queries) ListBudgetsUntil(ctx context.Context, arg ListBudgetsUntilParams) ([]ListBudgetsUntilRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBudgetsUntil, arg.UserID, arg.Period, arg.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBudgetsUntilRow{}
+	for rows.Next() {
+		var i ListBudgetsUntilRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CategoryID,
+			&i.Period,
+			&i.Amount,
+			&i.Currency,
+			&i.Rollover,
+			&i.CreatedAt,
+			&i.CategoryTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateBudget = `-- name: UpdateBudget :one
+UPDATE budgets
+SET amount = $3, rollover = $4
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, category_id, period, amount, currency, rollover, created_at
+`
+
+type UpdateBudgetParams struct {
+	ID       int32 `json:"id"`
+	UserID   int32 `json:"user_id"`
+	Amount   int64 `json:"amount"`
+	Rollover bool  `json:"rollover"`
+}
+
+func (q *Queries) UpdateBudget(ctx context.Context, arg UpdateBudgetParams) (Budget, error) {
+	row := q.db.QueryRowContext(ctx, updateBudget,
+		arg.ID,
+		arg.UserID,
+		arg.Amount,
+		arg.Rollover,
+	)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Period,
+		&i.Amount,
+		&i.Currency,
+		&i.Rollover,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomBudget(t *testing.T, category Category, period time.Time) Budget {
+	arg := CreateBudgetParams{
+		UserID:     category.UserID,
+		CategoryID: category.ID,
+		Period:     period,
+		Amount:     50000,
+		Currency:   "BRL",
+		Rollover:   true,
+	}
+
+	budget, err := testQueries.CreateBudget(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, budget)
+
+	require.Equal(t, arg.UserID, budget.UserID)
+	require.Equal(t, arg.CategoryID, budget.CategoryID)
+	require.Equal(t, arg.Amount, budget.Amount)
+	require.Equal(t, arg.Rollover, budget.Rollover)
+	require.NotEmpty(t, budget.CreatedAt)
+
+	return budget
+}
+
+func TestCreateBudgetOncePerPeriod(t *testing.T) {
+	category := createRandomCategory(t)
+	period := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	createRandomBudget(t, category, period)
+
+	_, err := testQueries.CreateBudget(context.Background(), CreateBudgetParams{
+		UserID:     category.UserID,
+		CategoryID: category.ID,
+		Period:     period,
+		Amount:     100,
+		Currency:   "BRL",
+	})
+	require.Error(t, err)
+}
+
+func TestCreateBudgetPeriodMustStartTheMonth(t *testing.T) {
+	category := createRandomCategory(t)
+
+	_, err := testQueries.CreateBudget(context.Background(), CreateBudgetParams{
+		UserID:     category.UserID,
+		CategoryID: category.ID,
+		Period:     time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		Amount:     100,
+		Currency:   "BRL",
+	})
+	require.Error(t, err)
+}
+
+func TestListBudgetsUntil(t *testing.T) {
+	category := createRandomCategory(t)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	createRandomBudget(t, category, march.AddDate(0, -1, 0))
+	createRandomBudget(t, category, march)
+	createRandomBudget(t, category, march.AddDate(0, 1, 0))
+
+	budgets, err := testQueries.ListBudgetsUntil(context.Background(), ListBudgetsUntilParams{
+		UserID:     category.UserID,
+		Period:     march,
+		CategoryID: sql.NullInt32{Int32: category.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, budgets, 2)
+	require.Equal(t, category.Title, budgets[1].CategoryTitle)
+	require.WithinDuration(t, march, budgets[1].Period, 0)
+
+	budgetsOfMarch, err := testQueries.ListBudgets(context.Background(), ListBudgetsParams{
+		UserID: category.UserID,
+		Period: sql.NullTime{Time: march, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, budgetsOfMarch, 1)
+}
+
+func TestGetBudgetSpending(t *testing.T) {
+	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.UserID)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, date := range []time.Time{march, march.AddDate(0, 0, 10), march.AddDate(0, 1, 0)} {
+		_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			UserID:      category.UserID,
+			WalletID:    wallet.ID,
+			CategoryID:  category.ID,
+			Title:       "groceries",
+			Type:        "debit",
+			Description: "groceries",
+			Amount:      1000,
+			Currency:    "BRL",
+			Date:        date,
+		})
+		require.NoError(t, err)
+	}
+
+	rows, err := testQueries.GetBudgetSpending(context.Background(), GetBudgetSpendingParams{
+		UserID:      category.UserID,
+		FromDate:    march,
+		ToDate:      march.AddDate(0, 1, 0),
+		CategoryIds: []int32{category.ID},
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	for _, row := range rows {
+		require.Equal(t, int64(1000), row.SumAmount)
+	}
+}
+
+func TestUpdateBudget(t *testing.T) {
+	category := createRandomCategory(t)
+	budget1 := createRandomBudget(t, category, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	budget2, err := testQueries.UpdateBudget(context.Background(), UpdateBudgetParams{
+		ID:       budget1.ID,
+		UserID:   budget1.UserID,
+		Amount:   75000,
+		Rollover: false,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(75000), budget2.Amount)
+	require.False(t, budget2.Rollover)
+
+	deleted, err := testQueries.DeleteBudget(context.Background(), DeleteBudgetParams{
+		ID:     budget1.ID,
+		UserID: budget1.UserID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+}
@@ -46,14 +46,22 @@ func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams)
 	return i, err
 }
 
-const deleteCategories = `-- name: DeleteCategories :exec
+const deleteCategories = `-- name: DeleteCategories :execrows
 DELETE FROM categories
-WHERE id = $1
+WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteCategories(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, deleteCategories, id)
-	return err
+type DeleteCategoriesParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteCategories(ctx context.Context, arg DeleteCategoriesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCategories, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getCategories = `-- name: GetCategories :many
@@ -112,11 +120,41 @@ func (q *Queries) GetCategories(ctx context.Context, arg GetCategoriesParams) ([
 
 const getCategory = `-- name: GetCategory :one
 SELECT id, user_id, title, type, description, created_at FROM categories
-WHERE id = $1 LIMIT 1
+WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type GetCategoryParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetCategory(ctx context.Context, arg GetCategoryParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategory, arg.ID, arg.UserID)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCategoryForUpdate = `-- name: GetCategoryForUpdate :one
+SELECT id, user_id, title, type, description, created_at FROM categories
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE
 `
 
-func (q *Queries) GetCategory(ctx context.Context, id int32) (Category, error) {
-	row := q.db.QueryRowContext(ctx, getCategory, id)
+type GetCategoryForUpdateParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetCategoryForUpdate(ctx context.Context, arg GetCategoryForUpdateParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategoryForUpdate, arg.ID, arg.UserID)
 	var i Category
 	err := row.Scan(
 		&i.ID,
@@ -129,21 +167,63 @@ func (q *Queries) GetCategory(ctx context.Context, id int32) (Category, error) {
 	return i, err
 }
 
+const listUserCategories = `-- name: ListUserCategories :many
+SELECT id, user_id, title, type, description, created_at FROM categories
+WHERE user_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListUserCategories(ctx context.Context, userID int32) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, listUserCategories, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Category{}
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Type,
+			&i.Description,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateCategories = `-- name: UpdateCategories :one
 UPDATE categories
-SET title = $2, description = $3
-WHERE id = $1
+SET title = $3, description = $4
+WHERE id = $1 AND user_id = $2
 RETURNING id, user_id, title, type, description, created_at
 `
 
 type UpdateCategoriesParams struct {
 	ID          int32  `json:"id"`
+	UserID      int32  `json:"user_id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
 }
 
 func (q *Queries) UpdateCategories(ctx context.Context, arg UpdateCategoriesParams) (Category, error) {
-	row := q.db.QueryRowContext(ctx, updateCategories, arg.ID, arg.Title, arg.Description)
+	row := q.db.QueryRowContext(ctx, updateCategories,
+		arg.ID,
+		arg.UserID,
+		arg.Title,
+		arg.Description,
+	)
 	var i Category
 	err := row.Scan(
 		&i.ID,
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/GustavoNoronha0/gofinance-backend/util"
@@ -36,7 +37,10 @@ func TestCreateCategory(t *testing.T) {
 
 func TestGetCategory(t *testing.T) {
 	category1 := createRandomCategory(t)
-	category2, err := testQueries.GetCategory(context.Background(), category1.ID)
+	category2, err := testQueries.GetCategory(context.Background(), GetCategoryParams{
+		ID:     category1.ID,
+		UserID: category1.UserID,
+	})
 	require.NoError(t, err)
 	require.NotEmpty(t, category2)
 
@@ -49,8 +53,25 @@ func TestGetCategory(t *testing.T) {
 
 func TestDeleteCategory(t *testing.T) {
 	category := createRandomCategory(t)
-	err := testQueries.DeleteCategories(context.Background(), category.ID)
+	rows, err := testQueries.DeleteCategories(context.Background(), DeleteCategoriesParams{
+		ID:     category.ID,
+		UserID: category.UserID,
+	})
 	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
+
+func TestUpdateCategoryOfAnotherUser(t *testing.T) {
+	category := createRandomCategory(t)
+	otherUser := createRandomUser(t)
+
+	_, err := testQueries.UpdateCategories(context.Background(), UpdateCategoriesParams{
+		ID:          category.ID,
+		UserID:      otherUser.ID,
+		Title:       util.RandomString(12),
+		Description: util.RandomString(20),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
 }
 
 func TestUpdateCategory(t *testing.T) {
@@ -58,6 +79,7 @@ func TestUpdateCategory(t *testing.T) {
 
 	arg := UpdateCategoriesParams{
 		ID:          category1.ID,
+		UserID:      category1.UserID,
 		Title:       util.RandomString(12),
 		Description: util.RandomString(20),
 	}
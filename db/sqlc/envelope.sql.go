@@ -0,0 +1,430 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: envelope.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createEnvelopeClose = `-- name: CreateEnvelopeClose :one
+INSERT INTO envelope_closes (
+  user_id,
+  period
+) VALUES (
+  $1, $2
+) RETURNING user_id, period, closed_at
+`
+
+type CreateEnvelopeCloseParams struct {
+	UserID int32     `json:"user_id"`
+	Period time.Time `json:"period"`
+}
+
+func (q *Queries) CreateEnvelopeClose(ctx context.Context, arg CreateEnvelopeCloseParams) (EnvelopeClose, error) {
+	row := q.db.QueryRowContext(ctx, createEnvelopeClose, arg.UserID, arg.Period)
+	var i EnvelopeClose
+	err := row.Scan(&i.UserID, &i.Period, &i.ClosedAt)
+	return i, err
+}
+
+const createEnvelopeCloseBalance = `-- name: CreateEnvelopeCloseBalance :one
+INSERT INTO envelope_close_balances (
+  user_id,
+  period,
+  category_id,
+  balance,
+  currency
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING id, user_id, period, category_id, balance, currency
+`
+
+type CreateEnvelopeCloseBalanceParams struct {
+	UserID     int32         `json:"user_id"`
+	Period     time.Time     `json:"period"`
+	CategoryID sql.NullInt32 `json:"category_id"`
+	Balance    int64         `json:"balance"`
+	Currency   string        `json:"currency"`
+}
+
+func (q *Queries) CreateEnvelopeCloseBalance(ctx context.Context, arg CreateEnvelopeCloseBalanceParams) (EnvelopeCloseBalance, error) {
+	row := q.db.QueryRowContext(ctx, createEnvelopeCloseBalance,
+		arg.UserID,
+		arg.Period,
+		arg.CategoryID,
+		arg.Balance,
+		arg.Currency,
+	)
+	var i EnvelopeCloseBalance
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Period,
+		&i.CategoryID,
+		&i.Balance,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const getEnvelopeActivity = `-- name: GetEnvelopeActivity :many
+SELECT category_id, type, currency, date, SUM(amount)::bigint AS sum_amount FROM accounts
+WHERE user_id = $1
+AND date >= $2::date AND date < $3::date
+GROUP BY category_id, type, currency, date
+ORDER BY date, category_id, type, currency
+`
+
+type GetEnvelopeActivityParams struct {
+	UserID   int32     `json:"user_id"`
+	FromDate time.Time `json:"from_date"`
+	ToDate   time.Time `json:"to_date"`
+}
+
+type GetEnvelopeActivityRow struct {
+	CategoryID int32     `json:"category_id"`
+	Type       string    `json:"type"`
+	Currency   string    `json:"currency"`
+	Date       time.Time `json:"date"`
+	SumAmount  int64     `json:"sum_amount"`
+}
+
+func (q *Queries) GetEnvelopeActivity(ctx context.Context, arg GetEnvelopeActivityParams) ([]GetEnvelopeActivityRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEnvelopeActivity, arg.UserID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetEnvelopeActivityRow{}
+	for rows.Next() {
+		var i GetEnvelopeActivityRow
+		if err := rows.Scan(
+			&i.CategoryID,
+			&i.Type,
+			&i.Currency,
+			&i.Date,
+			&i.SumAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEnvelopeAssignmentForUpdate = `-- name: GetEnvelopeAssignmentForUpdate :one
+SELECT id, user_id, category_id, period, amount, currency, created_at FROM envelope_assignments
+WHERE user_id = $1 AND category_id = $2 AND period = $3 LIMIT 1
+FOR UPDATE
+`
+
+type GetEnvelopeAssignmentForUpdateParams struct {
+	UserID     int32     `json:"user_id"`
+	CategoryID int32     `json:"category_id"`
+	Period     time.Time `json:"period"`
+}
+
+func (q *Queries) GetEnvelopeAssignmentForUpdate(ctx context.Context, arg GetEnvelopeAssignmentForUpdateParams) (EnvelopeAssignment, error) {
+	row := q.db.QueryRowContext(ctx, getEnvelopeAssignmentForUpdate, arg.UserID, arg.CategoryID, arg.Period)
+	var i EnvelopeAssignment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Period,
+		&i.Amount,
+		&i.Currency,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getEnvelopeCloseBefore = `-- name: GetEnvelopeCloseBefore :one
+SELECT user_id, period, closed_at FROM envelope_closes
+WHERE user_id = $1 AND period < $2::date
+ORDER BY period DESC
+LIMIT 1
+`
+
+type GetEnvelopeCloseBeforeParams struct {
+	UserID int32     `json:"user_id"`
+	Period time.Time `json:"period"`
+}
+
+func (q *Queries) GetEnvelopeCloseBefore(ctx context.Context, arg GetEnvelopeCloseBeforeParams) (EnvelopeClose, error) {
+	row := q.db.QueryRowContext(ctx, getEnvelopeCloseBefore, arg.UserID, arg.Period)
+	var i EnvelopeClose
+	err := row.Scan(&i.UserID, &i.Period, &i.ClosedAt)
+	return i, err
+}
+
+const getEnvelopeClosedUntil = `-- name: GetEnvelopeClosedUntil :one
+SELECT c.period FROM envelope_closes c
+JOIN users u ON u.id = c.user_id
+WHERE c.user_id = $1 AND u.envelope_start IS NOT NULL
+ORDER BY c.period DESC
+LIMIT 1
+`
+
+func (q *Queries) GetEnvelopeClosedUntil(ctx context.Context, userID int32) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getEnvelopeClosedUntil, userID)
+	var period time.Time
+	err := row.Scan(&period)
+	return period, err
+}
+
+const getLastEnvelopeClose = `-- name: GetLastEnvelopeClose :one
+SELECT user_id, period, closed_at FROM envelope_closes
+WHERE user_id = $1
+ORDER BY period DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastEnvelopeClose(ctx context.Context, userID int32) (EnvelopeClose, error) {
+	row := q.db.QueryRowContext(ctx, getLastEnvelopeClose, userID)
+	var i EnvelopeClose
+	err := row.Scan(&i.UserID, &i.Period, &i.ClosedAt)
+	return i, err
+}
+
+const listEnvelopeAssignments = `-- name: ListEnvelopeAssignments :many
+SELECT id, user_id, category_id, period, amount, currency, created_at FROM envelope_assignments
+WHERE user_id = $1
+AND period >= $2::date AND period <= $3::date
+ORDER BY period, category_id
+`
+
+type ListEnvelopeAssignmentsParams struct {
+	UserID     int32     `json:"user_id"`
+	FromPeriod time.Time `json:"from_period"`
+	ToPeriod   time.Time `json:"to_period"`
+}
+
+func (q *Queries) ListEnvelopeAssignments(ctx context.Context, arg ListEnvelopeAssignmentsParams) ([]EnvelopeAssignment, error) {
+	rows, err := q.db.QueryContext(ctx, listEnvelopeAssignments, arg.UserID, arg.FromPeriod, arg.ToPeriod)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EnvelopeAssignment{}
+	for rows.Next() {
+		var i EnvelopeAssignment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CategoryID,
+			&i.Period,
+			&i.Amount,
+			&i.Currency,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEnvelopeCloseBalances = `-- name: ListEnvelopeCloseBalances :many
+SELECT id, user_id, period, category_id, balance, currency FROM envelope_close_balances
+WHERE user_id = $1 AND period = $2
+ORDER BY category_id NULLS FIRST
+`
+
+type ListEnvelopeCloseBalancesParams struct {
+	UserID int32     `json:"user_id"`
+	Period time.Time `json:"period"`
+}
+
+func (q *Queries) ListEnvelopeCloseBalances(ctx context.Context, arg ListEnvelopeCloseBalancesParams) ([]EnvelopeCloseBalance, error) {
+	rows, err := q.db.QueryContext(ctx, listEnvelopeCloseBalances, arg.UserID, arg.Period)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EnvelopeCloseBalance{}
+	for rows.Next() {
+		var i EnvelopeCloseBalance
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Period,
+			&i.CategoryID,
+			&i.Balance,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserEnvelopeAssignments = `-- name: ListUserEnvelopeAssignments :many
+SELECT id, user_id, category_id, period, amount, currency, created_at FROM envelope_assignments
+WHERE user_id = $1
+ORDER BY period, category_id
+`
+
+func (q *Queries) ListUserEnvelopeAssignments(ctx context.Context, userID int32) ([]EnvelopeAssignment, error) {
+	rows, err := q.db.QueryContext(ctx, listUserEnvelopeAssignments, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EnvelopeAssignment{}
+	for rows.Next() {
+		var i EnvelopeAssignment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CategoryID,
+			&i.Period,
+			&i.Amount,
+			&i.Currency,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserEnvelopeCloseBalances = `-- name: ListUserEnvelopeCloseBalances :many
+SELECT id, user_id, period, category_id, balance, currency FROM envelope_close_balances
+WHERE user_id = $1
+ORDER BY period, category_id NULLS FIRST
+`
+
+func (q *Queries) ListUserEnvelopeCloseBalances(ctx context.Context, userID int32) ([]EnvelopeCloseBalance, error) {
+	rows, err := q.db.QueryContext(ctx, listUserEnvelopeCloseBalances, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EnvelopeCloseBalance{}
+	for rows.Next() {
+		var i EnvelopeCloseBalance
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Period,
+			&i.CategoryID,
+			&i.Balance,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserEnvelopeCloses = `-- name: ListUserEnvelopeCloses :many
+SELECT user_id, period, closed_at FROM envelope_closes
+WHERE user_id = $1
+ORDER BY period
+`
+
+func (q *Queries) ListUserEnvelopeCloses(ctx context.Context, userID int32) ([]EnvelopeClose, error) {
+	rows, err := q.db.QueryContext(ctx, listUserEnvelopeCloses, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EnvelopeClose{}
+	for rows.Next() {
+		var i EnvelopeClose
+		if err := rows.Scan(&i.UserID, &i.Period, &i.ClosedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setEnvelopeAssignment = `-- name: SetEnvelopeAssignment :one
+INSERT INTO envelope_assignments (
+  user_id,
+  category_id,
+  period,
+  amount,
+  currency
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, category_id, period)
+DO UPDATE SET amount = EXCLUDED.amount, currency = EXCLUDED.currency
+RETURNING id, user_id, category_id, period, amount, currency, created_at
+`
+
+type SetEnvelopeAssignmentParams struct {
+	UserID     int32     `json:"user_id"`
+	CategoryID int32     `json:"category_id"`
+	Period     time.Time `json:"period"`
+	Amount     int64     `json:"amount"`
+	Currency   string    `json:"currency"`
+}
+
+func (q *Queries) SetEnvelopeAssignment(ctx context.Context, arg SetEnvelopeAssignmentParams) (EnvelopeAssignment, error) {
+	row := q.db.QueryRowContext(ctx, setEnvelopeAssignment,
+		arg.UserID,
+		arg.CategoryID,
+		arg.Period,
+		arg.Amount,
+		arg.Currency,
+	)
+	var i EnvelopeAssignment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Period,
+		&i.Amount,
+		&i.Currency,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetUserEnvelopeStart(t *testing.T) {
+	user1 := createRandomUser(t)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	user2, err := testQueries.SetUserEnvelopeStart(context.Background(), SetUserEnvelopeStartParams{
+		ID:            user1.ID,
+		EnvelopeStart: sql.NullTime{Time: march, Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, user2.EnvelopeStart.Valid)
+	require.WithinDuration(t, march, user2.EnvelopeStart.Time, 0)
+
+	_, err = testQueries.SetUserEnvelopeStart(context.Background(), SetUserEnvelopeStartParams{
+		ID:            user1.ID,
+		EnvelopeStart: sql.NullTime{Time: march.AddDate(0, 0, 3), Valid: true},
+	})
+	require.Error(t, err)
+
+	user3, err := testQueries.SetUserEnvelopeStart(context.Background(), SetUserEnvelopeStartParams{ID: user1.ID})
+	require.NoError(t, err)
+	require.False(t, user3.EnvelopeStart.Valid)
+}
+
+func TestSetEnvelopeAssignment(t *testing.T) {
+	category := createRandomCategory(t)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	arg := SetEnvelopeAssignmentParams{
+		UserID:     category.UserID,
+		CategoryID: category.ID,
+		Period:     march,
+		Amount:     30000,
+		Currency:   "BRL",
+	}
+
+	assignment1, err := testQueries.SetEnvelopeAssignment(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Amount, assignment1.Amount)
+
+	arg.Amount = -5000
+	assignment2, err := testQueries.SetEnvelopeAssignment(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, assignment1.ID, assignment2.ID)
+	require.Equal(t, int64(-5000), assignment2.Amount)
+
+	assignment3, err := testQueries.GetEnvelopeAssignmentForUpdate(context.Background(), GetEnvelopeAssignmentForUpdateParams{
+		UserID:     category.UserID,
+		CategoryID: category.ID,
+		Period:     march,
+	})
+	require.NoError(t, err)
+	require.Equal(t, assignment2, assignment3)
+
+	for _, period := range []time.Time{march.AddDate(0, -1, 0), march.AddDate(0, 1, 0)} {
+		arg.Period = period
+		_, err = testQueries.SetEnvelopeAssignment(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	assignments, err := testQueries.ListEnvelopeAssignments(context.Background(), ListEnvelopeAssignmentsParams{
+		UserID:     category.UserID,
+		FromPeriod: march,
+		ToPeriod:   march.AddDate(0, 1, 0),
+	})
+	require.NoError(t, err)
+	require.Len(t, assignments, 2)
+	require.WithinDuration(t, march, assignments[0].Period, 0)
+}
+
+func TestGetEnvelopeActivity(t *testing.T) {
+	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.UserID)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, date := range []time.Time{march.AddDate(0, 0, -1), march, march, march.AddDate(0, 1, 0)} {
+		_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			UserID:      category.UserID,
+			WalletID:    wallet.ID,
+			CategoryID:  category.ID,
+			Title:       "groceries",
+			Type:        category.Type,
+			Description: "groceries",
+			Amount:      1000,
+			Currency:    "BRL",
+			Date:        date,
+		})
+		require.NoError(t, err)
+	}
+
+	rows, err := testQueries.GetEnvelopeActivity(context.Background(), GetEnvelopeActivityParams{
+		UserID:   category.UserID,
+		FromDate: march,
+		ToDate:   march.AddDate(0, 1, 0),
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, category.Type, rows[0].Type)
+	require.Equal(t, int64(2000), rows[0].SumAmount)
+}
+
+func TestEnvelopeClose(t *testing.T) {
+	user := createRandomUser(t)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := testQueries.GetLastEnvelopeClose(context.Background(), user.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, period := range []time.Time{march, march.AddDate(0, 1, 0)} {
+		closed, err := testQueries.CreateEnvelopeClose(context.Background(), CreateEnvelopeCloseParams{
+			UserID: user.ID,
+			Period: period,
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, closed.ClosedAt)
+	}
+
+	_, err = testQueries.CreateEnvelopeClose(context.Background(), CreateEnvelopeCloseParams{
+		UserID: user.ID,
+		Period: march,
+	})
+	require.Error(t, err)
+
+	last, err := testQueries.GetLastEnvelopeClose(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, march.AddDate(0, 1, 0), last.Period, 0)
+}
+
+func TestEnvelopeCloseBalances(t *testing.T) {
+	category := createRandomCategory(t)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := testQueries.GetEnvelopeClosedUntil(context.Background(), category.UserID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.CreateEnvelopeClose(context.Background(), CreateEnvelopeCloseParams{
+		UserID: category.UserID,
+		Period: march,
+	})
+	require.NoError(t, err)
+
+	// Closes only count while envelope budgeting is on.
+	_, err = testQueries.GetEnvelopeClosedUntil(context.Background(), category.UserID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.SetUserEnvelopeStart(context.Background(), SetUserEnvelopeStartParams{
+		ID:            category.UserID,
+		EnvelopeStart: sql.NullTime{Time: march, Valid: true},
+	})
+	require.NoError(t, err)
+	closedUntil, err := testQueries.GetEnvelopeClosedUntil(context.Background(), category.UserID)
+	require.NoError(t, err)
+	require.WithinDuration(t, march, closedUntil, 0)
+
+	for _, categoryID := range []sql.NullInt32{{}, {Int32: category.ID, Valid: true}} {
+		_, err = testQueries.CreateEnvelopeCloseBalance(context.Background(), CreateEnvelopeCloseBalanceParams{
+			UserID:     category.UserID,
+			Period:     march,
+			CategoryID: categoryID,
+			Balance:    2500,
+			Currency:   "BRL",
+		})
+		require.NoError(t, err)
+	}
+	_, err = testQueries.CreateEnvelopeCloseBalance(context.Background(), CreateEnvelopeCloseBalanceParams{
+		UserID:   category.UserID,
+		Period:   march,
+		Balance:  100,
+		Currency: "BRL",
+	})
+	require.Error(t, err)
+
+	previous, err := testQueries.GetEnvelopeCloseBefore(context.Background(), GetEnvelopeCloseBeforeParams{
+		UserID: category.UserID,
+		Period: march.AddDate(0, 2, 0),
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, march, previous.Period, 0)
+
+	_, err = testQueries.GetEnvelopeCloseBefore(context.Background(), GetEnvelopeCloseBeforeParams{
+		UserID: category.UserID,
+		Period: march,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	balances, err := testQueries.ListEnvelopeCloseBalances(context.Background(), ListEnvelopeCloseBalancesParams{
+		UserID: category.UserID,
+		Period: march,
+	})
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	require.False(t, balances[0].CategoryID.Valid)
+	require.Equal(t, category.ID, balances[1].CategoryID.Int32)
+}
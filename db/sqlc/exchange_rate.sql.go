@@ -0,0 +1,85 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: exchange_rate.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const getExchangeRate = `-- name: GetExchangeRate :one
+SELECT base_currency, quote_currency, date, rate, source, created_at FROM exchange_rates
+WHERE
+  ((base_currency = $1 AND quote_currency = $2)
+  OR (base_currency = $2 AND quote_currency = $1))
+AND
+  date <= $3
+ORDER BY date DESC
+LIMIT 1
+`
+
+type GetExchangeRateParams struct {
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Date         time.Time `json:"date"`
+}
+
+func (q *Queries) GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, getExchangeRate, arg.FromCurrency, arg.ToCurrency, arg.Date)
+	var i ExchangeRate
+	err := row.Scan(
+		&i.BaseCurrency,
+		&i.QuoteCurrency,
+		&i.Date,
+		&i.Rate,
+		&i.Source,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertExchangeRate = `-- name: UpsertExchangeRate :one
+INSERT INTO exchange_rates (
+  base_currency,
+  quote_currency,
+  date,
+  rate,
+  source
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (base_currency, quote_currency, date)
+DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source
+RETURNING base_currency, quote_currency, date, rate, source, created_at
+`
+
+type UpsertExchangeRateParams struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Date          time.Time `json:"date"`
+	Rate          string    `json:"rate"`
+	Source        string    `json:"source"`
+}
+
+func (q *Queries) UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, upsertExchangeRate,
+		arg.BaseCurrency,
+		arg.QuoteCurrency,
+		arg.Date,
+		arg.Rate,
+		arg.Source,
+	)
+	var i ExchangeRate
+	err := row.Scan(
+		&i.BaseCurrency,
+		&i.QuoteCurrency,
+		&i.Date,
+		&i.Rate,
+		&i.Source,
+		&i.CreatedAt,
+	)
+	return i, err
+}
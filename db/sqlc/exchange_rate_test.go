@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertExchangeRate(t *testing.T) {
+	arg := UpsertExchangeRateParams{
+		BaseCurrency:  "CHF",
+		QuoteCurrency: "NOK",
+		Date:          time.Date(2001, 3, 5, 0, 0, 0, 0, time.UTC),
+		Rate:          "5.1",
+		Source:        "csv",
+	}
+
+	rate, err := testQueries.UpsertExchangeRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.BaseCurrency, rate.BaseCurrency)
+	require.Equal(t, arg.QuoteCurrency, rate.QuoteCurrency)
+	require.Equal(t, "5.1", rate.Rate)
+
+	arg.Rate = "5.25"
+	arg.Source = "ecb"
+	rate, err = testQueries.UpsertExchangeRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, "5.25", rate.Rate)
+	require.Equal(t, "ecb", rate.Source)
+}
+
+func TestGetExchangeRate(t *testing.T) {
+	for _, arg := range []UpsertExchangeRateParams{
+		{BaseCurrency: "CHF", QuoteCurrency: "SEK", Date: time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC), Rate: "6", Source: "csv"},
+		{BaseCurrency: "SEK", QuoteCurrency: "CHF", Date: time.Date(2001, 3, 2, 0, 0, 0, 0, time.UTC), Rate: "0.16", Source: "csv"},
+	} {
+		_, err := testQueries.UpsertExchangeRate(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	// Saturday falls back to Friday's rate, stored the other way round.
+	rate, err := testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: "CHF",
+		ToCurrency:   "SEK",
+		Date:         time.Date(2001, 3, 3, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "SEK", rate.BaseCurrency)
+	require.Equal(t, "0.16", rate.Rate)
+
+	rate, err = testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: "CHF",
+		ToCurrency:   "SEK",
+		Date:         time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "CHF", rate.BaseCurrency)
+	require.Equal(t, "6", rate.Rate)
+
+	_, err = testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: "CHF",
+		ToCurrency:   "SEK",
+		Date:         time.Date(2001, 2, 28, 0, 0, 0, 0, time.UTC),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,329 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: goal.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const createGoal = `-- name: CreateGoal :one
+INSERT INTO goals (
+  user_id,
+  title,
+  target_amount,
+  currency,
+  target_date,
+  wallet_id,
+  category_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, user_id, title, target_amount, currency, target_date, wallet_id, category_id, created_at
+`
+
+type CreateGoalParams struct {
+	UserID       int32         `json:"user_id"`
+	Title        string        `json:"title"`
+	TargetAmount int64         `json:"target_amount"`
+	Currency     string        `json:"currency"`
+	TargetDate   sql.NullTime  `json:"target_date"`
+	WalletID     sql.NullInt32 `json:"wallet_id"`
+	CategoryID   sql.NullInt32 `json:"category_id"`
+}
+
+func (q *Queries) CreateGoal(ctx context.Context, arg CreateGoalParams) (Goal, error) {
+	row := q.db.QueryRowContext(ctx, createGoal,
+		arg.UserID,
+		arg.Title,
+		arg.TargetAmount,
+		arg.Currency,
+		arg.TargetDate,
+		arg.WalletID,
+		arg.CategoryID,
+	)
+	var i Goal
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.TargetAmount,
+		&i.Currency,
+		&i.TargetDate,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createGoalContribution = `-- name: CreateGoalContribution :one
+INSERT INTO accounts (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  date,
+  goal_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10::int
+) RETURNING id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id
+`
+
+type CreateGoalContributionParams struct {
+	UserID      int32     `json:"user_id"`
+	WalletID    int32     `json:"wallet_id"`
+	CategoryID  int32     `json:"category_id"`
+	Title       string    `json:"title"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Date        time.Time `json:"date"`
+	GoalID      int32     `json:"goal_id"`
+}
+
+func (q *Queries) CreateGoalContribution(ctx context.Context, arg CreateGoalContributionParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createGoalContribution,
+		arg.UserID,
+		arg.WalletID,
+		arg.CategoryID,
+		arg.Title,
+		arg.Type,
+		arg.Description,
+		arg.Amount,
+		arg.Currency,
+		arg.Date,
+		arg.GoalID,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Date,
+		&i.CreatedAt,
+		&i.Amount,
+		&i.Currency,
+		&i.WalletID,
+		&i.RecurringID,
+		&i.OccurrenceDate,
+		&i.InstallmentPlanID,
+		&i.InstallmentNumber,
+		&i.GoalID,
+	)
+	return i, err
+}
+
+const deleteGoal = `-- name: DeleteGoal :execrows
+DELETE FROM goals
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteGoalParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteGoal(ctx context.Context, arg DeleteGoalParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteGoal, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getGoal = `-- name: GetGoal :one
+SELECT id, user_id, title, target_amount, currency, target_date, wallet_id, category_id, created_at FROM goals
+WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type GetGoalParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetGoal(ctx context.Context, arg GetGoalParams) (Goal, error) {
+	row := q.db.QueryRowContext(ctx, getGoal, arg.ID, arg.UserID)
+	var i Goal
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.TargetAmount,
+		&i.Currency,
+		&i.TargetDate,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGoalContributions = `-- name: GetGoalContributions :many
+SELECT goal_id::int AS goal_id, currency, date, SUM(amount)::bigint AS sum_amount FROM accounts
+WHERE user_id = $1 AND goal_id = ANY($2::int[])
+GROUP BY goal_id, currency, date
+ORDER BY goal_id, date, currency
+`
+
+type GetGoalContributionsParams struct {
+	UserID  int32   `json:"user_id"`
+	GoalIds []int32 `json:"goal_ids"`
+}
+
+type GetGoalContributionsRow struct {
+	GoalID    int32     `json:"goal_id"`
+	Currency  string    `json:"currency"`
+	Date      time.Time `json:"date"`
+	SumAmount int64     `json:"sum_amount"`
+}
+
+func (q *Queries) GetGoalContributions(ctx context.Context, arg GetGoalContributionsParams) ([]GetGoalContributionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGoalContributions, arg.UserID, pq.Array(arg.GoalIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetGoalContributionsRow{}
+	for rows.Next() {
+		var i GetGoalContributionsRow
+		if err := rows.Scan(
+			&i.GoalID,
+			&i.Currency,
+			&i.Date,
+			&i.SumAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGoalForUpdate = `-- name: GetGoalForUpdate :one
+SELECT id, user_id, title, target_amount, currency, target_date, wallet_id, category_id, created_at FROM goals
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE
+`
+
+type GetGoalForUpdateParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetGoalForUpdate(ctx context.Context, arg GetGoalForUpdateParams) (Goal, error) {
+	row := q.db.QueryRowContext(ctx, getGoalForUpdate, arg.ID, arg.UserID)
+	var i Goal
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.TargetAmount,
+		&i.Currency,
+		&i.TargetDate,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listGoals = `-- name: ListGoals :many
+SELECT id, user_id, title, target_amount, currency, target_date, wallet_id, category_id, created_at FROM goals
+WHERE user_id = $1
+ORDER BY target_date NULLS LAST, id
+`
+
+func (q *Queries) ListGoals(ctx context.Context, userID int32) ([]Goal, error) {
+	rows, err := q.db.QueryContext(ctx, listGoals, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Goal{}
+	for rows.Next() {
+		var i Goal
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.TargetAmount,
+			&i.Currency,
+			&i.TargetDate,
+			&i.WalletID,
+			&i.CategoryID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateGoal = `-- name: UpdateGoal :one
+UPDATE goals
+SET title = $3, target_amount = $4, target_date = $5, wallet_id = $6, category_id = $7
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, title, target_amount, currency, target_date, wallet_id, category_id, created_at
+`
+
+type UpdateGoalParams struct {
+	ID           int32         `json:"id"`
+	UserID       int32         `json:"user_id"`
+	Title        string        `json:"title"`
+	TargetAmount int64         `json:"target_amount"`
+	TargetDate   sql.NullTime  `json:"target_date"`
+	WalletID     sql.NullInt32 `json:"wallet_id"`
+	CategoryID   sql.NullInt32 `json:"category_id"`
+}
+
+func (q *Queries) UpdateGoal(ctx context.Context, arg UpdateGoalParams) (Goal, error) {
+	row := q.db.QueryRowContext(ctx, updateGoal,
+		arg.ID,
+		arg.UserID,
+		arg.Title,
+		arg.TargetAmount,
+		arg.TargetDate,
+		arg.WalletID,
+		arg.CategoryID,
+	)
+	var i Goal
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.TargetAmount,
+		&i.Currency,
+		&i.TargetDate,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
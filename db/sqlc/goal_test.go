@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomGoal(t *testing.T, category Category, wallet Wallet) Goal {
+	arg := CreateGoalParams{
+		UserID:       category.UserID,
+		Title:        util.RandomString(12),
+		TargetAmount: 500000,
+		Currency:     "BRL",
+		TargetDate:   sql.NullTime{Time: time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), Valid: true},
+		WalletID:     sql.NullInt32{Int32: wallet.ID, Valid: true},
+		CategoryID:   sql.NullInt32{Int32: category.ID, Valid: true},
+	}
+
+	goal, err := testQueries.CreateGoal(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, goal)
+
+	require.Equal(t, arg.UserID, goal.UserID)
+	require.Equal(t, arg.Title, goal.Title)
+	require.Equal(t, arg.TargetAmount, goal.TargetAmount)
+	require.Equal(t, arg.WalletID, goal.WalletID)
+	require.Equal(t, arg.CategoryID, goal.CategoryID)
+	require.NotEmpty(t, goal.CreatedAt)
+
+	return goal
+}
+
+func TestCreateGoalTargetMustBePositive(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQueries.CreateGoal(context.Background(), CreateGoalParams{
+		UserID:       user.ID,
+		Title:        "trip",
+		TargetAmount: 0,
+		Currency:     "BRL",
+	})
+	require.Error(t, err)
+}
+
+func TestGoalContributions(t *testing.T) {
+	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.UserID)
+	goal := createRandomGoal(t, category, wallet)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, date := range []time.Time{march, march, march.AddDate(0, 1, 0)} {
+		account, err := testQueries.CreateGoalContribution(context.Background(), CreateGoalContributionParams{
+			UserID:      category.UserID,
+			WalletID:    wallet.ID,
+			CategoryID:  category.ID,
+			Title:       "saving",
+			Type:        category.Type,
+			Description: "saving",
+			Amount:      10000,
+			Currency:    "BRL",
+			Date:        date,
+			GoalID:      goal.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, goal.ID, account.GoalID.Int32)
+	}
+
+	rows, err := testQueries.GetGoalContributions(context.Background(), GetGoalContributionsParams{
+		UserID:  category.UserID,
+		GoalIds: []int32{goal.ID},
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, goal.ID, rows[0].GoalID)
+	require.Equal(t, int64(20000), rows[0].SumAmount)
+	require.Equal(t, int64(10000), rows[1].SumAmount)
+}
+
+func TestUpdateGoal(t *testing.T) {
+	category := createRandomCategory(t)
+	goal1 := createRandomGoal(t, category, createRandomWallet(t, category.UserID))
+
+	goal2, err := testQueries.UpdateGoal(context.Background(), UpdateGoalParams{
+		ID:           goal1.ID,
+		UserID:       goal1.UserID,
+		Title:        "emergency fund",
+		TargetAmount: 1000000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "emergency fund", goal2.Title)
+	require.Equal(t, int64(1000000), goal2.TargetAmount)
+	require.False(t, goal2.TargetDate.Valid)
+	require.False(t, goal2.WalletID.Valid)
+
+	goals, err := testQueries.ListGoals(context.Background(), goal1.UserID)
+	require.NoError(t, err)
+	require.Len(t, goals, 1)
+}
+
+func TestDeleteGoalKeepsContributions(t *testing.T) {
+	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.UserID)
+	goal := createRandomGoal(t, category, wallet)
+
+	account, err := testQueries.CreateGoalContribution(context.Background(), CreateGoalContributionParams{
+		UserID:      category.UserID,
+		WalletID:    wallet.ID,
+		CategoryID:  category.ID,
+		Title:       "saving",
+		Type:        category.Type,
+		Description: "saving",
+		Amount:      10000,
+		Currency:    "BRL",
+		Date:        time.Now(),
+		GoalID:      goal.ID,
+	})
+	require.NoError(t, err)
+
+	deleted, err := testQueries.DeleteGoal(context.Background(), DeleteGoalParams{
+		ID:     goal.ID,
+		UserID: goal.UserID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	kept, err := testQueries.GetAccount(context.Background(), GetAccountParams{
+		ID:     account.ID,
+		UserID: account.UserID,
+	})
+	require.NoError(t, err)
+	require.False(t, kept.GoalID.Valid)
+}
@@ -0,0 +1,415 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: installment.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const createInstallment = `-- name: CreateInstallment :one
+INSERT INTO accounts (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  date,
+  installment_plan_id,
+  installment_number
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10::int, $11::int
+) RETURNING id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id
+`
+
+type CreateInstallmentParams struct {
+	UserID            int32     `json:"user_id"`
+	WalletID          int32     `json:"wallet_id"`
+	CategoryID        int32     `json:"category_id"`
+	Title             string    `json:"title"`
+	Type              string    `json:"type"`
+	Description       string    `json:"description"`
+	Amount            int64     `json:"amount"`
+	Currency          string    `json:"currency"`
+	Date              time.Time `json:"date"`
+	InstallmentPlanID int32     `json:"installment_plan_id"`
+	InstallmentNumber int32     `json:"installment_number"`
+}
+
+func (q *Queries) CreateInstallment(ctx context.Context, arg CreateInstallmentParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createInstallment,
+		arg.UserID,
+		arg.WalletID,
+		arg.CategoryID,
+		arg.Title,
+		arg.Type,
+		arg.Description,
+		arg.Amount,
+		arg.Currency,
+		arg.Date,
+		arg.InstallmentPlanID,
+		arg.InstallmentNumber,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Date,
+		&i.CreatedAt,
+		&i.Amount,
+		&i.Currency,
+		&i.WalletID,
+		&i.RecurringID,
+		&i.OccurrenceDate,
+		&i.InstallmentPlanID,
+		&i.InstallmentNumber,
+		&i.GoalID,
+	)
+	return i, err
+}
+
+const createInstallmentPlan = `-- name: CreateInstallmentPlan :one
+INSERT INTO installment_plans (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  installments,
+  first_due_date,
+  interest_rate,
+  remainder_on
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING id, user_id, wallet_id, category_id, title, type, description, amount, currency, installments, first_due_date, interest_rate, remainder_on, status, created_at
+`
+
+type CreateInstallmentPlanParams struct {
+	UserID       int32     `json:"user_id"`
+	WalletID     int32     `json:"wallet_id"`
+	CategoryID   int32     `json:"category_id"`
+	Title        string    `json:"title"`
+	Type         string    `json:"type"`
+	Description  string    `json:"description"`
+	Amount       int64     `json:"amount"`
+	Currency     string    `json:"currency"`
+	Installments int32     `json:"installments"`
+	FirstDueDate time.Time `json:"first_due_date"`
+	InterestRate string    `json:"interest_rate"`
+	RemainderOn  string    `json:"remainder_on"`
+}
+
+func (q *Queries) CreateInstallmentPlan(ctx context.Context, arg CreateInstallmentPlanParams) (InstallmentPlan, error) {
+	row := q.db.QueryRowContext(ctx, createInstallmentPlan,
+		arg.UserID,
+		arg.WalletID,
+		arg.CategoryID,
+		arg.Title,
+		arg.Type,
+		arg.Description,
+		arg.Amount,
+		arg.Currency,
+		arg.Installments,
+		arg.FirstDueDate,
+		arg.InterestRate,
+		arg.RemainderOn,
+	)
+	var i InstallmentPlan
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Installments,
+		&i.FirstDueDate,
+		&i.InterestRate,
+		&i.RemainderOn,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteUnpaidInstallments = `-- name: DeleteUnpaidInstallments :many
+DELETE FROM accounts
+WHERE installment_plan_id = $1::int AND date > $2::date
+RETURNING id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id
+`
+
+type DeleteUnpaidInstallmentsParams struct {
+	InstallmentPlanID int32     `json:"installment_plan_id"`
+	PaidUntil         time.Time `json:"paid_until"`
+}
+
+func (q *Queries) DeleteUnpaidInstallments(ctx context.Context, arg DeleteUnpaidInstallmentsParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, deleteUnpaidInstallments, arg.InstallmentPlanID, arg.PaidUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Type,
+			&i.Description,
+			&i.Date,
+			&i.CreatedAt,
+			&i.Amount,
+			&i.Currency,
+			&i.WalletID,
+			&i.RecurringID,
+			&i.OccurrenceDate,
+			&i.InstallmentPlanID,
+			&i.InstallmentNumber,
+			&i.GoalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getInstallmentPlan = `-- name: GetInstallmentPlan :one
+SELECT id, user_id, wallet_id, category_id, title, type, description, amount, currency, installments, first_due_date, interest_rate, remainder_on, status, created_at FROM installment_plans
+WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type GetInstallmentPlanParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetInstallmentPlan(ctx context.Context, arg GetInstallmentPlanParams) (InstallmentPlan, error) {
+	row := q.db.QueryRowContext(ctx, getInstallmentPlan, arg.ID, arg.UserID)
+	var i InstallmentPlan
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Installments,
+		&i.FirstDueDate,
+		&i.InterestRate,
+		&i.RemainderOn,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInstallmentPlanForUpdate = `-- name: GetInstallmentPlanForUpdate :one
+SELECT id, user_id, wallet_id, category_id, title, type, description, amount, currency, installments, first_due_date, interest_rate, remainder_on, status, created_at FROM installment_plans
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE
+`
+
+type GetInstallmentPlanForUpdateParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetInstallmentPlanForUpdate(ctx context.Context, arg GetInstallmentPlanForUpdateParams) (InstallmentPlan, error) {
+	row := q.db.QueryRowContext(ctx, getInstallmentPlanForUpdate, arg.ID, arg.UserID)
+	var i InstallmentPlan
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Installments,
+		&i.FirstDueDate,
+		&i.InterestRate,
+		&i.RemainderOn,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listInstallmentPlans = `-- name: ListInstallmentPlans :many
+SELECT id, user_id, wallet_id, category_id, title, type, description, amount, currency, installments, first_due_date, interest_rate, remainder_on, status, created_at FROM installment_plans
+WHERE user_id = $1
+ORDER BY first_due_date DESC, id DESC
+`
+
+func (q *Queries) ListInstallmentPlans(ctx context.Context, userID int32) ([]InstallmentPlan, error) {
+	rows, err := q.db.QueryContext(ctx, listInstallmentPlans, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InstallmentPlan{}
+	for rows.Next() {
+		var i InstallmentPlan
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.WalletID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Type,
+			&i.Description,
+			&i.Amount,
+			&i.Currency,
+			&i.Installments,
+			&i.FirstDueDate,
+			&i.InterestRate,
+			&i.RemainderOn,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlanInstallments = `-- name: ListPlanInstallments :many
+SELECT id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id FROM accounts
+WHERE installment_plan_id = ANY($1::int[])
+ORDER BY installment_plan_id, installment_number
+`
+
+func (q *Queries) ListPlanInstallments(ctx context.Context, planIds []int32) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listPlanInstallments, pq.Array(planIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Type,
+			&i.Description,
+			&i.Date,
+			&i.CreatedAt,
+			&i.Amount,
+			&i.Currency,
+			&i.WalletID,
+			&i.RecurringID,
+			&i.OccurrenceDate,
+			&i.InstallmentPlanID,
+			&i.InstallmentNumber,
+			&i.GoalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateInstallmentPlan = `-- name: UpdateInstallmentPlan :one
+UPDATE installment_plans
+SET category_id = $3, title = $4, description = $5, amount = $6, installments = $7,
+  interest_rate = $8, remainder_on = $9, status = $10
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, wallet_id, category_id, title, type, description, amount, currency, installments, first_due_date, interest_rate, remainder_on, status, created_at
+`
+
+type UpdateInstallmentPlanParams struct {
+	ID           int32  `json:"id"`
+	UserID       int32  `json:"user_id"`
+	CategoryID   int32  `json:"category_id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Amount       int64  `json:"amount"`
+	Installments int32  `json:"installments"`
+	InterestRate string `json:"interest_rate"`
+	RemainderOn  string `json:"remainder_on"`
+	Status       string `json:"status"`
+}
+
+func (q *Queries) UpdateInstallmentPlan(ctx context.Context, arg UpdateInstallmentPlanParams) (InstallmentPlan, error) {
+	row := q.db.QueryRowContext(ctx, updateInstallmentPlan,
+		arg.ID,
+		arg.UserID,
+		arg.CategoryID,
+		arg.Title,
+		arg.Description,
+		arg.Amount,
+		arg.Installments,
+		arg.InterestRate,
+		arg.RemainderOn,
+		arg.Status,
+	)
+	var i InstallmentPlan
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Installments,
+		&i.FirstDueDate,
+		&i.InterestRate,
+		&i.RemainderOn,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomInstallmentPlan(t *testing.T) InstallmentPlan {
+	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.UserID)
+	arg := CreateInstallmentPlanParams{
+		UserID:       category.UserID,
+		WalletID:     wallet.ID,
+		CategoryID:   category.ID,
+		Title:        util.RandomString(12),
+		Type:         category.Type,
+		Description:  util.RandomString(20),
+		Amount:       100000,
+		Currency:     "BRL",
+		Installments: 3,
+		FirstDueDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		InterestRate: "1.99",
+		RemainderOn:  "last",
+	}
+
+	plan, err := testQueries.CreateInstallmentPlan(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, plan)
+
+	require.Equal(t, arg.UserID, plan.UserID)
+	require.Equal(t, arg.Amount, plan.Amount)
+	require.Equal(t, arg.Installments, plan.Installments)
+	require.Equal(t, arg.InterestRate, plan.InterestRate)
+	require.Equal(t, "active", plan.Status)
+	require.NotEmpty(t, plan.CreatedAt)
+
+	return plan
+}
+
+func createPlanInstallment(t *testing.T, plan InstallmentPlan, number int32, amount int64) Account {
+	account, err := testQueries.CreateInstallment(context.Background(), CreateInstallmentParams{
+		UserID:            plan.UserID,
+		WalletID:          plan.WalletID,
+		CategoryID:        plan.CategoryID,
+		Title:             plan.Title,
+		Type:              plan.Type,
+		Description:       plan.Description,
+		Amount:            amount,
+		Currency:          plan.Currency,
+		Date:              plan.FirstDueDate.AddDate(0, int(number-1), 0),
+		InstallmentPlanID: plan.ID,
+		InstallmentNumber: number,
+	})
+	require.NoError(t, err)
+	require.Equal(t, plan.ID, account.InstallmentPlanID.Int32)
+	require.Equal(t, number, account.InstallmentNumber.Int32)
+	return account
+}
+
+func TestCreateInstallmentPlanInvalid(t *testing.T) {
+	plan := createRandomInstallmentPlan(t)
+
+	_, err := testQueries.CreateInstallmentPlan(context.Background(), CreateInstallmentPlanParams{
+		UserID:       plan.UserID,
+		WalletID:     plan.WalletID,
+		CategoryID:   plan.CategoryID,
+		Title:        plan.Title,
+		Type:         plan.Type,
+		Description:  plan.Description,
+		Amount:       plan.Amount,
+		Currency:     plan.Currency,
+		Installments: 0,
+		FirstDueDate: plan.FirstDueDate,
+		InterestRate: "0",
+		RemainderOn:  "last",
+	})
+	require.Error(t, err)
+}
+
+func TestCreateInstallmentOnlyOnce(t *testing.T) {
+	plan := createRandomInstallmentPlan(t)
+	createPlanInstallment(t, plan, 1, 33333)
+
+	_, err := testQueries.CreateInstallment(context.Background(), CreateInstallmentParams{
+		UserID:            plan.UserID,
+		WalletID:          plan.WalletID,
+		CategoryID:        plan.CategoryID,
+		Title:             plan.Title,
+		Type:              plan.Type,
+		Description:       plan.Description,
+		Amount:            33333,
+		Currency:          plan.Currency,
+		Date:              plan.FirstDueDate,
+		InstallmentPlanID: plan.ID,
+		InstallmentNumber: 1,
+	})
+	require.Error(t, err)
+}
+
+func TestDeleteUnpaidInstallments(t *testing.T) {
+	plan := createRandomInstallmentPlan(t)
+	createPlanInstallment(t, plan, 1, 33333)
+	createPlanInstallment(t, plan, 2, 33333)
+	createPlanInstallment(t, plan, 3, 33334)
+
+	removed, err := testQueries.DeleteUnpaidInstallments(context.Background(), DeleteUnpaidInstallmentsParams{
+		InstallmentPlanID: plan.ID,
+		PaidUntil:         plan.FirstDueDate,
+	})
+	require.NoError(t, err)
+	require.Len(t, removed, 2)
+
+	installments, err := testQueries.ListPlanInstallments(context.Background(), []int32{plan.ID})
+	require.NoError(t, err)
+	require.Len(t, installments, 1)
+	require.Equal(t, int32(1), installments[0].InstallmentNumber.Int32)
+}
+
+func TestUpdateInstallmentPlan(t *testing.T) {
+	plan1 := createRandomInstallmentPlan(t)
+
+	plan2, err := testQueries.UpdateInstallmentPlan(context.Background(), UpdateInstallmentPlanParams{
+		ID:           plan1.ID,
+		UserID:       plan1.UserID,
+		CategoryID:   plan1.CategoryID,
+		Title:        plan1.Title,
+		Description:  plan1.Description,
+		Amount:       plan1.Amount,
+		Installments: 6,
+		InterestRate: plan1.InterestRate,
+		RemainderOn:  "first",
+		Status:       "cancelled",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(6), plan2.Installments)
+	require.Equal(t, "first", plan2.RemainderOn)
+	require.Equal(t, "cancelled", plan2.Status)
+
+	plans, err := testQueries.ListInstallmentPlans(context.Background(), plan1.UserID)
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+}
@@ -0,0 +1,73 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: login_attempt.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const deleteLoginAttempt = `-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts
+WHERE key = $1
+`
+
+func (q *Queries) DeleteLoginAttempt(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, deleteLoginAttempt, key)
+	return err
+}
+
+const deleteStaleLoginAttempts = `-- name: DeleteStaleLoginAttempts :exec
+DELETE FROM login_attempts
+WHERE last_failure_at < $1
+`
+
+func (q *Queries) DeleteStaleLoginAttempts(ctx context.Context, lastFailureAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteStaleLoginAttempts, lastFailureAt)
+	return err
+}
+
+const getLoginAttempt = `-- name: GetLoginAttempt :one
+SELECT key, failures, last_failure_at FROM login_attempts
+WHERE key = $1 LIMIT 1
+`
+
+func (q *Queries) GetLoginAttempt(ctx context.Context, key string) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, getLoginAttempt, key)
+	var i LoginAttempt
+	err := row.Scan(&i.Key, &i.Failures, &i.LastFailureAt)
+	return i, err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (
+  key,
+  failures,
+  last_failure_at
+) VALUES (
+  $1, 1, now()
+)
+ON CONFLICT (key) DO UPDATE
+SET
+  failures = CASE
+    WHEN login_attempts.last_failure_at < $2 THEN 1
+    ELSE login_attempts.failures + 1
+  END,
+  last_failure_at = now()
+RETURNING key, failures, last_failure_at
+`
+
+type RecordLoginFailureParams struct {
+	Key         string    `json:"key"`
+	ResetBefore time.Time `json:"reset_before"`
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Key, arg.ResetBefore)
+	var i LoginAttempt
+	err := row.Scan(&i.Key, &i.Failures, &i.LastFailureAt)
+	return i, err
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	key := "user:" + util.RandomString(8)
+
+	attempt1, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Key:         key,
+		ResetBefore: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), attempt1.Failures)
+
+	attempt2, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Key:         key,
+		ResetBefore: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), attempt2.Failures)
+
+	attempt3, err := testQueries.GetLoginAttempt(context.Background(), key)
+	require.NoError(t, err)
+	require.Equal(t, attempt2.Failures, attempt3.Failures)
+}
+
+func TestRecordLoginFailureAfterResetWindow(t *testing.T) {
+	key := "ip:" + util.RandomString(8)
+
+	_, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Key:         key,
+		ResetBefore: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	attempt, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Key:         key,
+		ResetBefore: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), attempt.Failures)
+}
+
+func TestDeleteLoginAttempt(t *testing.T) {
+	key := "user:" + util.RandomString(8)
+	_, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Key:         key,
+		ResetBefore: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	err = testQueries.DeleteLoginAttempt(context.Background(), key)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetLoginAttempt(context.Background(), key)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUserTOTP(t *testing.T) UserTotp {
+	user := createRandomUser(t)
+	arg := UpsertUserTOTPParams{
+		UserID: user.ID,
+		Secret: util.RandomString(32),
+	}
+
+	totp, err := testQueries.UpsertUserTOTP(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.UserID, totp.UserID)
+	require.Equal(t, arg.Secret, totp.Secret)
+	require.False(t, totp.ConfirmedAt.Valid)
+	require.Zero(t, totp.LastUsedStep)
+
+	return totp
+}
+
+func TestUpsertUserTOTP(t *testing.T) {
+	totp1 := createRandomUserTOTP(t)
+
+	totp2, err := testQueries.UpsertUserTOTP(context.Background(), UpsertUserTOTPParams{
+		UserID: totp1.UserID,
+		Secret: util.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, totp1.Secret, totp2.Secret)
+}
+
+func TestUpsertConfirmedUserTOTP(t *testing.T) {
+	totp := createRandomUserTOTP(t)
+	err := testQueries.ConfirmUserTOTP(context.Background(), totp.UserID)
+	require.NoError(t, err)
+
+	_, err = testQueries.UpsertUserTOTP(context.Background(), UpsertUserTOTPParams{
+		UserID: totp.UserID,
+		Secret: util.RandomString(32),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	totp := createRandomUserTOTP(t)
+
+	rows, err := testQueries.UseTOTPStep(context.Background(), UseTOTPStepParams{
+		UserID: totp.UserID,
+		Step:   100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.UseTOTPStep(context.Background(), UseTOTPStepParams{
+		UserID: totp.UserID,
+		Step:   100,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	user := createRandomUser(t)
+	arg := CreateRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: util.HashToken(util.RandomString(10)),
+	}
+	err := testQueries.CreateRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+
+	rows, err := testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams(arg))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams(arg))
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
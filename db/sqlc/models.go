@@ -5,19 +5,55 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
-	ID          int32     `json:"id"`
-	UserID      int32     `json:"user_id"`
-	CategoryID  int32     `json:"category_id"`
-	Title       string    `json:"title"`
-	Type        string    `json:"type"`
-	Description string    `json:"description"`
-	Value       int32     `json:"value"`
-	Date        time.Time `json:"date"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID                int32         `json:"id"`
+	UserID            int32         `json:"user_id"`
+	CategoryID        int32         `json:"category_id"`
+	Title             string        `json:"title"`
+	Type              string        `json:"type"`
+	Description       string        `json:"description"`
+	Date              time.Time     `json:"date"`
+	CreatedAt         time.Time     `json:"created_at"`
+	Amount            int64         `json:"amount"`
+	Currency          string        `json:"currency"`
+	WalletID          int32         `json:"wallet_id"`
+	RecurringID       sql.NullInt32 `json:"recurring_id"`
+	OccurrenceDate    sql.NullTime  `json:"occurrence_date"`
+	InstallmentPlanID sql.NullInt32 `json:"installment_plan_id"`
+	InstallmentNumber sql.NullInt32 `json:"installment_number"`
+	GoalID            sql.NullInt32 `json:"goal_id"`
+}
+
+type AuditEvent struct {
+	ID          int64           `json:"id"`
+	UserID      sql.NullInt32   `json:"user_id"`
+	ActorUserID sql.NullInt32   `json:"actor_user_id"`
+	TargetType  string          `json:"target_type"`
+	TargetID    sql.NullInt32   `json:"target_id"`
+	Action      string          `json:"action"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	ClientIp    string          `json:"client_ip"`
+	UserAgent   string          `json:"user_agent"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type Budget struct {
+	ID         int32     `json:"id"`
+	UserID     int32     `json:"user_id"`
+	CategoryID int32     `json:"category_id"`
+	Period     time.Time `json:"period"`
+	Amount     int64     `json:"amount"`
+	Currency   string    `json:"currency"`
+	Rollover   bool      `json:"rollover"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type Category struct {
@@ -29,10 +65,225 @@ type Category struct {
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+type EnvelopeAssignment struct {
+	ID         int32     `json:"id"`
+	UserID     int32     `json:"user_id"`
+	CategoryID int32     `json:"category_id"`
+	Period     time.Time `json:"period"`
+	Amount     int64     `json:"amount"`
+	Currency   string    `json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type EnvelopeClose struct {
+	UserID   int32     `json:"user_id"`
+	Period   time.Time `json:"period"`
+	ClosedAt time.Time `json:"closed_at"`
+}
+
+type EnvelopeCloseBalance struct {
+	ID         int32         `json:"id"`
+	UserID     int32         `json:"user_id"`
+	Period     time.Time     `json:"period"`
+	CategoryID sql.NullInt32 `json:"category_id"`
+	Balance    int64         `json:"balance"`
+	Currency   string        `json:"currency"`
+}
+
+type ExchangeRate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Date          time.Time `json:"date"`
+	Rate          string    `json:"rate"`
+	Source        string    `json:"source"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Goal struct {
+	ID           int32         `json:"id"`
+	UserID       int32         `json:"user_id"`
+	Title        string        `json:"title"`
+	TargetAmount int64         `json:"target_amount"`
+	Currency     string        `json:"currency"`
+	TargetDate   sql.NullTime  `json:"target_date"`
+	WalletID     sql.NullInt32 `json:"wallet_id"`
+	CategoryID   sql.NullInt32 `json:"category_id"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type InstallmentPlan struct {
+	ID           int32     `json:"id"`
+	UserID       int32     `json:"user_id"`
+	WalletID     int32     `json:"wallet_id"`
+	CategoryID   int32     `json:"category_id"`
+	Title        string    `json:"title"`
+	Type         string    `json:"type"`
+	Description  string    `json:"description"`
+	Amount       int64     `json:"amount"`
+	Currency     string    `json:"currency"`
+	Installments int32     `json:"installments"`
+	FirstDueDate time.Time `json:"first_due_date"`
+	InterestRate string    `json:"interest_rate"`
+	RemainderOn  string    `json:"remainder_on"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	Key           string    `json:"key"`
+	Failures      int32     `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
+type Notification struct {
+	ID        int64           `json:"id"`
+	UserID    int32           `json:"user_id"`
+	Kind      string          `json:"kind"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data"`
+	DedupKey  sql.NullString  `json:"dedup_key"`
+	ReadAt    sql.NullTime    `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type OidcLogin struct {
+	StateHash    string    `json:"state_hash"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type PersonalAccessToken struct {
+	ID          int32        `json:"id"`
+	UserID      int32        `json:"user_id"`
+	Name        string       `json:"name"`
+	TokenPrefix string       `json:"token_prefix"`
+	TokenHash   string       `json:"token_hash"`
+	Scopes      []string     `json:"scopes"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	LastUsedAt  sql.NullTime `json:"last_used_at"`
+	RevokedAt   sql.NullTime `json:"revoked_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type Posting struct {
+	ID         int64         `json:"id"`
+	TransferID int32         `json:"transfer_id"`
+	WalletID   sql.NullInt32 `json:"wallet_id"`
+	Kind       string        `json:"kind"`
+	Amount     int64         `json:"amount"`
+	Currency   string        `json:"currency"`
+}
+
+type RecoveryCode struct {
+	ID        int64        `json:"id"`
+	UserID    int32        `json:"user_id"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type RecurringException struct {
+	RecurringID int32     `json:"recurring_id"`
+	Date        time.Time `json:"date"`
+}
+
+type RecurringTransaction struct {
+	ID          int32        `json:"id"`
+	UserID      int32        `json:"user_id"`
+	WalletID    int32        `json:"wallet_id"`
+	CategoryID  int32        `json:"category_id"`
+	Title       string       `json:"title"`
+	Type        string       `json:"type"`
+	Description string       `json:"description"`
+	Amount      int64        `json:"amount"`
+	Currency    string       `json:"currency"`
+	Rule        string       `json:"rule"`
+	StartDate   time.Time    `json:"start_date"`
+	NextDate    sql.NullTime `json:"next_date"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID        int64        `json:"id"`
+	SessionID uuid.UUID    `json:"session_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type Session struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    int32        `json:"user_id"`
+	UserAgent string       `json:"user_agent"`
+	ClientIp  string       `json:"client_ip"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type Transfer struct {
+	ID          int32          `json:"id"`
+	UserID      int32          `json:"user_id"`
+	Description string         `json:"description"`
+	Date        time.Time      `json:"date"`
+	Rate        sql.NullString `json:"rate"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
 type User struct {
-	ID        int32     `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"password"`
+	ID                    int32        `json:"id"`
+	Username              string       `json:"username"`
+	Password              string       `json:"password"`
+	Email                 string       `json:"email"`
+	CreatedAt             time.Time    `json:"created_at"`
+	EmailVerifiedAt       sql.NullTime `json:"email_verified_at"`
+	FailedLoginAttempts   int32        `json:"failed_login_attempts"`
+	LockedUntil           sql.NullTime `json:"locked_until"`
+	DeletionScheduledAt   sql.NullTime `json:"deletion_scheduled_at"`
+	Role                  string       `json:"role"`
+	DisabledAt            sql.NullTime `json:"disabled_at"`
+	PasswordResetRequired bool         `json:"password_reset_required"`
+	BaseCurrency          string       `json:"base_currency"`
+	EnvelopeStart         sql.NullTime `json:"envelope_start"`
+	Timezone              string       `json:"timezone"`
+}
+
+type UserIdentity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	UserID    int32     `json:"user_id"`
 	Email     string    `json:"email"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+type UserToken struct {
+	ID        int64        `json:"id"`
+	UserID    int32        `json:"user_id"`
+	Purpose   string       `json:"purpose"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type UserTotp struct {
+	UserID       int32        `json:"user_id"`
+	Secret       string       `json:"secret"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at"`
+	LastUsedStep int64        `json:"last_used_step"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type Wallet struct {
+	ID             int32        `json:"id"`
+	UserID         int32        `json:"user_id"`
+	Name           string       `json:"name"`
+	Kind           string       `json:"kind"`
+	Currency       string       `json:"currency"`
+	OpeningBalance int64        `json:"opening_balance"`
+	ArchivedAt     sql.NullTime `json:"archived_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomNotification(t *testing.T, userID int32, dedupKey string) Notification {
+	arg := CreateNotificationParams{
+		UserID:   userID,
+		Kind:     "budget_threshold",
+		Title:    util.RandomString(12),
+		Body:     util.RandomString(40),
+		Data:     json.RawMessage(`{"threshold": 80}`),
+		DedupKey: sql.NullString{String: dedupKey, Valid: dedupKey != ""},
+	}
+
+	notification, err := testQueries.CreateNotification(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, notification)
+
+	require.Equal(t, arg.UserID, notification.UserID)
+	require.Equal(t, arg.Kind, notification.Kind)
+	require.Equal(t, arg.Title, notification.Title)
+	require.Equal(t, arg.DedupKey, notification.DedupKey)
+	require.False(t, notification.ReadAt.Valid)
+	require.NotEmpty(t, notification.CreatedAt)
+
+	return notification
+}
+
+func TestCreateNotificationOnce(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomNotification(t, user.ID, "budget:1:2024-03:80")
+
+	_, err := testQueries.CreateNotification(context.Background(), CreateNotificationParams{
+		UserID:   user.ID,
+		Kind:     "budget_threshold",
+		Title:    "again",
+		Body:     "again",
+		Data:     json.RawMessage(`{}`),
+		DedupKey: sql.NullString{String: "budget:1:2024-03:80", Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Notifications without a key are never deduplicated.
+	createRandomNotification(t, user.ID, "")
+	createRandomNotification(t, user.ID, "")
+}
+
+func TestListNotifications(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomNotification(t, user.ID, "")
+	}
+	last := createRandomNotification(t, user.ID, "")
+
+	read, err := testQueries.MarkNotificationRead(context.Background(), MarkNotificationReadParams{
+		ID:     last.ID,
+		UserID: user.ID,
+	})
+	require.NoError(t, err)
+	require.True(t, read.ReadAt.Valid)
+
+	notifications, err := testQueries.ListNotifications(context.Background(), ListNotificationsParams{
+		UserID:    user.ID,
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, notifications, 4)
+	require.Equal(t, last.ID, notifications[0].ID)
+
+	unread, err := testQueries.ListNotifications(context.Background(), ListNotificationsParams{
+		UserID:     user.ID,
+		UnreadOnly: true,
+		PageLimit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, unread, 3)
+
+	count, err := testQueries.CountUnreadNotifications(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+
+	marked, err := testQueries.MarkAllNotificationsRead(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), marked)
+
+	count, err = testQueries.CountUnreadNotifications(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func TestListUserNotifications(t *testing.T) {
+	user := createRandomUser(t)
+	first := createRandomNotification(t, user.ID, "")
+	createRandomNotification(t, user.ID, "")
+	createRandomNotification(t, createRandomUser(t).ID, "")
+
+	notifications, err := testQueries.ListUserNotifications(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, notifications, 2)
+	require.Equal(t, first.ID, notifications[0].ID)
+}
+
+func TestMarkNotificationReadOfOtherUser(t *testing.T) {
+	notification := createRandomNotification(t, createRandomUser(t).ID, "")
+
+	_, err := testQueries.MarkNotificationRead(context.Background(), MarkNotificationReadParams{
+		ID:     notification.ID,
+		UserID: createRandomUser(t).ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
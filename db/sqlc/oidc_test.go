@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomOIDCLogin(t *testing.T, expiresAt time.Time) OidcLogin {
+	arg := CreateOIDCLoginParams{
+		StateHash:    util.HashToken(util.RandomString(32)),
+		Nonce:        util.RandomString(32),
+		CodeVerifier: util.RandomString(43),
+		ExpiresAt:    expiresAt,
+	}
+
+	login, err := testQueries.CreateOIDCLogin(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.StateHash, login.StateHash)
+	require.Equal(t, arg.Nonce, login.Nonce)
+	require.Equal(t, arg.CodeVerifier, login.CodeVerifier)
+
+	return login
+}
+
+func TestConsumeOIDCLoginOnlyOnce(t *testing.T) {
+	login := createRandomOIDCLogin(t, time.Now().Add(time.Minute))
+
+	consumed, err := testQueries.ConsumeOIDCLogin(context.Background(), login.StateHash)
+	require.NoError(t, err)
+	require.Equal(t, login.Nonce, consumed.Nonce)
+
+	_, err = testQueries.ConsumeOIDCLogin(context.Background(), login.StateHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestConsumeExpiredOIDCLogin(t *testing.T) {
+	login := createRandomOIDCLogin(t, time.Now().Add(-time.Minute))
+
+	_, err := testQueries.ConsumeOIDCLogin(context.Background(), login.StateHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateUserIdentity(t *testing.T) {
+	user := createRandomUser(t)
+	arg := CreateUserIdentityParams{
+		Issuer:  "https://accounts.example.com",
+		Subject: util.RandomString(16),
+		UserID:  user.ID,
+		Email:   user.Email,
+	}
+
+	_, err := testQueries.CreateUserIdentity(context.Background(), arg)
+	require.NoError(t, err)
+
+	identity, err := testQueries.GetUserIdentity(context.Background(), GetUserIdentityParams{
+		Issuer:  arg.Issuer,
+		Subject: arg.Subject,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.ID, identity.UserID)
+
+	_, err = testQueries.CreateUserIdentity(context.Background(), arg)
+	require.Error(t, err)
+}
+
+func TestListUserIdentities(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 2; i++ {
+		_, err := testQueries.CreateUserIdentity(context.Background(), CreateUserIdentityParams{
+			Issuer:  "https://accounts.example.com",
+			Subject: util.RandomString(16),
+			UserID:  user.ID,
+			Email:   user.Email,
+		})
+		require.NoError(t, err)
+	}
+
+	identities, err := testQueries.ListUserIdentities(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+}
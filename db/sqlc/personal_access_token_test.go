@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPersonalAccessToken(t *testing.T) PersonalAccessToken {
+	user := createRandomUser(t)
+	arg := CreatePersonalAccessTokenParams{
+		UserID:      user.ID,
+		Name:        util.RandomString(8),
+		TokenPrefix: "gfp_" + util.RandomString(6),
+		TokenHash:   util.HashToken(util.RandomString(32)),
+		Scopes:      []string{"read", "transactions:write"},
+		ExpiresAt:   time.Now().Add(24 * time.Hour),
+	}
+
+	token, err := testQueries.CreatePersonalAccessToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	require.Equal(t, arg.UserID, token.UserID)
+	require.Equal(t, arg.Name, token.Name)
+	require.Equal(t, arg.TokenPrefix, token.TokenPrefix)
+	require.Equal(t, arg.Scopes, token.Scopes)
+	require.False(t, token.LastUsedAt.Valid)
+	require.False(t, token.RevokedAt.Valid)
+
+	return token
+}
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	createRandomPersonalAccessToken(t)
+}
+
+func TestGetPersonalAccessTokenByHash(t *testing.T) {
+	token := createRandomPersonalAccessToken(t)
+
+	row, err := testQueries.GetPersonalAccessTokenByHash(context.Background(), token.TokenHash)
+	require.NoError(t, err)
+
+	require.Equal(t, token.ID, row.ID)
+	require.Equal(t, token.UserID, row.UserID)
+	require.Equal(t, token.Scopes, row.Scopes)
+	require.NotEmpty(t, row.Username)
+}
+
+func TestListPersonalAccessTokens(t *testing.T) {
+	token := createRandomPersonalAccessToken(t)
+
+	tokens, err := testQueries.ListPersonalAccessTokens(context.Background(), token.UserID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, token.ID, tokens[0].ID)
+}
+
+func TestRevokePersonalAccessToken(t *testing.T) {
+	token := createRandomPersonalAccessToken(t)
+
+	rows, err := testQueries.RevokePersonalAccessToken(context.Background(), RevokePersonalAccessTokenParams{
+		ID:     token.ID,
+		UserID: token.UserID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	tokens, err := testQueries.ListPersonalAccessTokens(context.Background(), token.UserID)
+	require.NoError(t, err)
+	require.Empty(t, tokens)
+}
+
+func TestListUserPersonalAccessTokensKeepsRevoked(t *testing.T) {
+	token := createRandomPersonalAccessToken(t)
+	_, err := testQueries.RevokePersonalAccessToken(context.Background(), RevokePersonalAccessTokenParams{
+		ID:     token.ID,
+		UserID: token.UserID,
+	})
+	require.NoError(t, err)
+
+	tokens, err := testQueries.ListUserPersonalAccessTokens(context.Background(), token.UserID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.True(t, tokens[0].RevokedAt.Valid)
+}
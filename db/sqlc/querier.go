@@ -6,24 +6,166 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
+	CancelUserDeletion(ctx context.Context, id int32) (int64, error)
+	ClaimDueRecurringTransaction(ctx context.Context, arg ClaimDueRecurringTransactionParams) (RecurringTransaction, error)
+	ConfirmUserTOTP(ctx context.Context, userID int32) error
+	ConsumeOIDCLogin(ctx context.Context, stateHash string) (OidcLogin, error)
+	CopyRecurringExceptions(ctx context.Context, arg CopyRecurringExceptionsParams) error
+	CountUnreadNotifications(ctx context.Context, userID int32) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
+	CreateBudget(ctx context.Context, arg CreateBudgetParams) (Budget, error)
 	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+	CreateEnvelopeClose(ctx context.Context, arg CreateEnvelopeCloseParams) (EnvelopeClose, error)
+	CreateEnvelopeCloseBalance(ctx context.Context, arg CreateEnvelopeCloseBalanceParams) (EnvelopeCloseBalance, error)
+	CreateGoal(ctx context.Context, arg CreateGoalParams) (Goal, error)
+	CreateGoalContribution(ctx context.Context, arg CreateGoalContributionParams) (Account, error)
+	CreateInstallment(ctx context.Context, arg CreateInstallmentParams) (Account, error)
+	CreateInstallmentPlan(ctx context.Context, arg CreateInstallmentPlanParams) (InstallmentPlan, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
+	CreateOIDCLogin(ctx context.Context, arg CreateOIDCLoginParams) (OidcLogin, error)
+	CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
+	CreatePosting(ctx context.Context, arg CreatePostingParams) (Posting, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
+	CreateRecurringException(ctx context.Context, arg CreateRecurringExceptionParams) error
+	CreateRecurringOccurrence(ctx context.Context, arg CreateRecurringOccurrenceParams) (Account, error)
+	CreateRecurringTransaction(ctx context.Context, arg CreateRecurringTransactionParams) (RecurringTransaction, error)
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteAccount(ctx context.Context, id int32) error
-	DeleteCategories(ctx context.Context, id int32) error
-	GetAccount(ctx context.Context, id int32) (Account, error)
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
+	CreateUserToken(ctx context.Context, arg CreateUserTokenParams) (UserToken, error)
+	CreateWallet(ctx context.Context, arg CreateWalletParams) (Wallet, error)
+	DeleteAccount(ctx context.Context, arg DeleteAccountParams) (int64, error)
+	DeleteBudget(ctx context.Context, arg DeleteBudgetParams) (int64, error)
+	DeleteCategories(ctx context.Context, arg DeleteCategoriesParams) (int64, error)
+	DeleteExpiredOIDCLogins(ctx context.Context) error
+	DeleteGoal(ctx context.Context, arg DeleteGoalParams) (int64, error)
+	DeleteLoginAttempt(ctx context.Context, key string) error
+	DeleteRecoveryCodes(ctx context.Context, userID int32) error
+	DeleteRecurringOccurrence(ctx context.Context, arg DeleteRecurringOccurrenceParams) ([]Account, error)
+	DeleteRecurringOccurrencesFrom(ctx context.Context, arg DeleteRecurringOccurrencesFromParams) ([]Account, error)
+	DeleteRecurringTransaction(ctx context.Context, arg DeleteRecurringTransactionParams) (int64, error)
+	DeleteStaleLoginAttempts(ctx context.Context, lastFailureAt time.Time) error
+	DeleteTransfer(ctx context.Context, arg DeleteTransferParams) (int64, error)
+	DeleteTransferPostings(ctx context.Context, transferID int32) error
+	DeleteUnpaidInstallments(ctx context.Context, arg DeleteUnpaidInstallmentsParams) ([]Account, error)
+	DeleteUserTOTP(ctx context.Context, userID int32) error
+	DeleteWallet(ctx context.Context, arg DeleteWalletParams) (int64, error)
+	DisableUser(ctx context.Context, id int32) (User, error)
+	EnableUser(ctx context.Context, id int32) (User, error)
+	GetAccount(ctx context.Context, arg GetAccountParams) (Account, error)
+	GetAccountForUpdate(ctx context.Context, arg GetAccountForUpdateParams) (Account, error)
 	GetAccounts(ctx context.Context, arg GetAccountsParams) ([]GetAccountsRow, error)
 	GetAccountsGraph(ctx context.Context, arg GetAccountsGraphParams) (int64, error)
-	GetAccountsReports(ctx context.Context, arg GetAccountsReportsParams) (int64, error)
+	GetAccountsReports(ctx context.Context, arg GetAccountsReportsParams) ([]GetAccountsReportsRow, error)
+	GetBudgetForUpdate(ctx context.Context, arg GetBudgetForUpdateParams) (Budget, error)
+	GetBudgetSpending(ctx context.Context, arg GetBudgetSpendingParams) ([]GetBudgetSpendingRow, error)
+	GetCashFlow(ctx context.Context, arg GetCashFlowParams) ([]GetCashFlowRow, error)
 	GetCategories(ctx context.Context, arg GetCategoriesParams) ([]Category, error)
-	GetCategory(ctx context.Context, id int32) (Category, error)
+	GetCategory(ctx context.Context, arg GetCategoryParams) (Category, error)
+	GetCategoryForUpdate(ctx context.Context, arg GetCategoryForUpdateParams) (Category, error)
+	GetEnvelopeActivity(ctx context.Context, arg GetEnvelopeActivityParams) ([]GetEnvelopeActivityRow, error)
+	GetEnvelopeAssignmentForUpdate(ctx context.Context, arg GetEnvelopeAssignmentForUpdateParams) (EnvelopeAssignment, error)
+	GetEnvelopeCloseBefore(ctx context.Context, arg GetEnvelopeCloseBeforeParams) (EnvelopeClose, error)
+	GetEnvelopeClosedUntil(ctx context.Context, userID int32) (time.Time, error)
+	GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error)
+	GetGoal(ctx context.Context, arg GetGoalParams) (Goal, error)
+	GetGoalContributions(ctx context.Context, arg GetGoalContributionsParams) ([]GetGoalContributionsRow, error)
+	GetGoalForUpdate(ctx context.Context, arg GetGoalForUpdateParams) (Goal, error)
+	GetInstallmentPlan(ctx context.Context, arg GetInstallmentPlanParams) (InstallmentPlan, error)
+	GetInstallmentPlanForUpdate(ctx context.Context, arg GetInstallmentPlanForUpdateParams) (InstallmentPlan, error)
+	GetLastEnvelopeClose(ctx context.Context, userID int32) (EnvelopeClose, error)
+	GetLoginAttempt(ctx context.Context, key string) (LoginAttempt, error)
+	GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (GetPersonalAccessTokenByHashRow, error)
+	GetRecurringTransaction(ctx context.Context, arg GetRecurringTransactionParams) (RecurringTransaction, error)
+	GetRecurringTransactionForUpdate(ctx context.Context, arg GetRecurringTransactionForUpdateParams) (RecurringTransaction, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (GetRefreshTokenRow, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetTransfer(ctx context.Context, arg GetTransferParams) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, arg GetTransferForUpdateParams) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserById(ctx context.Context, id int32) (User, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	GetUserTOTP(ctx context.Context, userID int32) (UserTotp, error)
+	GetUserToken(ctx context.Context, arg GetUserTokenParams) (UserToken, error)
+	GetUserUsageStats(ctx context.Context, id int32) (GetUserUsageStatsRow, error)
+	GetWallet(ctx context.Context, arg GetWalletParams) (Wallet, error)
+	GetWalletBalance(ctx context.Context, arg GetWalletBalanceParams) (int64, error)
+	GetWalletForUpdate(ctx context.Context, arg GetWalletForUpdateParams) (Wallet, error)
+	InvalidateUserTokens(ctx context.Context, arg InvalidateUserTokensParams) error
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListBudgets(ctx context.Context, arg ListBudgetsParams) ([]Budget, error)
+	ListBudgetsUntil(ctx context.Context, arg ListBudgetsUntilParams) ([]ListBudgetsUntilRow, error)
+	ListDueRecurringTransactions(ctx context.Context, today time.Time) ([]int32, error)
+	ListEnvelopeAssignments(ctx context.Context, arg ListEnvelopeAssignmentsParams) ([]EnvelopeAssignment, error)
+	ListEnvelopeCloseBalances(ctx context.Context, arg ListEnvelopeCloseBalancesParams) ([]EnvelopeCloseBalance, error)
+	ListGoals(ctx context.Context, userID int32) ([]Goal, error)
+	ListInstallmentPlans(ctx context.Context, userID int32) ([]InstallmentPlan, error)
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error)
+	ListPersonalAccessTokens(ctx context.Context, userID int32) ([]PersonalAccessToken, error)
+	ListPlanInstallments(ctx context.Context, planIds []int32) ([]Account, error)
+	ListRecurringExceptions(ctx context.Context, recurringID int32) ([]time.Time, error)
+	ListRecurringTransactions(ctx context.Context, userID int32) ([]RecurringTransaction, error)
+	ListTransferPostings(ctx context.Context, transferIds []int32) ([]Posting, error)
+	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUserAccounts(ctx context.Context, userID int32) ([]Account, error)
+	ListUserAuditEvents(ctx context.Context, userID sql.NullInt32) ([]AuditEvent, error)
+	ListUserCategories(ctx context.Context, userID int32) ([]Category, error)
+	ListUserEnvelopeAssignments(ctx context.Context, userID int32) ([]EnvelopeAssignment, error)
+	ListUserEnvelopeCloseBalances(ctx context.Context, userID int32) ([]EnvelopeCloseBalance, error)
+	ListUserEnvelopeCloses(ctx context.Context, userID int32) ([]EnvelopeClose, error)
+	ListUserIdentities(ctx context.Context, userID int32) ([]UserIdentity, error)
+	ListUserNotifications(ctx context.Context, userID int32) ([]Notification, error)
+	ListUserPersonalAccessTokens(ctx context.Context, userID int32) ([]PersonalAccessToken, error)
+	ListUserSessions(ctx context.Context, userID int32) ([]Session, error)
+	ListUserTransfers(ctx context.Context, userID int32) ([]Transfer, error)
+	ListUserWallets(ctx context.Context, userID int32) ([]Wallet, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	ListWallets(ctx context.Context, arg ListWalletsParams) ([]ListWalletsRow, error)
+	MarkAllNotificationsRead(ctx context.Context, userID int32) (int64, error)
+	MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (Notification, error)
+	PurgeDeletedUsers(ctx context.Context) (int64, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginAttempt, error)
+	RecordUserLoginFailure(ctx context.Context, arg RecordUserLoginFailureParams) (RecordUserLoginFailureRow, error)
+	RequireUserPasswordReset(ctx context.Context, id int32) (User, error)
+	ResetUserLoginFailures(ctx context.Context, id int32) error
+	RevokeOtherUserSessions(ctx context.Context, arg RevokeOtherUserSessionsParams) (int64, error)
+	RevokePersonalAccessToken(ctx context.Context, arg RevokePersonalAccessTokenParams) (int64, error)
+	RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error)
+	RevokeUserPersonalAccessTokens(ctx context.Context, userID int32) error
+	RevokeUserSessions(ctx context.Context, userID int32) (int64, error)
+	ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error)
+	SetEnvelopeAssignment(ctx context.Context, arg SetEnvelopeAssignmentParams) (EnvelopeAssignment, error)
+	SetUserEnvelopeStart(ctx context.Context, arg SetUserEnvelopeStartParams) (User, error)
+	TouchPersonalAccessToken(ctx context.Context, id int32) error
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateBudget(ctx context.Context, arg UpdateBudgetParams) (Budget, error)
 	UpdateCategories(ctx context.Context, arg UpdateCategoriesParams) (Category, error)
+	UpdateGoal(ctx context.Context, arg UpdateGoalParams) (Goal, error)
+	UpdateInstallmentPlan(ctx context.Context, arg UpdateInstallmentPlanParams) (InstallmentPlan, error)
+	UpdateRecurringSchedule(ctx context.Context, arg UpdateRecurringScheduleParams) (RecurringTransaction, error)
+	UpdateTransfer(ctx context.Context, arg UpdateTransferParams) (Transfer, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpdateWallet(ctx context.Context, arg UpdateWalletParams) (Wallet, error)
+	UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRate, error)
+	UpsertUserTOTP(ctx context.Context, arg UpsertUserTOTPParams) (UserTotp, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	UseRefreshToken(ctx context.Context, id int64) (int64, error)
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error)
+	UseUserToken(ctx context.Context, id int64) (int64, error)
+	VerifyUserEmail(ctx context.Context, id int32) error
 }
 
 var _ Querier = (*Queries)(nil)
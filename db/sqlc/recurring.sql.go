@@ -0,0 +1,535 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: recurring.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const claimDueRecurringTransaction = `-- name: ClaimDueRecurringTransaction :one
+SELECT id, user_id, wallet_id, category_id, title, type, description, amount, currency, rule, start_date, next_date, created_at FROM recurring_transactions r
+WHERE r.id = $1 AND r.next_date <= $2::date
+  AND EXISTS (
+    SELECT 1 FROM wallets w
+    WHERE w.id = r.wallet_id AND w.archived_at IS NULL
+  )
+FOR UPDATE SKIP LOCKED
+`
+
+type ClaimDueRecurringTransactionParams struct {
+	ID    int32     `json:"id"`
+	Today time.Time `json:"today"`
+}
+
+func (q *Queries) ClaimDueRecurringTransaction(ctx context.Context, arg ClaimDueRecurringTransactionParams) (RecurringTransaction, error) {
+	row := q.db.QueryRowContext(ctx, claimDueRecurringTransaction, arg.ID, arg.Today)
+	var i RecurringTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Rule,
+		&i.StartDate,
+		&i.NextDate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const copyRecurringExceptions = `-- name: CopyRecurringExceptions :exec
+INSERT INTO recurring_exceptions (recurring_id, date)
+SELECT $1::int, e.date FROM recurring_exceptions e
+WHERE e.recurring_id = $2 AND e.date >= $3::date
+`
+
+type CopyRecurringExceptionsParams struct {
+	NewRecurringID int32     `json:"new_recurring_id"`
+	RecurringID    int32     `json:"recurring_id"`
+	FromDate       time.Time `json:"from_date"`
+}
+
+func (q *Queries) CopyRecurringExceptions(ctx context.Context, arg CopyRecurringExceptionsParams) error {
+	_, err := q.db.ExecContext(ctx, copyRecurringExceptions, arg.NewRecurringID, arg.RecurringID, arg.FromDate)
+	return err
+}
+
+const createRecurringException = `-- name: CreateRecurringException :exec
+INSERT INTO recurring_exceptions (
+  recurring_id,
+  date
+) VALUES (
+  $1, $2
+) ON CONFLICT DO NOTHING
+`
+
+type CreateRecurringExceptionParams struct {
+	RecurringID int32     `json:"recurring_id"`
+	Date        time.Time `json:"date"`
+}
+
+func (q *Queries) CreateRecurringException(ctx context.Context, arg CreateRecurringExceptionParams) error {
+	_, err := q.db.ExecContext(ctx, createRecurringException, arg.RecurringID, arg.Date)
+	return err
+}
+
+const createRecurringOccurrence = `-- name: CreateRecurringOccurrence :one
+INSERT INTO accounts (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  date,
+  recurring_id,
+  occurrence_date
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $9
+) ON CONFLICT (recurring_id, occurrence_date) DO NOTHING
+RETURNING id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id
+`
+
+type CreateRecurringOccurrenceParams struct {
+	UserID      int32         `json:"user_id"`
+	WalletID    int32         `json:"wallet_id"`
+	CategoryID  int32         `json:"category_id"`
+	Title       string        `json:"title"`
+	Type        string        `json:"type"`
+	Description string        `json:"description"`
+	Amount      int64         `json:"amount"`
+	Currency    string        `json:"currency"`
+	Date        time.Time     `json:"date"`
+	RecurringID sql.NullInt32 `json:"recurring_id"`
+}
+
+func (q *Queries) CreateRecurringOccurrence(ctx context.Context, arg CreateRecurringOccurrenceParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createRecurringOccurrence,
+		arg.UserID,
+		arg.WalletID,
+		arg.CategoryID,
+		arg.Title,
+		arg.Type,
+		arg.Description,
+		arg.Amount,
+		arg.Currency,
+		arg.Date,
+		arg.RecurringID,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Date,
+		&i.CreatedAt,
+		&i.Amount,
+		&i.Currency,
+		&i.WalletID,
+		&i.RecurringID,
+		&i.OccurrenceDate,
+		&i.InstallmentPlanID,
+		&i.InstallmentNumber,
+		&i.GoalID,
+	)
+	return i, err
+}
+
+const createRecurringTransaction = `-- name: CreateRecurringTransaction :one
+INSERT INTO recurring_transactions (
+  user_id,
+  wallet_id,
+  category_id,
+  title,
+  type,
+  description,
+  amount,
+  currency,
+  rule,
+  start_date,
+  next_date
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING id, user_id, wallet_id, category_id, title, type, description, amount, currency, rule, start_date, next_date, created_at
+`
+
+type CreateRecurringTransactionParams struct {
+	UserID      int32        `json:"user_id"`
+	WalletID    int32        `json:"wallet_id"`
+	CategoryID  int32        `json:"category_id"`
+	Title       string       `json:"title"`
+	Type        string       `json:"type"`
+	Description string       `json:"description"`
+	Amount      int64        `json:"amount"`
+	Currency    string       `json:"currency"`
+	Rule        string       `json:"rule"`
+	StartDate   time.Time    `json:"start_date"`
+	NextDate    sql.NullTime `json:"next_date"`
+}
+
+func (q *Queries) CreateRecurringTransaction(ctx context.Context, arg CreateRecurringTransactionParams) (RecurringTransaction, error) {
+	row := q.db.QueryRowContext(ctx, createRecurringTransaction,
+		arg.UserID,
+		arg.WalletID,
+		arg.CategoryID,
+		arg.Title,
+		arg.Type,
+		arg.Description,
+		arg.Amount,
+		arg.Currency,
+		arg.Rule,
+		arg.StartDate,
+		arg.NextDate,
+	)
+	var i RecurringTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Rule,
+		&i.StartDate,
+		&i.NextDate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteRecurringOccurrence = `-- name: DeleteRecurringOccurrence :many
+DELETE FROM accounts
+WHERE recurring_id = $1::int AND occurrence_date = $2::date
+RETURNING id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id
+`
+
+type DeleteRecurringOccurrenceParams struct {
+	RecurringID    int32     `json:"recurring_id"`
+	OccurrenceDate time.Time `json:"occurrence_date"`
+}
+
+func (q *Queries) DeleteRecurringOccurrence(ctx context.Context, arg DeleteRecurringOccurrenceParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, deleteRecurringOccurrence, arg.RecurringID, arg.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Type,
+			&i.Description,
+			&i.Date,
+			&i.CreatedAt,
+			&i.Amount,
+			&i.Currency,
+			&i.WalletID,
+			&i.RecurringID,
+			&i.OccurrenceDate,
+			&i.InstallmentPlanID,
+			&i.InstallmentNumber,
+			&i.GoalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteRecurringOccurrencesFrom = `-- name: DeleteRecurringOccurrencesFrom :many
+DELETE FROM accounts
+WHERE recurring_id = $1::int AND occurrence_date >= $2::date
+RETURNING id, user_id, category_id, title, type, description, date, created_at, amount, currency, wallet_id, recurring_id, occurrence_date, installment_plan_id, installment_number, goal_id
+`
+
+type DeleteRecurringOccurrencesFromParams struct {
+	RecurringID    int32     `json:"recurring_id"`
+	OccurrenceDate time.Time `json:"occurrence_date"`
+}
+
+func (q *Queries) DeleteRecurringOccurrencesFrom(ctx context.Context, arg DeleteRecurringOccurrencesFromParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, deleteRecurringOccurrencesFrom, arg.RecurringID, arg.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Type,
+			&i.Description,
+			&i.Date,
+			&i.CreatedAt,
+			&i.Amount,
+			&i.Currency,
+			&i.WalletID,
+			&i.RecurringID,
+			&i.OccurrenceDate,
+			&i.InstallmentPlanID,
+			&i.InstallmentNumber,
+			&i.GoalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteRecurringTransaction = `-- name: DeleteRecurringTransaction :execrows
+DELETE FROM recurring_transactions
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteRecurringTransactionParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteRecurringTransaction(ctx context.Context, arg DeleteRecurringTransactionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRecurringTransaction, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getRecurringTransaction = `-- name: GetRecurringTransaction :one
+SELECT id, user_id, wallet_id, category_id, title, type, description, amount, currency, rule, start_date, next_date, created_at FROM recurring_transactions
+WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type GetRecurringTransactionParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetRecurringTransaction(ctx context.Context, arg GetRecurringTransactionParams) (RecurringTransaction, error) {
+	row := q.db.QueryRowContext(ctx, getRecurringTransaction, arg.ID, arg.UserID)
+	var i RecurringTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Rule,
+		&i.StartDate,
+		&i.NextDate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRecurringTransactionForUpdate = `-- name: GetRecurringTransactionForUpdate :one
+SELECT id, user_id, wallet_id, category_id, title, type, description, amount, currency, rule, start_date, next_date, created_at FROM recurring_transactions
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE
+`
+
+type GetRecurringTransactionForUpdateParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetRecurringTransactionForUpdate(ctx context.Context, arg GetRecurringTransactionForUpdateParams) (RecurringTransaction, error) {
+	row := q.db.QueryRowContext(ctx, getRecurringTransactionForUpdate, arg.ID, arg.UserID)
+	var i RecurringTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Rule,
+		&i.StartDate,
+		&i.NextDate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listDueRecurringTransactions = `-- name: ListDueRecurringTransactions :many
+SELECT r.id FROM recurring_transactions r
+JOIN wallets w ON w.id = r.wallet_id AND w.archived_at IS NULL
+WHERE r.next_date <= $1::date
+ORDER BY r.next_date, r.id
+`
+
+func (q *Queries) ListDueRecurringTransactions(ctx context.Context, today time.Time) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listDueRecurringTransactions, today)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecurringExceptions = `-- name: ListRecurringExceptions :many
+SELECT date FROM recurring_exceptions
+WHERE recurring_id = $1
+ORDER BY date
+`
+
+func (q *Queries) ListRecurringExceptions(ctx context.Context, recurringID int32) ([]time.Time, error) {
+	rows, err := q.db.QueryContext(ctx, listRecurringExceptions, recurringID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []time.Time{}
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		items = append(items, date)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecurringTransactions = `-- name: ListRecurringTransactions :many
+SELECT id, user_id, wallet_id, category_id, title, type, description, amount, currency, rule, start_date, next_date, created_at FROM recurring_transactions
+WHERE user_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListRecurringTransactions(ctx context.Context, userID int32) ([]RecurringTransaction, error) {
+	rows, err := q.db.QueryContext(ctx, listRecurringTransactions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RecurringTransaction{}
+	for rows.Next() {
+		var i RecurringTransaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.WalletID,
+			&i.CategoryID,
+			&i.Title,
+			&i.Type,
+			&i.Description,
+			&i.Amount,
+			&i.Currency,
+			&i.Rule,
+			&i.StartDate,
+			&i.NextDate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateRecurringSchedule = `-- name: UpdateRecurringSchedule :one
+UPDATE recurring_transactions
+SET rule = $2, next_date = $3
+WHERE id = $1
+RETURNING id, user_id, wallet_id, category_id, title, type, description, amount, currency, rule, start_date, next_date, created_at
+`
+
+type UpdateRecurringScheduleParams struct {
+	ID       int32        `json:"id"`
+	Rule     string       `json:"rule"`
+	NextDate sql.NullTime `json:"next_date"`
+}
+
+func (q *Queries) UpdateRecurringSchedule(ctx context.Context, arg UpdateRecurringScheduleParams) (RecurringTransaction, error) {
+	row := q.db.QueryRowContext(ctx, updateRecurringSchedule, arg.ID, arg.Rule, arg.NextDate)
+	var i RecurringTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.CategoryID,
+		&i.Title,
+		&i.Type,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Rule,
+		&i.StartDate,
+		&i.NextDate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
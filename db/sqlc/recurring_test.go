@@ -0,0 +1,228 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRecurringTransaction(t *testing.T, startDate time.Time) RecurringTransaction {
+	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.UserID)
+	arg := CreateRecurringTransactionParams{
+		UserID:      category.UserID,
+		WalletID:    wallet.ID,
+		CategoryID:  category.ID,
+		Title:       util.RandomString(12),
+		Type:        category.Type,
+		Description: util.RandomString(20),
+		Amount:      150000,
+		Currency:    "BRL",
+		Rule:        "FREQ=MONTHLY;COUNT=12",
+		StartDate:   startDate,
+		NextDate:    sql.NullTime{Time: startDate, Valid: true},
+	}
+
+	recurring, err := testQueries.CreateRecurringTransaction(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, recurring)
+
+	require.Equal(t, arg.UserID, recurring.UserID)
+	require.Equal(t, arg.WalletID, recurring.WalletID)
+	require.Equal(t, arg.Amount, recurring.Amount)
+	require.Equal(t, arg.Rule, recurring.Rule)
+	require.True(t, recurring.NextDate.Valid)
+	require.NotEmpty(t, recurring.CreatedAt)
+
+	return recurring
+}
+
+func recurringOccurrenceParams(recurring RecurringTransaction, date time.Time) CreateRecurringOccurrenceParams {
+	return CreateRecurringOccurrenceParams{
+		UserID:      recurring.UserID,
+		WalletID:    recurring.WalletID,
+		CategoryID:  recurring.CategoryID,
+		Title:       recurring.Title,
+		Type:        recurring.Type,
+		Description: recurring.Description,
+		Amount:      recurring.Amount,
+		Currency:    recurring.Currency,
+		Date:        date,
+		RecurringID: sql.NullInt32{Int32: recurring.ID, Valid: true},
+	}
+}
+
+func TestCreateRecurringOccurrenceOnlyOnce(t *testing.T) {
+	date := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	recurring := createRandomRecurringTransaction(t, date)
+
+	account, err := testQueries.CreateRecurringOccurrence(context.Background(), recurringOccurrenceParams(recurring, date))
+	require.NoError(t, err)
+	require.Equal(t, recurring.ID, account.RecurringID.Int32)
+	require.True(t, account.OccurrenceDate.Valid)
+	require.WithinDuration(t, date, account.OccurrenceDate.Time, 0)
+
+	_, err = testQueries.CreateRecurringOccurrence(context.Background(), recurringOccurrenceParams(recurring, date))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestClaimDueRecurringTransaction(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	due := createRandomRecurringTransaction(t, today.AddDate(0, 0, -1))
+	later := createRandomRecurringTransaction(t, today.AddDate(0, 0, 1))
+
+	ids, err := testQueries.ListDueRecurringTransactions(context.Background(), today)
+	require.NoError(t, err)
+	require.Contains(t, ids, due.ID)
+	require.NotContains(t, ids, later.ID)
+
+	_, err = testQueries.ClaimDueRecurringTransaction(context.Background(), ClaimDueRecurringTransactionParams{
+		ID:    later.ID,
+		Today: today,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	claimed, err := testQueries.ClaimDueRecurringTransaction(context.Background(), ClaimDueRecurringTransactionParams{
+		ID:    due.ID,
+		Today: today,
+	})
+	require.NoError(t, err)
+	require.Equal(t, due.ID, claimed.ID)
+}
+
+func TestDueRecurringTransactionsSkipArchivedWallets(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	recurring := createRandomRecurringTransaction(t, today.AddDate(0, 0, -1))
+
+	wallet, err := testQueries.GetWallet(context.Background(), GetWalletParams{
+		ID:     recurring.WalletID,
+		UserID: recurring.UserID,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.UpdateWallet(context.Background(), UpdateWalletParams{
+		ID:             wallet.ID,
+		UserID:         wallet.UserID,
+		Name:           wallet.Name,
+		Kind:           wallet.Kind,
+		OpeningBalance: wallet.OpeningBalance,
+		ArchivedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	ids, err := testQueries.ListDueRecurringTransactions(context.Background(), today)
+	require.NoError(t, err)
+	require.NotContains(t, ids, recurring.ID)
+
+	_, err = testQueries.ClaimDueRecurringTransaction(context.Background(), ClaimDueRecurringTransactionParams{
+		ID:    recurring.ID,
+		Today: today,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRecurringExceptions(t *testing.T) {
+	start := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	recurring := createRandomRecurringTransaction(t, start)
+	skipped := start.AddDate(0, 1, 0)
+
+	for i := 0; i < 2; i++ {
+		err := testQueries.CreateRecurringException(context.Background(), CreateRecurringExceptionParams{
+			RecurringID: recurring.ID,
+			Date:        skipped,
+		})
+		require.NoError(t, err)
+	}
+
+	exceptions, err := testQueries.ListRecurringExceptions(context.Background(), recurring.ID)
+	require.NoError(t, err)
+	require.Len(t, exceptions, 1)
+	require.WithinDuration(t, skipped, exceptions[0], 0)
+
+	following := createRandomRecurringTransaction(t, skipped)
+	err = testQueries.CopyRecurringExceptions(context.Background(), CopyRecurringExceptionsParams{
+		NewRecurringID: following.ID,
+		RecurringID:    recurring.ID,
+		FromDate:       skipped,
+	})
+	require.NoError(t, err)
+
+	exceptions, err = testQueries.ListRecurringExceptions(context.Background(), following.ID)
+	require.NoError(t, err)
+	require.Len(t, exceptions, 1)
+}
+
+func TestCopyRecurringExceptionsFromFirstOccurrence(t *testing.T) {
+	start := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	recurring := createRandomRecurringTransaction(t, start)
+	skipped := start.AddDate(0, 2, 0)
+	err := testQueries.CreateRecurringException(context.Background(), CreateRecurringExceptionParams{
+		RecurringID: recurring.ID,
+		Date:        skipped,
+	})
+	require.NoError(t, err)
+
+	// Editing from the first occurrence replaces the whole series, so the
+	// exceptions are copied before the old one is deleted.
+	following := createRandomRecurringTransaction(t, start)
+	err = testQueries.CopyRecurringExceptions(context.Background(), CopyRecurringExceptionsParams{
+		NewRecurringID: following.ID,
+		RecurringID:    recurring.ID,
+		FromDate:       start,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.DeleteRecurringTransaction(context.Background(), DeleteRecurringTransactionParams{
+		ID:     recurring.ID,
+		UserID: recurring.UserID,
+	})
+	require.NoError(t, err)
+
+	exceptions, err := testQueries.ListRecurringExceptions(context.Background(), following.ID)
+	require.NoError(t, err)
+	require.Len(t, exceptions, 1)
+	require.WithinDuration(t, skipped, exceptions[0], 0)
+}
+
+func TestDeleteRecurringOccurrencesFrom(t *testing.T) {
+	start := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	recurring := createRandomRecurringTransaction(t, start)
+	for month := 0; month < 3; month++ {
+		_, err := testQueries.CreateRecurringOccurrence(context.Background(), recurringOccurrenceParams(recurring, start.AddDate(0, month, 0)))
+		require.NoError(t, err)
+	}
+
+	removed, err := testQueries.DeleteRecurringOccurrencesFrom(context.Background(), DeleteRecurringOccurrencesFromParams{
+		RecurringID:    recurring.ID,
+		OccurrenceDate: start.AddDate(0, 1, 0),
+	})
+	require.NoError(t, err)
+	require.Len(t, removed, 2)
+
+	removed, err = testQueries.DeleteRecurringOccurrence(context.Background(), DeleteRecurringOccurrenceParams{
+		RecurringID:    recurring.ID,
+		OccurrenceDate: start,
+	})
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+}
+
+func TestDeleteRecurringTransactionKeepsOccurrences(t *testing.T) {
+	start := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	recurring := createRandomRecurringTransaction(t, start)
+	account, err := testQueries.CreateRecurringOccurrence(context.Background(), recurringOccurrenceParams(recurring, start))
+	require.NoError(t, err)
+
+	deleted, err := testQueries.DeleteRecurringTransaction(context.Background(), DeleteRecurringTransactionParams{
+		ID:     recurring.ID,
+		UserID: recurring.UserID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	account, err = testQueries.GetAccount(context.Background(), GetAccountParams{ID: account.ID, UserID: account.UserID})
+	require.NoError(t, err)
+	require.False(t, account.RecurringID.Valid)
+}
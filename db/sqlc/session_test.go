@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T) Session {
+	user := createRandomUser(t)
+	arg := CreateSessionParams{
+		UserID:    user.ID,
+		UserAgent: util.RandomString(10),
+		ClientIp:  "127.0.0.1",
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.UserID, session.UserID)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.False(t, session.RevokedAt.Valid)
+	require.NotEmpty(t, session.CreatedAt)
+
+	return session
+}
+
+func createRandomRefreshToken(t *testing.T, session Session) RefreshToken {
+	arg := CreateRefreshTokenParams{
+		SessionID: session.ID,
+		TokenHash: util.HashToken(util.RandomString(32)),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	refreshToken, err := testQueries.CreateRefreshToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, refreshToken)
+
+	require.Equal(t, arg.SessionID, refreshToken.SessionID)
+	require.Equal(t, arg.TokenHash, refreshToken.TokenHash)
+	require.False(t, refreshToken.UsedAt.Valid)
+
+	return refreshToken
+}
+
+func TestCreateSession(t *testing.T) {
+	createRandomSession(t)
+}
+
+func TestRevokeSession(t *testing.T) {
+	session := createRandomSession(t)
+
+	rows, err := testQueries.RevokeSession(context.Background(), RevokeSessionParams{
+		ID:     session.ID,
+		UserID: session.UserID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	session2, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session2.RevokedAt.Valid)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	session := createRandomSession(t)
+	_, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+		UserID:    session.UserID,
+		UserAgent: util.RandomString(10),
+		ClientIp:  "127.0.0.1",
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.RevokeUserSessions(context.Background(), session.UserID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rows)
+}
+
+func TestGetRefreshToken(t *testing.T) {
+	session := createRandomSession(t)
+	refreshToken := createRandomRefreshToken(t, session)
+
+	row, err := testQueries.GetRefreshToken(context.Background(), refreshToken.TokenHash)
+	require.NoError(t, err)
+
+	require.Equal(t, refreshToken.ID, row.ID)
+	require.Equal(t, session.ID, row.SessionID)
+	require.Equal(t, session.UserID, row.UserID)
+	require.False(t, row.SessionRevokedAt.Valid)
+}
+
+func TestUseRefreshTokenOnlyOnce(t *testing.T) {
+	session := createRandomSession(t)
+	refreshToken := createRandomRefreshToken(t, session)
+
+	rows, err := testQueries.UseRefreshToken(context.Background(), refreshToken.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.UseRefreshToken(context.Background(), refreshToken.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestRevokeOtherUserSessions(t *testing.T) {
+	current := createRandomSession(t)
+	other, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+		UserID:    current.UserID,
+		UserAgent: util.RandomString(10),
+		ClientIp:  "127.0.0.1",
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.RevokeOtherUserSessions(context.Background(), RevokeOtherUserSessionsParams{
+		UserID: current.UserID,
+		ID:     current.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	session, err := testQueries.GetSession(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.True(t, session.RevokedAt.Valid)
+
+	session, err = testQueries.GetSession(context.Background(), current.ID)
+	require.NoError(t, err)
+	require.False(t, session.RevokedAt.Valid)
+}
+
+func TestListUserSessions(t *testing.T) {
+	session := createRandomSession(t)
+	_, err := testQueries.RevokeUserSessions(context.Background(), session.UserID)
+	require.NoError(t, err)
+
+	sessions, err := testQueries.ListUserSessions(context.Background(), session.UserID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, session.ID, sessions[0].ID)
+	require.True(t, sessions[0].RevokedAt.Valid)
+}
@@ -1,11 +1,33 @@
 package db
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
 
+	"github.com/lib/pq"
+)
+
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+
+	defaultTxAttempts = 3
+	txRetryBaseDelay  = 10 * time.Millisecond
+)
+
+// Store adds transactions to the generated queries. Handlers depend on
+// this interface rather than on SQLStore.
 type Store interface {
 	Querier
+	ExecTx(ctx context.Context, fn func(*Queries) error, opts ...TxOption) error
 }
 
+var _ Store = (*SQLStore)(nil)
+
 type SQLStore struct {
 	db *sql.DB
 	*Queries
@@ -17,3 +39,87 @@ func NewStore(db *sql.DB) *SQLStore {
 		Queries: New(db),
 	}
 }
+
+type txConfig struct {
+	isolation sql.IsolationLevel
+	attempts  int
+}
+
+type TxOption func(*txConfig)
+
+// WithIsolation runs the transaction with the given isolation level
+// instead of the database default.
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(config *txConfig) {
+		config.isolation = level
+	}
+}
+
+// WithAttempts sets how many times the transaction is tried in total when
+// it keeps failing with serialization failures or deadlocks. It is always
+// tried at least once.
+func WithAttempts(attempts int) TxOption {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(config *txConfig) {
+		config.attempts = attempts
+	}
+}
+
+// ExecTx runs fn inside a database transaction. The transaction is
+// committed when fn returns nil and rolled back otherwise. Serialization
+// failures and deadlocks are retried from scratch, so fn may run more than
+// once and must not have side effects outside the transaction.
+func (store *SQLStore) ExecTx(ctx context.Context, fn func(*Queries) error, opts ...TxOption) error {
+	config := txConfig{isolation: sql.LevelDefault, attempts: defaultTxAttempts}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	var err error
+	for attempt := 0; attempt < config.attempts; attempt++ {
+		if attempt > 0 {
+			delay := txRetryBaseDelay << (attempt - 1)
+			delay += time.Duration(rand.Int63n(int64(delay)))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		err = store.execTx(ctx, config.isolation, fn)
+		if !IsRetryableTxError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (store *SQLStore) execTx(ctx context.Context, isolation sql.IsolationLevel, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, &sql.TxOptions{Isolation: isolation})
+	if err != nil {
+		return err
+	}
+
+	err = fn(New(tx))
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsRetryableTxError reports whether err means the transaction lost a race
+// with another one and can simply be run again.
+func IsRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == serializationFailureCode || pqErr.Code == deadlockDetectedCode
+}
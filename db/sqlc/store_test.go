@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecTxRollsBackOnError(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	errStop := errors.New("stop")
+
+	err := store.ExecTx(context.Background(), func(q *Queries) error {
+		_, err := q.DeleteAccount(context.Background(), DeleteAccountParams{
+			ID:     account.ID,
+			UserID: account.UserID,
+		})
+		require.NoError(t, err)
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+
+	_, err = store.GetAccount(context.Background(), GetAccountParams{ID: account.ID, UserID: account.UserID})
+	require.NoError(t, err)
+}
+
+func TestExecTxCommits(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	err := store.ExecTx(context.Background(), func(q *Queries) error {
+		_, err := q.DeleteAccount(context.Background(), DeleteAccountParams{
+			ID:     account.ID,
+			UserID: account.UserID,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	_, err = store.GetAccount(context.Background(), GetAccountParams{ID: account.ID, UserID: account.UserID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestExecTxRetriesSerializationFailures(t *testing.T) {
+	store := NewStore(testDB)
+
+	attempts := 0
+	err := store.ExecTx(context.Background(), func(q *Queries) error {
+		attempts++
+		if attempts < 3 {
+			return &pq.Error{Code: serializationFailureCode}
+		}
+		return nil
+	}, WithIsolation(sql.LevelSerializable))
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = store.ExecTx(context.Background(), func(q *Queries) error {
+		attempts++
+		return &pq.Error{Code: deadlockDetectedCode}
+	}, WithAttempts(2))
+	require.True(t, IsRetryableTxError(err))
+	require.Equal(t, 2, attempts)
+
+	for _, n := range []int{0, -1} {
+		attempts = 0
+		err = store.ExecTx(context.Background(), func(q *Queries) error {
+			attempts++
+			return &pq.Error{Code: deadlockDetectedCode}
+		}, WithAttempts(n))
+		require.True(t, IsRetryableTxError(err))
+		require.Equal(t, 1, attempts)
+	}
+}
+
+func TestIsRetryableTxError(t *testing.T) {
+	require.True(t, IsRetryableTxError(&pq.Error{Code: serializationFailureCode}))
+	require.True(t, IsRetryableTxError(fmt.Errorf("wrapped: %w", &pq.Error{Code: deadlockDetectedCode})))
+	require.False(t, IsRetryableTxError(&pq.Error{Code: "23505"}))
+	require.False(t, IsRetryableTxError(sql.ErrNoRows))
+	require.False(t, IsRetryableTxError(nil))
+}
@@ -0,0 +1,319 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: transfer.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const createPosting = `-- name: CreatePosting :one
+INSERT INTO postings (
+  transfer_id,
+  wallet_id,
+  kind,
+  amount,
+  currency
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING id, transfer_id, wallet_id, kind, amount, currency
+`
+
+type CreatePostingParams struct {
+	TransferID int32         `json:"transfer_id"`
+	WalletID   sql.NullInt32 `json:"wallet_id"`
+	Kind       string        `json:"kind"`
+	Amount     int64         `json:"amount"`
+	Currency   string        `json:"currency"`
+}
+
+func (q *Queries) CreatePosting(ctx context.Context, arg CreatePostingParams) (Posting, error) {
+	row := q.db.QueryRowContext(ctx, createPosting,
+		arg.TransferID,
+		arg.WalletID,
+		arg.Kind,
+		arg.Amount,
+		arg.Currency,
+	)
+	var i Posting
+	err := row.Scan(
+		&i.ID,
+		&i.TransferID,
+		&i.WalletID,
+		&i.Kind,
+		&i.Amount,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const createTransfer = `-- name: CreateTransfer :one
+INSERT INTO transfers (
+  user_id,
+  description,
+  date,
+  rate
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, user_id, description, date, rate, created_at
+`
+
+type CreateTransferParams struct {
+	UserID      int32          `json:"user_id"`
+	Description string         `json:"description"`
+	Date        time.Time      `json:"date"`
+	Rate        sql.NullString `json:"rate"`
+}
+
+func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.UserID,
+		arg.Description,
+		arg.Date,
+		arg.Rate,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Description,
+		&i.Date,
+		&i.Rate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteTransfer = `-- name: DeleteTransfer :execrows
+DELETE FROM transfers
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteTransferParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteTransfer(ctx context.Context, arg DeleteTransferParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTransfer, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTransferPostings = `-- name: DeleteTransferPostings :exec
+DELETE FROM postings
+WHERE transfer_id = $1
+`
+
+func (q *Queries) DeleteTransferPostings(ctx context.Context, transferID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteTransferPostings, transferID)
+	return err
+}
+
+const getTransfer = `-- name: GetTransfer :one
+SELECT id, user_id, description, date, rate, created_at FROM transfers
+WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type GetTransferParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetTransfer(ctx context.Context, arg GetTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransfer, arg.ID, arg.UserID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Description,
+		&i.Date,
+		&i.Rate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, user_id, description, date, rate, created_at FROM transfers
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE
+`
+
+type GetTransferForUpdateParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, arg GetTransferForUpdateParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, arg.ID, arg.UserID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Description,
+		&i.Date,
+		&i.Rate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listTransferPostings = `-- name: ListTransferPostings :many
+SELECT id, transfer_id, wallet_id, kind, amount, currency FROM postings
+WHERE transfer_id = ANY($1::int[])
+ORDER BY transfer_id, id
+`
+
+func (q *Queries) ListTransferPostings(ctx context.Context, transferIds []int32) ([]Posting, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferPostings, pq.Array(transferIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Posting{}
+	for rows.Next() {
+		var i Posting
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransferID,
+			&i.WalletID,
+			&i.Kind,
+			&i.Amount,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfers = `-- name: ListTransfers :many
+SELECT id, user_id, description, date, rate, created_at FROM transfers
+WHERE user_id = $1
+ORDER BY date DESC, id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListTransfersParams struct {
+	UserID int32 `json:"user_id"`
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfers, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Description,
+			&i.Date,
+			&i.Rate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserTransfers = `-- name: ListUserTransfers :many
+SELECT id, user_id, description, date, rate, created_at FROM transfers
+WHERE user_id = $1
+ORDER BY date, id
+`
+
+func (q *Queries) ListUserTransfers(ctx context.Context, userID int32) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listUserTransfers, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Description,
+			&i.Date,
+			&i.Rate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTransfer = `-- name: UpdateTransfer :one
+UPDATE transfers
+SET description = $3, date = $4, rate = $5
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, description, date, rate, created_at
+`
+
+type UpdateTransferParams struct {
+	ID          int32          `json:"id"`
+	UserID      int32          `json:"user_id"`
+	Description string         `json:"description"`
+	Date        time.Time      `json:"date"`
+	Rate        sql.NullString `json:"rate"`
+}
+
+func (q *Queries) UpdateTransfer(ctx context.Context, arg UpdateTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, updateTransfer,
+		arg.ID,
+		arg.UserID,
+		arg.Description,
+		arg.Date,
+		arg.Rate,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Description,
+		&i.Date,
+		&i.Rate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
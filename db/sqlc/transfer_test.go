@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransfer(t *testing.T, from, to Wallet, amount int64, date time.Time) Transfer {
+	store := NewStore(testDB)
+
+	var transfer Transfer
+	err := store.ExecTx(context.Background(), func(q *Queries) error {
+		var err error
+		transfer, err = q.CreateTransfer(context.Background(), CreateTransferParams{
+			UserID:      from.UserID,
+			Description: util.RandomString(12),
+			Date:        date,
+		})
+		require.NoError(t, err)
+
+		for _, arg := range []CreatePostingParams{
+			{TransferID: transfer.ID, WalletID: sql.NullInt32{Int32: from.ID, Valid: true}, Kind: "source", Amount: -amount, Currency: from.Currency},
+			{TransferID: transfer.ID, WalletID: sql.NullInt32{Int32: to.ID, Valid: true}, Kind: "destination", Amount: amount, Currency: to.Currency},
+		} {
+			_, err = q.CreatePosting(context.Background(), arg)
+			require.NoError(t, err)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return transfer
+}
+
+func TestCreateTransfer(t *testing.T) {
+	user := createRandomUser(t)
+	from := createRandomWallet(t, user.ID)
+	to := createRandomWallet(t, user.ID)
+	date := time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC)
+
+	transfer := createRandomTransfer(t, from, to, 2500, date)
+
+	postings, err := testQueries.ListTransferPostings(context.Background(), []int32{transfer.ID})
+	require.NoError(t, err)
+	require.Len(t, postings, 2)
+	require.Equal(t, int64(-2500), postings[0].Amount)
+	require.Equal(t, int64(2500), postings[1].Amount)
+
+	for _, tc := range []struct {
+		wallet  Wallet
+		asOf    time.Time
+		balance int64
+	}{
+		{from, date.AddDate(0, 0, -1), 10000},
+		{to, date.AddDate(0, 0, -1), 10000},
+		{from, date, 7500},
+		{to, date, 12500},
+	} {
+		balance, err := testQueries.GetWalletBalance(context.Background(), GetWalletBalanceParams{
+			AsOf:   tc.asOf,
+			ID:     tc.wallet.ID,
+			UserID: user.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, tc.balance, balance)
+	}
+}
+
+func TestTransferPostingsMustBalance(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	from := createRandomWallet(t, user.ID)
+
+	var transferID int32
+	err := store.ExecTx(context.Background(), func(q *Queries) error {
+		transfer, err := q.CreateTransfer(context.Background(), CreateTransferParams{
+			UserID: user.ID,
+			Date:   time.Now(),
+		})
+		require.NoError(t, err)
+		transferID = transfer.ID
+
+		_, err = q.CreatePosting(context.Background(), CreatePostingParams{
+			TransferID: transfer.ID,
+			WalletID:   sql.NullInt32{Int32: from.ID, Valid: true},
+			Kind:       "source",
+			Amount:     -100,
+			Currency:   from.Currency,
+		})
+		require.NoError(t, err)
+		return nil
+	})
+	require.Error(t, err)
+
+	_, err = testQueries.GetTransfer(context.Background(), GetTransferParams{ID: transferID, UserID: user.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTransferPostingsBalancePerCurrency(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	from := createRandomWallet(t, user.ID)
+
+	err := store.ExecTx(context.Background(), func(q *Queries) error {
+		transfer, err := q.CreateTransfer(context.Background(), CreateTransferParams{
+			UserID: user.ID,
+			Date:   time.Now(),
+		})
+		require.NoError(t, err)
+
+		for _, arg := range []CreatePostingParams{
+			{TransferID: transfer.ID, WalletID: sql.NullInt32{Int32: from.ID, Valid: true}, Kind: "source", Amount: -100, Currency: "BRL"},
+			{TransferID: transfer.ID, Kind: "exchange", Amount: 100, Currency: "USD"},
+		} {
+			_, err = q.CreatePosting(context.Background(), arg)
+			require.NoError(t, err)
+		}
+		return nil
+	})
+	require.Error(t, err)
+}
+
+func TestPostingWalletMatchesKind(t *testing.T) {
+	user := createRandomUser(t)
+	wallet := createRandomWallet(t, user.ID)
+	transfer := createRandomTransfer(t, wallet, createRandomWallet(t, user.ID), 1, time.Now())
+
+	_, err := testQueries.CreatePosting(context.Background(), CreatePostingParams{
+		TransferID: transfer.ID,
+		Kind:       "source",
+		Amount:     0,
+		Currency:   "BRL",
+	})
+	require.Error(t, err)
+}
+
+func TestDeleteTransfer(t *testing.T) {
+	user := createRandomUser(t)
+	from := createRandomWallet(t, user.ID)
+	to := createRandomWallet(t, user.ID)
+	transfer := createRandomTransfer(t, from, to, 300, time.Now())
+
+	rows, err := testQueries.DeleteTransfer(context.Background(), DeleteTransferParams{
+		ID:     transfer.ID,
+		UserID: user.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	postings, err := testQueries.ListTransferPostings(context.Background(), []int32{transfer.ID})
+	require.NoError(t, err)
+	require.Empty(t, postings)
+
+	balance, err := testQueries.GetWalletBalance(context.Background(), GetWalletBalanceParams{
+		AsOf:   time.Now(),
+		ID:     from.ID,
+		UserID: user.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, from.OpeningBalance, balance)
+}
+
+func TestListTransfers(t *testing.T) {
+	user := createRandomUser(t)
+	from := createRandomWallet(t, user.ID)
+	to := createRandomWallet(t, user.ID)
+	for i := 0; i < 3; i++ {
+		createRandomTransfer(t, from, to, 100, time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC))
+	}
+
+	transfers, err := testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		UserID: user.ID,
+		Limit:  2,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.True(t, transfers[0].Date.After(transfers[1].Date))
+}
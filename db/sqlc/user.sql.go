@@ -7,8 +7,24 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :execrows
+UPDATE users
+SET deletion_scheduled_at = NULL
+WHERE id = $1 AND deletion_scheduled_at IS NOT NULL
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelUserDeletion, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
   username,
@@ -16,7 +32,7 @@ INSERT INTO users (
   email
 ) VALUES (
   $1, $2, $3
-) RETURNING id, username, password, email, created_at
+) RETURNING id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone
 `
 
 type CreateUserParams struct {
@@ -34,12 +50,82 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Password,
 		&i.Email,
 		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const disableUser = `-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE id = $1
+RETURNING id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone
+`
+
+func (q *Queries) DisableUser(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, disableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const enableUser = `-- name: EnableUser :one
+UPDATE users
+SET disabled_at = NULL
+WHERE id = $1
+RETURNING id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone
+`
+
+func (q *Queries) EnableUser(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, username, password, email, created_at FROM users
+SELECT id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -52,12 +138,50 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Password,
 		&i.Email,
 		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, username, password, email, created_at FROM users
+SELECT id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone FROM users
 WHERE id = $1 LIMIT 1
 `
 
@@ -70,6 +194,382 @@ func (q *Queries) GetUserById(ctx context.Context, id int32) (User, error) {
 		&i.Password,
 		&i.Email,
 		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
 	)
 	return i, err
 }
+
+const getUserUsageStats = `-- name: GetUserUsageStats :one
+SELECT
+  u.id AS user_id,
+  (SELECT count(*) FROM categories c WHERE c.user_id = u.id)::bigint AS categories,
+  (SELECT count(*) FROM accounts a WHERE a.user_id = u.id)::bigint AS accounts,
+  (SELECT count(*) FROM sessions s WHERE s.user_id = u.id AND s.revoked_at IS NULL)::bigint AS active_sessions,
+  (SELECT count(*) FROM personal_access_tokens t WHERE t.user_id = u.id AND t.revoked_at IS NULL)::bigint AS personal_access_tokens,
+  last_account.created_at AS last_account_at,
+  last_session.created_at AS last_login_at
+FROM users u
+LEFT JOIN accounts last_account ON last_account.id = (
+  SELECT a.id FROM accounts a WHERE a.user_id = u.id ORDER BY a.created_at DESC LIMIT 1
+)
+LEFT JOIN sessions last_session ON last_session.id = (
+  SELECT s.id FROM sessions s WHERE s.user_id = u.id ORDER BY s.created_at DESC LIMIT 1
+)
+WHERE u.id = $1
+`
+
+type GetUserUsageStatsRow struct {
+	UserID               int32        `json:"user_id"`
+	Categories           int64        `json:"categories"`
+	Accounts             int64        `json:"accounts"`
+	ActiveSessions       int64        `json:"active_sessions"`
+	PersonalAccessTokens int64        `json:"personal_access_tokens"`
+	LastAccountAt        sql.NullTime `json:"last_account_at"`
+	LastLoginAt          sql.NullTime `json:"last_login_at"`
+}
+
+func (q *Queries) GetUserUsageStats(ctx context.Context, id int32) (GetUserUsageStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserUsageStats, id)
+	var i GetUserUsageStatsRow
+	err := row.Scan(
+		&i.UserID,
+		&i.Categories,
+		&i.Accounts,
+		&i.ActiveSessions,
+		&i.PersonalAccessTokens,
+		&i.LastAccountAt,
+		&i.LastLoginAt,
+	)
+	return i, err
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone FROM users
+WHERE
+  ($1::text IS NULL OR username ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%')
+  AND ($2::varchar IS NULL OR role = $2)
+ORDER BY id
+LIMIT $4 OFFSET $3
+`
+
+type ListUsersParams struct {
+	Search     sql.NullString `json:"search"`
+	Role       sql.NullString `json:"role"`
+	PageOffset int32          `json:"page_offset"`
+	PageLimit  int32          `json:"page_limit"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers,
+		arg.Search,
+		arg.Role,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Password,
+			&i.Email,
+			&i.CreatedAt,
+			&i.EmailVerifiedAt,
+			&i.FailedLoginAttempts,
+			&i.LockedUntil,
+			&i.DeletionScheduledAt,
+			&i.Role,
+			&i.DisabledAt,
+			&i.PasswordResetRequired,
+			&i.BaseCurrency,
+			&i.EnvelopeStart,
+			&i.Timezone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deletion_scheduled_at <= now()
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedUsers)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recordUserLoginFailure = `-- name: RecordUserLoginFailure :one
+UPDATE users
+SET
+  failed_login_attempts = failed_login_attempts + 1,
+  locked_until = CASE
+    WHEN failed_login_attempts + 1 >= $1::int THEN $2::timestamptz
+    ELSE locked_until
+  END
+WHERE id = $3
+RETURNING failed_login_attempts, locked_until
+`
+
+type RecordUserLoginFailureParams struct {
+	MaxFailures int32     `json:"max_failures"`
+	LockedUntil time.Time `json:"locked_until"`
+	ID          int32     `json:"id"`
+}
+
+type RecordUserLoginFailureRow struct {
+	FailedLoginAttempts int32        `json:"failed_login_attempts"`
+	LockedUntil         sql.NullTime `json:"locked_until"`
+}
+
+func (q *Queries) RecordUserLoginFailure(ctx context.Context, arg RecordUserLoginFailureParams) (RecordUserLoginFailureRow, error) {
+	row := q.db.QueryRowContext(ctx, recordUserLoginFailure, arg.MaxFailures, arg.LockedUntil, arg.ID)
+	var i RecordUserLoginFailureRow
+	err := row.Scan(&i.FailedLoginAttempts, &i.LockedUntil)
+	return i, err
+}
+
+const requireUserPasswordReset = `-- name: RequireUserPasswordReset :one
+UPDATE users
+SET password_reset_required = true
+WHERE id = $1
+RETURNING id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone
+`
+
+func (q *Queries) RequireUserPasswordReset(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, requireUserPasswordReset, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const resetUserLoginFailures = `-- name: ResetUserLoginFailures :exec
+UPDATE users
+SET failed_login_attempts = 0, locked_until = NULL
+WHERE id = $1
+`
+
+func (q *Queries) ResetUserLoginFailures(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, resetUserLoginFailures, id)
+	return err
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = $2::timestamptz
+WHERE id = $1
+RETURNING id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone
+`
+
+type ScheduleUserDeletionParams struct {
+	ID                  int32     `json:"id"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, scheduleUserDeletion, arg.ID, arg.DeletionScheduledAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const setUserEnvelopeStart = `-- name: SetUserEnvelopeStart :one
+UPDATE users
+SET envelope_start = $1
+WHERE id = $2
+RETURNING id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone
+`
+
+type SetUserEnvelopeStartParams struct {
+	EnvelopeStart sql.NullTime `json:"envelope_start"`
+	ID            int32        `json:"id"`
+}
+
+func (q *Queries) SetUserEnvelopeStart(ctx context.Context, arg SetUserEnvelopeStartParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserEnvelopeStart, arg.EnvelopeStart, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2, password_reset_required = false
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID       int32  `json:"id"`
+	Password string `json:"password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.Password)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET username = $2, email = $3, email_verified_at = $4, base_currency = $5, timezone = $6
+WHERE id = $1
+RETURNING id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone
+`
+
+type UpdateUserProfileParams struct {
+	ID              int32        `json:"id"`
+	Username        string       `json:"username"`
+	Email           string       `json:"email"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+	BaseCurrency    string       `json:"base_currency"`
+	Timezone        string       `json:"timezone"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.ID,
+		arg.Username,
+		arg.Email,
+		arg.EmailVerifiedAt,
+		arg.BaseCurrency,
+		arg.Timezone,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING id, username, password, email, created_at, email_verified_at, failed_login_attempts, locked_until, deletion_scheduled_at, role, disabled_at, password_reset_required, base_currency, envelope_start, timezone
+`
+
+type UpdateUserRoleParams struct {
+	ID   int32  `json:"id"`
+	Role string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.DeletionScheduledAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.PasswordResetRequired,
+		&i.BaseCurrency,
+		&i.EnvelopeStart,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :exec
+UPDATE users
+SET email_verified_at = now()
+WHERE id = $1 AND email_verified_at IS NULL
+`
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, verifyUserEmail, id)
+	return err
+}
@@ -2,7 +2,9 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/GustavoNoronha0/gofinance-backend/util"
 	"github.com/stretchr/testify/require"
@@ -22,6 +24,7 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, arg.Username, user.Username)
 	require.Equal(t, arg.Password, user.Password)
 	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, "BRL", user.BaseCurrency)
 	require.NotEmpty(t, user.CreatedAt)
 
 	return user
@@ -54,3 +57,221 @@ func TestGetUserById(t *testing.T) {
 	require.Equal(t, user1.Email, user2.Email)
 	require.NotEmpty(t, user2.CreatedAt)
 }
+
+func TestGetUserByEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2, err := testQueries.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+
+	require.Equal(t, user1.ID, user2.ID)
+	require.Equal(t, user1.Username, user2.Username)
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	user1 := createRandomUser(t)
+	arg := UpdateUserPasswordParams{
+		ID:       user1.ID,
+		Password: util.RandomString(12),
+	}
+
+	err := testQueries.UpdateUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+
+	user2, err := testQueries.GetUserById(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.Password, user2.Password)
+}
+
+func TestVerifyUserEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+	require.False(t, user1.EmailVerifiedAt.Valid)
+
+	err := testQueries.VerifyUserEmail(context.Background(), user1.ID)
+	require.NoError(t, err)
+
+	user2, err := testQueries.GetUserById(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.True(t, user2.EmailVerifiedAt.Valid)
+}
+
+func TestRecordUserLoginFailureLocksAccount(t *testing.T) {
+	user := createRandomUser(t)
+	arg := RecordUserLoginFailureParams{
+		ID:          user.ID,
+		MaxFailures: 2,
+		LockedUntil: time.Now().Add(time.Minute),
+	}
+
+	row, err := testQueries.RecordUserLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), row.FailedLoginAttempts)
+	require.False(t, row.LockedUntil.Valid)
+
+	row, err = testQueries.RecordUserLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), row.FailedLoginAttempts)
+	require.True(t, row.LockedUntil.Valid)
+
+	err = testQueries.ResetUserLoginFailures(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	user2, err := testQueries.GetUserById(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Zero(t, user2.FailedLoginAttempts)
+	require.False(t, user2.LockedUntil.Valid)
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	user1 := createRandomUser(t)
+	arg := UpdateUserProfileParams{
+		ID:           user1.ID,
+		Username:     util.RandomString(6),
+		Email:        util.RandomEmail(8),
+		BaseCurrency: "EUR",
+		Timezone:     "America/Sao_Paulo",
+	}
+	require.Equal(t, "UTC", user1.Timezone)
+
+	user2, err := testQueries.UpdateUserProfile(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, user1.ID, user2.ID)
+	require.Equal(t, arg.Username, user2.Username)
+	require.Equal(t, arg.Email, user2.Email)
+	require.Equal(t, arg.BaseCurrency, user2.BaseCurrency)
+	require.Equal(t, arg.Timezone, user2.Timezone)
+	require.False(t, user2.EmailVerifiedAt.Valid)
+	require.Equal(t, user1.Password, user2.Password)
+}
+
+func TestUpdateUserProfileDuplicateUsername(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+
+	_, err := testQueries.UpdateUserProfile(context.Background(), UpdateUserProfileParams{
+		ID:           user2.ID,
+		Username:     user1.Username,
+		Email:        user2.Email,
+		BaseCurrency: user2.BaseCurrency,
+		Timezone:     user2.Timezone,
+	})
+	require.Error(t, err)
+}
+
+func TestCancelUserDeletion(t *testing.T) {
+	user := createRandomUser(t)
+
+	scheduled, err := testQueries.ScheduleUserDeletion(context.Background(), ScheduleUserDeletionParams{
+		ID:                  user.ID,
+		DeletionScheduledAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.True(t, scheduled.DeletionScheduledAt.Valid)
+
+	rows, err := testQueries.CancelUserDeletion(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.CancelUserDeletion(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	account := createRandomAccount(t)
+	pending := createRandomUser(t)
+
+	_, err := testQueries.ScheduleUserDeletion(context.Background(), ScheduleUserDeletionParams{
+		ID:                  account.UserID,
+		DeletionScheduledAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.ScheduleUserDeletion(context.Background(), ScheduleUserDeletionParams{
+		ID:                  pending.ID,
+		DeletionScheduledAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	purged, err := testQueries.PurgeDeletedUsers(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, purged, int64(1))
+
+	_, err = testQueries.GetUserById(context.Background(), account.UserID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.GetCategory(context.Background(), GetCategoryParams{ID: account.CategoryID, UserID: account.UserID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.GetUserById(context.Background(), pending.ID)
+	require.NoError(t, err)
+}
+
+func TestListUsers(t *testing.T) {
+	user := createRandomUser(t)
+
+	users, err := testQueries.ListUsers(context.Background(), ListUsersParams{
+		Search:    sql.NullString{String: user.Username, Valid: true},
+		Role:      sql.NullString{String: "user", Valid: true},
+		PageLimit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, user.ID, users[0].ID)
+	require.Equal(t, "user", users[0].Role)
+
+	users, err = testQueries.ListUsers(context.Background(), ListUsersParams{
+		Search:    sql.NullString{String: user.Username, Valid: true},
+		Role:      sql.NullString{String: "admin", Valid: true},
+		PageLimit: 5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, users)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	user := createRandomUser(t)
+
+	updated, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{ID: user.ID, Role: "support-readonly"})
+	require.NoError(t, err)
+	require.Equal(t, "support-readonly", updated.Role)
+
+	_, err = testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{ID: user.ID, Role: "root"})
+	require.Error(t, err)
+}
+
+func TestDisableAndEnableUser(t *testing.T) {
+	user := createRandomUser(t)
+
+	disabled, err := testQueries.DisableUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.True(t, disabled.DisabledAt.Valid)
+
+	enabled, err := testQueries.EnableUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.False(t, enabled.DisabledAt.Valid)
+}
+
+func TestRequireUserPasswordReset(t *testing.T) {
+	user := createRandomUser(t)
+
+	updated, err := testQueries.RequireUserPasswordReset(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.True(t, updated.PasswordResetRequired)
+
+	err = testQueries.UpdateUserPassword(context.Background(), UpdateUserPasswordParams{ID: user.ID, Password: user.Password})
+	require.NoError(t, err)
+
+	updated, err = testQueries.GetUserById(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.False(t, updated.PasswordResetRequired)
+}
+
+func TestGetUserUsageStats(t *testing.T) {
+	account := createRandomAccount(t)
+
+	stats, err := testQueries.GetUserUsageStats(context.Background(), account.UserID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), stats.Categories)
+	require.Equal(t, int64(1), stats.Accounts)
+	require.True(t, stats.LastAccountAt.Valid)
+	require.False(t, stats.LastLoginAt.Valid)
+}
@@ -0,0 +1,104 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: user_token.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createUserToken = `-- name: CreateUserToken :one
+INSERT INTO user_tokens (
+  user_id,
+  purpose,
+  token_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at
+`
+
+type CreateUserTokenParams struct {
+	UserID    int32     `json:"user_id"`
+	Purpose   string    `json:"purpose"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateUserToken(ctx context.Context, arg CreateUserTokenParams) (UserToken, error) {
+	row := q.db.QueryRowContext(ctx, createUserToken,
+		arg.UserID,
+		arg.Purpose,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i UserToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Purpose,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserToken = `-- name: GetUserToken :one
+SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at FROM user_tokens
+WHERE token_hash = $1 AND purpose = $2 LIMIT 1
+`
+
+type GetUserTokenParams struct {
+	TokenHash string `json:"token_hash"`
+	Purpose   string `json:"purpose"`
+}
+
+func (q *Queries) GetUserToken(ctx context.Context, arg GetUserTokenParams) (UserToken, error) {
+	row := q.db.QueryRowContext(ctx, getUserToken, arg.TokenHash, arg.Purpose)
+	var i UserToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Purpose,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const invalidateUserTokens = `-- name: InvalidateUserTokens :exec
+UPDATE user_tokens
+SET used_at = now()
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+`
+
+type InvalidateUserTokensParams struct {
+	UserID  int32  `json:"user_id"`
+	Purpose string `json:"purpose"`
+}
+
+func (q *Queries) InvalidateUserTokens(ctx context.Context, arg InvalidateUserTokensParams) error {
+	_, err := q.db.ExecContext(ctx, invalidateUserTokens, arg.UserID, arg.Purpose)
+	return err
+}
+
+const useUserToken = `-- name: UseUserToken :execrows
+UPDATE user_tokens
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL AND expires_at > now()
+`
+
+func (q *Queries) UseUserToken(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useUserToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUserToken(t *testing.T, purpose string, expiresAt time.Time) UserToken {
+	user := createRandomUser(t)
+	arg := CreateUserTokenParams{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: util.HashToken(util.RandomString(32)),
+		ExpiresAt: expiresAt,
+	}
+
+	userToken, err := testQueries.CreateUserToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, userToken)
+
+	require.Equal(t, arg.UserID, userToken.UserID)
+	require.Equal(t, arg.Purpose, userToken.Purpose)
+	require.Equal(t, arg.TokenHash, userToken.TokenHash)
+	require.False(t, userToken.UsedAt.Valid)
+	require.NotEmpty(t, userToken.CreatedAt)
+
+	return userToken
+}
+
+func TestCreateUserToken(t *testing.T) {
+	createRandomUserToken(t, "password_reset", time.Now().Add(time.Hour))
+}
+
+func TestGetUserTokenWithOtherPurpose(t *testing.T) {
+	userToken := createRandomUserToken(t, "password_reset", time.Now().Add(time.Hour))
+
+	_, err := testQueries.GetUserToken(context.Background(), GetUserTokenParams{
+		TokenHash: userToken.TokenHash,
+		Purpose:   "email_verification",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUseUserTokenOnlyOnce(t *testing.T) {
+	userToken := createRandomUserToken(t, "password_reset", time.Now().Add(time.Hour))
+
+	rows, err := testQueries.UseUserToken(context.Background(), userToken.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.UseUserToken(context.Background(), userToken.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestUseExpiredUserToken(t *testing.T) {
+	userToken := createRandomUserToken(t, "password_reset", time.Now().Add(-time.Minute))
+
+	rows, err := testQueries.UseUserToken(context.Background(), userToken.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestInvalidateUserTokens(t *testing.T) {
+	userToken := createRandomUserToken(t, "password_reset", time.Now().Add(time.Hour))
+
+	err := testQueries.InvalidateUserTokens(context.Background(), InvalidateUserTokensParams{
+		UserID:  userToken.UserID,
+		Purpose: userToken.Purpose,
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.UseUserToken(context.Background(), userToken.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
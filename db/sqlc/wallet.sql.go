@@ -0,0 +1,311 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: wallet.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createWallet = `-- name: CreateWallet :one
+INSERT INTO wallets (
+  user_id,
+  name,
+  kind,
+  currency,
+  opening_balance
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING id, user_id, name, kind, currency, opening_balance, archived_at, created_at
+`
+
+type CreateWalletParams struct {
+	UserID         int32  `json:"user_id"`
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`
+	Currency       string `json:"currency"`
+	OpeningBalance int64  `json:"opening_balance"`
+}
+
+func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) (Wallet, error) {
+	row := q.db.QueryRowContext(ctx, createWallet,
+		arg.UserID,
+		arg.Name,
+		arg.Kind,
+		arg.Currency,
+		arg.OpeningBalance,
+	)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Kind,
+		&i.Currency,
+		&i.OpeningBalance,
+		&i.ArchivedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteWallet = `-- name: DeleteWallet :execrows
+DELETE FROM wallets
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteWalletParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteWallet(ctx context.Context, arg DeleteWalletParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWallet, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getWallet = `-- name: GetWallet :one
+SELECT id, user_id, name, kind, currency, opening_balance, archived_at, created_at FROM wallets
+WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type GetWalletParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetWallet(ctx context.Context, arg GetWalletParams) (Wallet, error) {
+	row := q.db.QueryRowContext(ctx, getWallet, arg.ID, arg.UserID)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Kind,
+		&i.Currency,
+		&i.OpeningBalance,
+		&i.ArchivedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWalletBalance = `-- name: GetWalletBalance :one
+SELECT
+  (w.opening_balance
+  + COALESCE((
+    SELECT SUM(CASE a.type WHEN 'credit' THEN a.amount WHEN 'debit' THEN -a.amount ELSE 0 END)
+    FROM accounts a
+    WHERE a.wallet_id = w.id AND a.date <= $1::date
+  ), 0)
+  + COALESCE((
+    SELECT SUM(p.amount)
+    FROM postings p
+    JOIN transfers t ON t.id = p.transfer_id
+    WHERE p.wallet_id = w.id AND t.date <= $1::date
+  ), 0))::bigint AS balance
+FROM
+  wallets w
+WHERE
+  w.id = $2 AND w.user_id = $3
+`
+
+type GetWalletBalanceParams struct {
+	AsOf   time.Time `json:"as_of"`
+	ID     int32     `json:"id"`
+	UserID int32     `json:"user_id"`
+}
+
+func (q *Queries) GetWalletBalance(ctx context.Context, arg GetWalletBalanceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getWalletBalance, arg.AsOf, arg.ID, arg.UserID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
+const getWalletForUpdate = `-- name: GetWalletForUpdate :one
+SELECT id, user_id, name, kind, currency, opening_balance, archived_at, created_at FROM wallets
+WHERE id = $1 AND user_id = $2 LIMIT 1
+FOR UPDATE
+`
+
+type GetWalletForUpdateParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetWalletForUpdate(ctx context.Context, arg GetWalletForUpdateParams) (Wallet, error) {
+	row := q.db.QueryRowContext(ctx, getWalletForUpdate, arg.ID, arg.UserID)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Kind,
+		&i.Currency,
+		&i.OpeningBalance,
+		&i.ArchivedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listUserWallets = `-- name: ListUserWallets :many
+SELECT id, user_id, name, kind, currency, opening_balance, archived_at, created_at FROM wallets
+WHERE user_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListUserWallets(ctx context.Context, userID int32) ([]Wallet, error) {
+	rows, err := q.db.QueryContext(ctx, listUserWallets, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Wallet{}
+	for rows.Next() {
+		var i Wallet
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Kind,
+			&i.Currency,
+			&i.OpeningBalance,
+			&i.ArchivedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWallets = `-- name: ListWallets :many
+SELECT
+  w.id, w.user_id, w.name, w.kind, w.currency, w.opening_balance, w.archived_at, w.created_at,
+  (w.opening_balance
+  + COALESCE((
+    SELECT SUM(CASE a.type WHEN 'credit' THEN a.amount WHEN 'debit' THEN -a.amount ELSE 0 END)
+    FROM accounts a
+    WHERE a.wallet_id = w.id AND a.date <= $1::date
+  ), 0)
+  + COALESCE((
+    SELECT SUM(p.amount)
+    FROM postings p
+    JOIN transfers t ON t.id = p.transfer_id
+    WHERE p.wallet_id = w.id AND t.date <= $1::date
+  ), 0))::bigint AS balance
+FROM
+  wallets w
+WHERE
+  w.user_id = $2
+AND
+  ($3::boolean OR w.archived_at IS NULL)
+ORDER BY
+  w.name, w.id
+`
+
+type ListWalletsParams struct {
+	AsOf            time.Time `json:"as_of"`
+	UserID          int32     `json:"user_id"`
+	IncludeArchived bool      `json:"include_archived"`
+}
+
+type ListWalletsRow struct {
+	ID             int32        `json:"id"`
+	UserID         int32        `json:"user_id"`
+	Name           string       `json:"name"`
+	Kind           string       `json:"kind"`
+	Currency       string       `json:"currency"`
+	OpeningBalance int64        `json:"opening_balance"`
+	ArchivedAt     sql.NullTime `json:"archived_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	Balance        int64        `json:"balance"`
+}
+
+func (q *Queries) ListWallets(ctx context.Context, arg ListWalletsParams) ([]ListWalletsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listWallets, arg.AsOf, arg.UserID, arg.IncludeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListWalletsRow{}
+	for rows.Next() {
+		var i ListWalletsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Kind,
+			&i.Currency,
+			&i.OpeningBalance,
+			&i.ArchivedAt,
+			&i.CreatedAt,
+			&i.Balance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateWallet = `-- name: UpdateWallet :one
+UPDATE wallets
+SET name = $3, kind = $4, opening_balance = $5, archived_at = $6
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, name, kind, currency, opening_balance, archived_at, created_at
+`
+
+type UpdateWalletParams struct {
+	ID             int32        `json:"id"`
+	UserID         int32        `json:"user_id"`
+	Name           string       `json:"name"`
+	Kind           string       `json:"kind"`
+	OpeningBalance int64        `json:"opening_balance"`
+	ArchivedAt     sql.NullTime `json:"archived_at"`
+}
+
+func (q *Queries) UpdateWallet(ctx context.Context, arg UpdateWalletParams) (Wallet, error) {
+	row := q.db.QueryRowContext(ctx, updateWallet,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.Kind,
+		arg.OpeningBalance,
+		arg.ArchivedAt,
+	)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Kind,
+		&i.Currency,
+		&i.OpeningBalance,
+		&i.ArchivedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWallet(t *testing.T, userID int32) Wallet {
+	arg := CreateWalletParams{
+		UserID:         userID,
+		Name:           util.RandomString(10),
+		Kind:           "checking",
+		Currency:       "BRL",
+		OpeningBalance: 10000,
+	}
+
+	wallet, err := testQueries.CreateWallet(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, wallet)
+
+	require.Equal(t, arg.UserID, wallet.UserID)
+	require.Equal(t, arg.Name, wallet.Name)
+	require.Equal(t, arg.Kind, wallet.Kind)
+	require.Equal(t, arg.Currency, wallet.Currency)
+	require.Equal(t, arg.OpeningBalance, wallet.OpeningBalance)
+	require.False(t, wallet.ArchivedAt.Valid)
+	require.NotEmpty(t, wallet.CreatedAt)
+
+	return wallet
+}
+
+func TestCreateWallet(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomWallet(t, user.ID)
+}
+
+func TestCreateWalletInvalidKind(t *testing.T) {
+	user := createRandomUser(t)
+	_, err := testQueries.CreateWallet(context.Background(), CreateWalletParams{
+		UserID:   user.ID,
+		Name:     util.RandomString(10),
+		Kind:     "piggy_bank",
+		Currency: "BRL",
+	})
+	require.Error(t, err)
+}
+
+func TestGetWallet(t *testing.T) {
+	user := createRandomUser(t)
+	wallet1 := createRandomWallet(t, user.ID)
+
+	wallet2, err := testQueries.GetWallet(context.Background(), GetWalletParams{
+		ID:     wallet1.ID,
+		UserID: user.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, wallet1, wallet2)
+
+	other := createRandomUser(t)
+	_, err = testQueries.GetWallet(context.Background(), GetWalletParams{
+		ID:     wallet1.ID,
+		UserID: other.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpdateWallet(t *testing.T) {
+	user := createRandomUser(t)
+	wallet1 := createRandomWallet(t, user.ID)
+
+	arg := UpdateWalletParams{
+		ID:             wallet1.ID,
+		UserID:         user.ID,
+		Name:           util.RandomString(10),
+		Kind:           "credit_card",
+		OpeningBalance: -5000,
+		ArchivedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	wallet2, err := testQueries.UpdateWallet(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Name, wallet2.Name)
+	require.Equal(t, arg.Kind, wallet2.Kind)
+	require.Equal(t, arg.OpeningBalance, wallet2.OpeningBalance)
+	require.True(t, wallet2.ArchivedAt.Valid)
+	require.Equal(t, wallet1.Currency, wallet2.Currency)
+}
+
+func TestDeleteWallet(t *testing.T) {
+	user := createRandomUser(t)
+	wallet := createRandomWallet(t, user.ID)
+
+	rows, err := testQueries.DeleteWallet(context.Background(), DeleteWalletParams{
+		ID:     wallet.ID,
+		UserID: user.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
+
+func TestDeleteWalletWithTransactions(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := testQueries.DeleteWallet(context.Background(), DeleteWalletParams{
+		ID:     account.WalletID,
+		UserID: account.UserID,
+	})
+	require.Error(t, err)
+}
+
+func TestWalletBalance(t *testing.T) {
+	category := createRandomCategory(t)
+	wallet := createRandomWallet(t, category.UserID)
+	day := func(d int) time.Time {
+		return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	for _, entry := range []struct {
+		accountType string
+		amount      int64
+		date        time.Time
+	}{
+		{"credit", 250000, day(1)},
+		{"debit", 1999, day(2)},
+		{"debit", 1, day(2)},
+		{"credit", 700, day(5)},
+	} {
+		_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			UserID:      category.UserID,
+			WalletID:    wallet.ID,
+			CategoryID:  category.ID,
+			Title:       util.RandomString(12),
+			Type:        entry.accountType,
+			Description: util.RandomString(20),
+			Amount:      entry.amount,
+			Currency:    wallet.Currency,
+			Date:        entry.date,
+		})
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		asOf    time.Time
+		balance int64
+	}{
+		{day(1).AddDate(0, 0, -1), 10000},
+		{day(1), 260000},
+		{day(2), 258000},
+		{day(4), 258000},
+		{day(5), 258700},
+	}
+	for _, tc := range testCases {
+		balance, err := testQueries.GetWalletBalance(context.Background(), GetWalletBalanceParams{
+			AsOf:   tc.asOf,
+			ID:     wallet.ID,
+			UserID: category.UserID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, tc.balance, balance)
+	}
+
+	wallets, err := testQueries.ListWallets(context.Background(), ListWalletsParams{
+		AsOf:   day(2),
+		UserID: category.UserID,
+	})
+	require.NoError(t, err)
+	require.Len(t, wallets, 1)
+	require.Equal(t, wallet.ID, wallets[0].ID)
+	require.Equal(t, int64(258000), wallets[0].Balance)
+}
+
+func TestListWalletsArchived(t *testing.T) {
+	user := createRandomUser(t)
+	active := createRandomWallet(t, user.ID)
+	archived := createRandomWallet(t, user.ID)
+
+	_, err := testQueries.UpdateWallet(context.Background(), UpdateWalletParams{
+		ID:             archived.ID,
+		UserID:         user.ID,
+		Name:           archived.Name,
+		Kind:           archived.Kind,
+		OpeningBalance: archived.OpeningBalance,
+		ArchivedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	wallets, err := testQueries.ListWallets(context.Background(), ListWalletsParams{
+		AsOf:   time.Now(),
+		UserID: user.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, wallets, 1)
+	require.Equal(t, active.ID, wallets[0].ID)
+	require.Equal(t, active.OpeningBalance, wallets[0].Balance)
+
+	wallets, err = testQueries.ListWallets(context.Background(), ListWalletsParams{
+		AsOf:            time.Now(),
+		UserID:          user.ID,
+		IncludeArchived: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, wallets, 2)
+}
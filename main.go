@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
-	"os"
+	_ "time/tzdata"
 
 	"github.com/GustavoNoronha0/gofinance-backend/api"
 	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+	"github.com/GustavoNoronha0/gofinance-backend/util"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -17,18 +19,26 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
-	dbDriver := os.Getenv("DB_DRIVER")
-	dbSource := os.Getenv("DB_SOURCE")
-	serverAddress := os.Getenv("SERVER_ADDRESS")
-	conn, err := sql.Open(dbDriver, dbSource)
+	config, err := util.LoadConfig()
+	if err != nil {
+		log.Fatal("cannot load config: ", err)
+	}
+
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
 	if err != nil {
 		log.Fatal("cannot connect to db: ", err)
 	}
 
 	store := db.NewStore(conn)
-	server := api.NewServer(store)
+	server, err := api.NewServer(config, store)
+	if err != nil {
+		log.Fatal("cannot create server: ", err)
+	}
+
+	go server.RunDeletionPurger(context.Background())
+	go server.RunRecurringScheduler(context.Background())
 
-	err = server.Start(serverAddress)
+	err = server.Start(config.ServerAddress)
 	if err != nil {
 		log.Fatal("cannot start api: ", err)
 	}
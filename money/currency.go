@@ -0,0 +1,52 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// exponents maps ISO 4217 currency codes to the number of digits after the
+// decimal separator of their minor unit.
+var exponents = map[string]int{}
+
+func init() {
+	byExponent := map[int]string{
+		0: "BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF",
+		2: "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BRL BSD BTN BWP BYN BZD " +
+			"CAD CDF CHF CNY COP CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD " +
+			"GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL MAD " +
+			"MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK PHP " +
+			"PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS " +
+			"TMT TOP TRY TTD TWD TZS UAH USD UYU UZS VES WST XCD YER ZAR ZMW ZWL",
+		3: "BHD IQD JOD KWD LYD OMR TND",
+		4: "CLF UYW",
+	}
+	for exponent, codes := range byExponent {
+		for _, code := range strings.Fields(codes) {
+			exponents[code] = exponent
+		}
+	}
+}
+
+type UnknownCurrencyError struct {
+	Code string
+}
+
+func (err UnknownCurrencyError) Error() string {
+	return fmt.Sprintf("unknown currency %q", err.Code)
+}
+
+// Exponent returns the number of minor unit digits of the currency.
+func Exponent(currency string) (int, error) {
+	exponent, ok := exponents[currency]
+	if !ok {
+		return 0, UnknownCurrencyError{Code: currency}
+	}
+	return exponent, nil
+}
+
+// IsCurrency reports whether code is a known ISO 4217 currency code.
+func IsCurrency(code string) bool {
+	_, ok := exponents[code]
+	return ok
+}
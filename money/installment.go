@@ -0,0 +1,91 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrInvalidInterest     = errors.New("interest rate must be a decimal number of zero or more")
+	ErrInvalidInstallments = errors.New("number of installments must be at least one")
+)
+
+// ParseInterest reads a monthly interest rate given as a percentage, so
+// "1.99" is 1.99% a month. An empty value means no interest.
+func ParseInterest(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return new(big.Rat), nil
+	}
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() < 0 || strings.ContainsAny(value, "/eE") {
+		return nil, ErrInvalidInterest
+	}
+	return rate.Quo(rate, big.NewRat(100, 1)), nil
+}
+
+// Split divides m into n parts of the same amount, putting the minor units
+// left over on the first part or on the last one.
+func (m Money) Split(n int, remainderFirst bool) ([]Money, error) {
+	if n < 1 {
+		return nil, ErrInvalidInstallments
+	}
+
+	part := m.Amount / int64(n)
+	remainder := m.Amount % int64(n)
+	parts := make([]Money, n)
+	for i := range parts {
+		parts[i] = Money{Amount: part, Currency: m.Currency}
+	}
+	if remainderFirst {
+		parts[0].Amount += remainder
+	} else {
+		parts[n-1].Amount += remainder
+	}
+	return parts, nil
+}
+
+// InstallmentTotal is what n fixed monthly installments of a purchase of
+// principal add up to at rate interest a month, following the Price table
+// (French amortisation) card issuers use. Without interest it's principal.
+func InstallmentTotal(principal Money, n int, rate *big.Rat) (Money, error) {
+	if n < 1 {
+		return Money{}, ErrInvalidInstallments
+	}
+	if rate.Sign() == 0 {
+		return principal, nil
+	}
+
+	// payment = principal * rate / (1 - (1 + rate)^-n)
+	growth := power(new(big.Rat).Add(big.NewRat(1, 1), rate), n)
+	discount := new(big.Rat).Sub(big.NewRat(1, 1), new(big.Rat).Inv(growth))
+	payment := new(big.Rat).Mul(new(big.Rat).SetInt64(principal.Amount), rate)
+	payment.Quo(payment, discount)
+
+	total := roundHalfAwayFromZero(payment.Mul(payment, big.NewRat(int64(n), 1)))
+	if !total.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: total.Int64(), Currency: principal.Currency}, nil
+}
+
+// Discount brings m due months from now back to its value today at rate
+// interest a month, which is what paying an installment early costs.
+func Discount(m Money, rate *big.Rat, months int) Money {
+	if months <= 0 || rate.Sign() == 0 {
+		return m
+	}
+	growth := power(new(big.Rat).Add(big.NewRat(1, 1), rate), months)
+	value := new(big.Rat).Quo(new(big.Rat).SetInt64(m.Amount), growth)
+	// Smaller than m, so it always fits.
+	return Money{Amount: roundHalfAwayFromZero(value).Int64(), Currency: m.Currency}
+}
+
+func power(base *big.Rat, exponent int) *big.Rat {
+	result := big.NewRat(1, 1)
+	for i := 0; i < exponent; i++ {
+		result.Mul(result, base)
+	}
+	return result
+}
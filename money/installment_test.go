@@ -0,0 +1,75 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	total := Money{Amount: 10000, Currency: "BRL"}
+
+	parts, err := total.Split(3, false)
+	require.NoError(t, err)
+	require.Equal(t, []Money{
+		{Amount: 3333, Currency: "BRL"},
+		{Amount: 3333, Currency: "BRL"},
+		{Amount: 3334, Currency: "BRL"},
+	}, parts)
+
+	parts, err = total.Split(3, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(3334), parts[0].Amount)
+	require.Equal(t, int64(3333), parts[2].Amount)
+
+	parts, err = Money{Amount: 5, Currency: "BRL"}.Split(10, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), parts[0].Amount)
+	require.Equal(t, int64(5), parts[9].Amount)
+
+	_, err = total.Split(0, false)
+	require.ErrorIs(t, err, ErrInvalidInstallments)
+}
+
+func TestParseInterest(t *testing.T) {
+	rate, err := ParseInterest("")
+	require.NoError(t, err)
+	require.Equal(t, 0, rate.Sign())
+
+	rate, err = ParseInterest("1.99")
+	require.NoError(t, err)
+	require.Equal(t, "0.0199", rate.FloatString(4))
+
+	for _, value := range []string{"-1", "abc", "1/2", "1e2"} {
+		_, err := ParseInterest(value)
+		require.ErrorIs(t, err, ErrInvalidInterest, value)
+	}
+}
+
+func TestInstallmentTotal(t *testing.T) {
+	principal := Money{Amount: 100000, Currency: "BRL"}
+
+	total, err := InstallmentTotal(principal, 10, new(big.Rat))
+	require.NoError(t, err)
+	require.Equal(t, principal, total)
+
+	rate, err := ParseInterest("2")
+	require.NoError(t, err)
+	// 10 installments of about 111.33 for 1000.00 at 2% a month.
+	total, err = InstallmentTotal(principal, 10, rate)
+	require.NoError(t, err)
+	require.Equal(t, Money{Amount: 111327, Currency: "BRL"}, total)
+
+	_, err = InstallmentTotal(principal, 0, rate)
+	require.ErrorIs(t, err, ErrInvalidInstallments)
+}
+
+func TestDiscount(t *testing.T) {
+	rate, err := ParseInterest("2")
+	require.NoError(t, err)
+
+	m := Money{Amount: 10200, Currency: "BRL"}
+	require.Equal(t, Money{Amount: 10000, Currency: "BRL"}, Discount(m, rate, 1))
+	require.Equal(t, m, Discount(m, rate, 0))
+}
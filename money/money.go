@@ -0,0 +1,207 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("amount is not a valid decimal number")
+	ErrTooManyDecimals  = errors.New("amount has more decimal places than its currency allows")
+	ErrOverflow         = errors.New("amount is out of range")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+)
+
+// Money is an exact amount counted in the minor unit of its currency, so
+// R$ 12,34 is Money{Amount: 1234, Currency: "BRL"}.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New checks the currency and returns amount minor units of it.
+func New(amount int64, currency string) (Money, error) {
+	if !IsCurrency(currency) {
+		return Money{}, UnknownCurrencyError{Code: currency}
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Parse reads a decimal amount written either with a dot or a comma as the
+// decimal separator ("12.34", "12,34"). When both show up, the last one is
+// the decimal separator and the other groups thousands ("1.234,56",
+// "1,234.56"); a separator repeated on its own only groups thousands.
+// Amounts with more decimals than the currency has are rejected rather
+// than rounded.
+func Parse(value string, currency string) (Money, error) {
+	exponent, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	value = strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+") {
+		negative = value[0] == '-'
+		value = value[1:]
+	}
+
+	integer, fraction, err := splitDecimal(value)
+	if err != nil {
+		return Money{}, err
+	}
+	if len(fraction) > exponent {
+		return Money{}, ErrTooManyDecimals
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	digits := strings.TrimLeft(integer+fraction, "0")
+	if digits == "" {
+		return Money{Amount: 0, Currency: currency}, nil
+	}
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrOverflow
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// splitDecimal returns the integer and fraction digits of value with any
+// grouping separators removed.
+func splitDecimal(value string) (string, string, error) {
+	lastDot := strings.LastIndex(value, ".")
+	lastComma := strings.LastIndex(value, ",")
+
+	var decimalSeparator, groupSeparator string
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimalSeparator, groupSeparator = ".", ","
+		if lastComma > lastDot {
+			decimalSeparator, groupSeparator = ",", "."
+		}
+	case lastDot >= 0:
+		decimalSeparator, groupSeparator = ".", ","
+		if strings.Count(value, ".") > 1 {
+			decimalSeparator, groupSeparator = "", "."
+		}
+	case lastComma >= 0:
+		decimalSeparator, groupSeparator = ",", "."
+		if strings.Count(value, ",") > 1 {
+			decimalSeparator, groupSeparator = "", ","
+		}
+	}
+
+	integer, fraction := value, ""
+	if decimalSeparator != "" {
+		index := strings.LastIndex(value, decimalSeparator)
+		integer, fraction = value[:index], value[index+1:]
+		if fraction == "" || !isDigits(fraction) {
+			return "", "", ErrInvalidAmount
+		}
+	}
+
+	if groupSeparator != "" && strings.Contains(integer, groupSeparator) {
+		groups := strings.Split(integer, groupSeparator)
+		if len(groups[0]) == 0 || len(groups[0]) > 3 {
+			return "", "", ErrInvalidAmount
+		}
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return "", "", ErrInvalidAmount
+			}
+		}
+		integer = strings.Join(groups, "")
+	}
+
+	if integer == "" && fraction == "" {
+		return "", "", ErrInvalidAmount
+	}
+	if integer != "" && !isDigits(integer) {
+		return "", "", ErrInvalidAmount
+	}
+	return integer, fraction, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// String formats the amount with a dot as decimal separator and no
+// grouping, which is also the format the API uses: "-1234.56".
+func (m Money) String() string {
+	return m.Format(".", "")
+}
+
+// Format writes the amount with the given separators, e.g. Format(",", ".")
+// gives "1.234,56".
+func (m Money) Format(decimalSeparator, groupSeparator string) string {
+	exponent, err := Exponent(m.Currency)
+	if err != nil {
+		exponent = 0
+	}
+
+	var digits string
+	if m.Amount == math.MinInt64 {
+		digits = strconv.FormatUint(uint64(math.MaxInt64)+1, 10)
+	} else if m.Amount < 0 {
+		digits = strconv.FormatInt(-m.Amount, 10)
+	} else {
+		digits = strconv.FormatInt(m.Amount, 10)
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	integer, fraction := digits[:len(digits)-exponent], digits[len(digits)-exponent:]
+	if groupSeparator != "" {
+		var grouped strings.Builder
+		for i, r := range integer {
+			if i > 0 && (len(integer)-i)%3 == 0 {
+				grouped.WriteString(groupSeparator)
+			}
+			grouped.WriteRune(r)
+		}
+		integer = grouped.String()
+	}
+
+	var formatted strings.Builder
+	if m.Amount < 0 {
+		formatted.WriteByte('-')
+	}
+	formatted.WriteString(integer)
+	if exponent > 0 {
+		formatted.WriteString(decimalSeparator)
+		formatted.WriteString(fraction)
+	}
+	return formatted.String()
+}
+
+// Add returns m + other. Both must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - other. Both must be in the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
@@ -0,0 +1,119 @@
+package money
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		value    string
+		currency string
+		amount   int64
+	}{
+		{"12.34", "BRL", 1234},
+		{"12,34", "BRL", 1234},
+		{"12", "BRL", 1200},
+		{"12.3", "USD", 1230},
+		{"-0,01", "BRL", -1},
+		{"+5", "EUR", 500},
+		{".5", "EUR", 50},
+		{"1.234,56", "BRL", 123456},
+		{"1,234.56", "USD", 123456},
+		{"1,234,567", "USD", 123456700},
+		{"1.234.567", "BRL", 123456700},
+		{" 7 ", "BRL", 700},
+		{"1500", "JPY", 1500},
+		{"1.500", "KWD", 1500},
+		{"0", "BRL", 0},
+		{"92233720368547758.07", "USD", math.MaxInt64},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value+" "+tc.currency, func(t *testing.T) {
+			m, err := Parse(tc.value, tc.currency)
+			require.NoError(t, err)
+			require.Equal(t, Money{Amount: tc.amount, Currency: tc.currency}, m)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		value    string
+		currency string
+		err      error
+	}{
+		{"", "BRL", ErrInvalidAmount},
+		{"-", "BRL", ErrInvalidAmount},
+		{"abc", "BRL", ErrInvalidAmount},
+		{"12.", "BRL", ErrInvalidAmount},
+		{"1e3", "BRL", ErrInvalidAmount},
+		{"12,34.56,78", "BRL", ErrInvalidAmount},
+		{"12.34.5", "BRL", ErrInvalidAmount},
+		{"1,23.45", "USD", ErrInvalidAmount},
+		{"12.345", "BRL", ErrTooManyDecimals},
+		{"1.5", "JPY", ErrTooManyDecimals},
+		{"92233720368547758.08", "USD", ErrOverflow},
+		{"10", "XXX", UnknownCurrencyError{Code: "XXX"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value+" "+tc.currency, func(t *testing.T) {
+			_, err := Parse(tc.value, tc.currency)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	require.Equal(t, "12.34", Money{Amount: 1234, Currency: "BRL"}.String())
+	require.Equal(t, "0.05", Money{Amount: 5, Currency: "BRL"}.String())
+	require.Equal(t, "-0.05", Money{Amount: -5, Currency: "BRL"}.String())
+	require.Equal(t, "1500", Money{Amount: 1500, Currency: "JPY"}.String())
+	require.Equal(t, "1.500", Money{Amount: 1500, Currency: "KWD"}.String())
+	require.Equal(t, "1.234.567,89", Money{Amount: 123456789, Currency: "BRL"}.Format(",", "."))
+	require.Equal(t, "-1,234.00", Money{Amount: -123400, Currency: "USD"}.Format(".", ","))
+	require.Equal(t, "-92233720368547758.08", Money{Amount: math.MinInt64, Currency: "USD"}.String())
+}
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	for _, amount := range []int64{0, 1, -1, 99, 100, 123456789, math.MaxInt64, -math.MaxInt64} {
+		for _, currency := range []string{"BRL", "JPY", "BHD", "CLF"} {
+			m := Money{Amount: amount, Currency: currency}
+			parsed, err := Parse(m.String(), currency)
+			require.NoError(t, err)
+			require.Equal(t, m, parsed)
+
+			parsed, err = Parse(m.Format(",", "."), currency)
+			require.NoError(t, err)
+			require.Equal(t, m, parsed)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	sum, err := Money{Amount: 1050, Currency: "BRL"}.Add(Money{Amount: -50, Currency: "BRL"})
+	require.NoError(t, err)
+	require.Equal(t, Money{Amount: 1000, Currency: "BRL"}, sum)
+
+	_, err = Money{Amount: 1, Currency: "BRL"}.Add(Money{Amount: 1, Currency: "USD"})
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = Money{Amount: math.MaxInt64, Currency: "BRL"}.Add(Money{Amount: 1, Currency: "BRL"})
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Money{Amount: math.MinInt64, Currency: "BRL"}.Sub(Money{Amount: 1, Currency: "BRL"})
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestNew(t *testing.T) {
+	m, err := New(100, "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "1.00", m.String())
+
+	_, err = New(100, "eur")
+	require.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package money
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const rateDateLayout = "2006-01-02"
+
+var ErrInvalidRate = errors.New("exchange rate must be a positive decimal number")
+
+// Rate says that on Date one unit of Base buys Value units of Quote.
+type Rate struct {
+	Base  string
+	Quote string
+	Date  time.Time
+	Value *big.Rat
+}
+
+// Decimal returns the rate as a decimal string exact enough for storage.
+func (rate Rate) Decimal() string {
+	return FormatRate(rate.Value)
+}
+
+// ParseRate reads a positive decimal rate such as "1.0956".
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || rate.Sign() <= 0 || strings.ContainsAny(value, "/eE") {
+		return nil, ErrInvalidRate
+	}
+	return rate, nil
+}
+
+// FormatRate writes a rate with up to 12 decimals and no trailing zeros.
+func FormatRate(rate *big.Rat) string {
+	formatted := rate.FloatString(12)
+	formatted = strings.TrimRight(formatted, "0")
+	return strings.TrimSuffix(formatted, ".")
+}
+
+// Convert turns m into the to currency at rate units of to per unit of
+// m.Currency, rounding half away from zero to the minor unit of to.
+func Convert(m Money, to string, rate *big.Rat) (Money, error) {
+	fromExponent, err := Exponent(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	toExponent, err := Exponent(to)
+	if err != nil {
+		return Money{}, err
+	}
+
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	if toExponent > fromExponent {
+		converted.Mul(converted, powerOfTen(toExponent-fromExponent))
+	} else {
+		converted.Quo(converted, powerOfTen(fromExponent-toExponent))
+	}
+
+	amount := roundHalfAwayFromZero(converted)
+	if !amount.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: amount.Int64(), Currency: to}, nil
+}
+
+func roundHalfAwayFromZero(value *big.Rat) *big.Int {
+	half := big.NewRat(1, 2)
+	if value.Sign() < 0 {
+		half.Neg(half)
+	}
+	shifted := new(big.Rat).Add(value, half)
+	// Quo truncates towards zero, which after adding the half rounds away
+	// from it.
+	return new(big.Int).Quo(shifted.Num(), shifted.Denom())
+}
+
+func powerOfTen(exponent int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil))
+}
+
+// ParseRatesCSV reads rates from a CSV file with a date,base,quote,rate
+// header, dates written as 2006-01-02.
+func ParseRatesCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "base", "quote", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	var rates []Rate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rate, err := newRate(
+			record[columns["base"]],
+			record[columns["quote"]],
+			record[columns["date"]],
+			record[columns["rate"]],
+		)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECBRates reads the euro reference rates published by the European
+// Central Bank, either the daily or the historical eurofxref XML file.
+func ParseECBRates(r io.Reader) ([]Rate, error) {
+	var envelope ecbEnvelope
+	err := xml.NewDecoder(r).Decode(&envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []Rate
+	for _, day := range envelope.Days {
+		for _, cube := range day.Rates {
+			rate, err := newRate("EUR", cube.Currency, day.Time, cube.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", day.Time, cube.Currency, err)
+			}
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+func newRate(base, quote, date, value string) (Rate, error) {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	quote = strings.ToUpper(strings.TrimSpace(quote))
+	if !IsCurrency(base) {
+		return Rate{}, UnknownCurrencyError{Code: base}
+	}
+	if !IsCurrency(quote) {
+		return Rate{}, UnknownCurrencyError{Code: quote}
+	}
+
+	day, err := time.Parse(rateDateLayout, strings.TrimSpace(date))
+	if err != nil {
+		return Rate{}, err
+	}
+
+	rate, err := ParseRate(value)
+	if err != nil {
+		return Rate{}, err
+	}
+	return Rate{Base: base, Quote: quote, Date: day, Value: rate}, nil
+}
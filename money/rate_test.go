@@ -0,0 +1,110 @@
+package money
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		name   string
+		from   Money
+		to     string
+		rate   string
+		amount int64
+	}{
+		{"same exponent", Money{Amount: 10000, Currency: "USD"}, "BRL", "4.9213", 49213},
+		{"rounds half up", Money{Amount: 1, Currency: "USD"}, "BRL", "2.5", 3},
+		{"rounds half away from zero", Money{Amount: -1, Currency: "USD"}, "BRL", "2.5", -3},
+		{"rounds down", Money{Amount: 1, Currency: "USD"}, "BRL", "2.49", 2},
+		{"to fewer decimals", Money{Amount: 1000, Currency: "USD"}, "JPY", "151.37", 1514},
+		{"to more decimals", Money{Amount: 1514, Currency: "JPY"}, "USD", "0.0066", 999},
+		{"to three decimals", Money{Amount: 100, Currency: "EUR"}, "KWD", "0.3351", 335},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, err := ParseRate(tc.rate)
+			require.NoError(t, err)
+
+			converted, err := Convert(tc.from, tc.to, rate)
+			require.NoError(t, err)
+			require.Equal(t, Money{Amount: tc.amount, Currency: tc.to}, converted)
+		})
+	}
+
+	_, err := Convert(Money{Amount: 1 << 62, Currency: "USD"}, "BRL", big.NewRat(5, 1))
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate(" 1.0956 ")
+	require.NoError(t, err)
+	require.Equal(t, "1.0956", FormatRate(rate))
+
+	for _, value := range []string{"", "0", "-1.2", "abc", "1/3", "1e3"} {
+		_, err := ParseRate(value)
+		require.ErrorIs(t, err, ErrInvalidRate, value)
+	}
+
+	require.Equal(t, "0.912741876597", FormatRate(new(big.Rat).Inv(big.NewRat(10956, 10000))))
+	require.Equal(t, "5", FormatRate(big.NewRat(5, 1)))
+}
+
+func TestParseRatesCSV(t *testing.T) {
+	rates, err := ParseRatesCSV(strings.NewReader("date,base,quote,rate\n2024-01-02,usd,BRL,4.8918\n2024-01-03, EUR, USD, 1.0919\n"))
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+
+	require.Equal(t, "USD", rates[0].Base)
+	require.Equal(t, "BRL", rates[0].Quote)
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), rates[0].Date)
+	require.Equal(t, "4.8918", rates[0].Decimal())
+	require.Equal(t, "EUR", rates[1].Base)
+
+	_, err = ParseRatesCSV(strings.NewReader("date,base,rate\n2024-01-02,USD,4.8918\n"))
+	require.Error(t, err)
+
+	_, err = ParseRatesCSV(strings.NewReader("date,base,quote,rate\n2024-01-02,USD,XXX,4.8918\n"))
+	require.ErrorIs(t, err, UnknownCurrencyError{Code: "XXX"})
+
+	_, err = ParseRatesCSV(strings.NewReader("date,base,quote,rate\n02/01/2024,USD,BRL,4.8918\n"))
+	require.Error(t, err)
+}
+
+func TestParseECBRates(t *testing.T) {
+	const daily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time="2024-01-03">
+			<Cube currency="USD" rate="1.0919"/>
+			<Cube currency="BRL" rate="5.3716"/>
+		</Cube>
+		<Cube time="2024-01-02">
+			<Cube currency="USD" rate="1.0956"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	rates, err := ParseECBRates(strings.NewReader(daily))
+	require.NoError(t, err)
+	require.Len(t, rates, 3)
+
+	require.Equal(t, "EUR", rates[0].Base)
+	require.Equal(t, "USD", rates[0].Quote)
+	require.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), rates[0].Date)
+	require.Equal(t, "1.0919", rates[0].Decimal())
+	require.Equal(t, "BRL", rates[1].Quote)
+	require.Equal(t, "1.0956", rates[2].Decimal())
+
+	_, err = ParseECBRates(strings.NewReader("not xml"))
+	require.Error(t, err)
+}
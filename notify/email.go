@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/GustavoNoronha0/gofinance-backend/mail"
+)
+
+// EmailChannel mails notifications to the user's address.
+type EmailChannel struct {
+	mailer mail.Mailer
+}
+
+func NewEmailChannel(mailer mail.Mailer) *EmailChannel {
+	return &EmailChannel{mailer: mailer}
+}
+
+func (channel *EmailChannel) Name() string {
+	return "email"
+}
+
+func (channel *EmailChannel) Deliver(ctx context.Context, to Recipient, notification Notification) error {
+	if to.Email == "" {
+		return nil
+	}
+	return channel.mailer.Send(ctx, mail.Message{
+		To:      []string{to.Email},
+		Subject: notification.Title,
+		Body:    notification.Body,
+	})
+}
@@ -0,0 +1,57 @@
+// Package notify delivers notifications to users outside the app. The
+// in-app channel is the notifications table itself; the channels here send
+// a copy of what was stored there.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Notification struct {
+	ID        int64           `json:"id"`
+	Kind      string          `json:"kind"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type Recipient struct {
+	UserID   int32  `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type Channel interface {
+	Name() string
+	Deliver(ctx context.Context, to Recipient, notification Notification) error
+}
+
+// Dispatcher sends each notification through every configured channel.
+type Dispatcher struct {
+	channels []Channel
+}
+
+func NewDispatcher(channels ...Channel) *Dispatcher {
+	return &Dispatcher{channels: channels}
+}
+
+// Deliver tries every channel even when some fail, so a webhook that is
+// down doesn't keep emails from going out.
+func (dispatcher *Dispatcher) Deliver(ctx context.Context, to Recipient, notification Notification) error {
+	var failures []string
+	for _, channel := range dispatcher.channels {
+		err := channel.Deliver(ctx, to, notification)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", channel.Name(), err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("cannot deliver notification %d: %s", notification.ID, strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/mail"
+	"github.com/stretchr/testify/require"
+)
+
+func randomNotification() Notification {
+	return Notification{
+		ID:        7,
+		Kind:      "budget_threshold",
+		Title:     "Mercado reached 80% of its budget",
+		Body:      "You spent 400.00 of 500.00 BRL on Mercado in 2024-03.",
+		Data:      json.RawMessage(`{"threshold":80}`),
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestEmailChannel(t *testing.T) {
+	mailer := mail.NewMemoryMailer()
+	channel := NewEmailChannel(mailer)
+	notification := randomNotification()
+
+	err := channel.Deliver(context.Background(), Recipient{UserID: 1, Email: "user@example.com"}, notification)
+	require.NoError(t, err)
+	err = channel.Deliver(context.Background(), Recipient{UserID: 2}, notification)
+	require.NoError(t, err)
+
+	messages := mailer.Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, []string{"user@example.com"}, messages[0].To)
+	require.Equal(t, notification.Title, messages[0].Subject)
+	require.Equal(t, notification.Body, messages[0].Body)
+}
+
+func TestWebhookChannel(t *testing.T) {
+	secret := "s3cret"
+	var received webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, Sign([]byte(secret), body), r.Header.Get(SignatureHeader))
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notification := randomNotification()
+	err := NewWebhookChannel(server.URL, secret).Deliver(context.Background(), Recipient{UserID: 1}, notification)
+	require.NoError(t, err)
+	require.Equal(t, notification.ID, received.Notification.ID)
+	require.Equal(t, int32(1), received.Recipient.UserID)
+}
+
+func TestWebhookChannelFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewWebhookChannel(server.URL, "").Deliver(context.Background(), Recipient{UserID: 1}, randomNotification())
+	require.Error(t, err)
+}
+
+type failingChannel struct{}
+
+func (failingChannel) Name() string { return "failing" }
+
+func (failingChannel) Deliver(ctx context.Context, to Recipient, notification Notification) error {
+	return errors.New("down")
+}
+
+func TestDispatcherTriesEveryChannel(t *testing.T) {
+	mailer := mail.NewMemoryMailer()
+	dispatcher := NewDispatcher(failingChannel{}, NewEmailChannel(mailer))
+
+	err := dispatcher.Deliver(context.Background(), Recipient{UserID: 1, Email: "user@example.com"}, randomNotification())
+	require.ErrorContains(t, err, "failing: down")
+	require.Len(t, mailer.Messages(), 1)
+}
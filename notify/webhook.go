@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Gofinance-Signature"
+
+	webhookTimeout = 10 * time.Second
+)
+
+type webhookPayload struct {
+	Recipient    Recipient    `json:"recipient"`
+	Notification Notification `json:"notification"`
+}
+
+// WebhookChannel posts notifications as JSON to an outside URL. When a
+// secret is set the body is signed with HMAC-SHA256, hex encoded in the
+// X-Gofinance-Signature header, so receivers can check where it came from.
+type WebhookChannel struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+func NewWebhookChannel(url, secret string) *WebhookChannel {
+	return &WebhookChannel{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (channel *WebhookChannel) Name() string {
+	return "webhook"
+}
+
+func (channel *WebhookChannel) Deliver(ctx context.Context, to Recipient, notification Notification) error {
+	body, err := json.Marshal(webhookPayload{Recipient: to, Notification: notification})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(channel.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(channel.secret, body))
+	}
+
+	rsp, err := channel.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", rsp.Status)
+	}
+	return nil
+}
+
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,263 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrInvalidIDToken = errors.New("id token is invalid")
+	ErrUnknownKeyID   = errors.New("id token was signed with an unknown key")
+)
+
+// signingMethods are the algorithms accepted on ID tokens. HS256 is left
+// out on purpose: it would mean the provider signs with our client secret.
+var signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}
+
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Metadata is the subset of the provider discovery document we use.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to one OpenID Connect provider. The provider keys are
+// cached and fetched again when a token shows up with an unknown kid.
+type Provider struct {
+	config   Config
+	metadata Metadata
+	client   *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// keysRefreshInterval stops tokens with made up kids from making us hit the
+// provider JWKS endpoint on every request.
+const keysRefreshInterval = time.Minute
+
+// Discover loads the provider metadata from the issuer's
+// .well-known/openid-configuration document.
+func Discover(ctx context.Context, config Config, client *http.Client) (*Provider, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	wellKnown := strings.TrimSuffix(config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var metadata Metadata
+	err := getJSON(ctx, client, wellKnown, &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("cannot discover provider: %w", err)
+	}
+
+	if metadata.Issuer != config.IssuerURL {
+		return nil, fmt.Errorf("provider issuer %q doesn't match %q", metadata.Issuer, config.IssuerURL)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("provider metadata is incomplete")
+	}
+
+	return &Provider{
+		config:   config,
+		metadata: metadata,
+		client:   client,
+		keys:     map[string]interface{}{},
+	}, nil
+}
+
+func (provider *Provider) Metadata() Metadata {
+	return provider.metadata
+}
+
+// AuthCodeURL builds the URL the user is sent to in order to log in.
+func (provider *Provider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	scopes := append([]string{"openid"}, provider.config.Scopes...)
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.config.ClientID},
+		"redirect_uri":          {provider.config.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(provider.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return provider.metadata.AuthorizationEndpoint + separator + query.Encode()
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Exchange trades the authorization code for the provider tokens.
+func (provider *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*TokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.config.RedirectURL},
+		"client_id":     {provider.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if provider.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(provider.config.ClientID), url.QueryEscape(provider.config.ClientSecret))
+	}
+
+	rsp, err := provider.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(rsp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint answered %s: %s", rsp.Status, body)
+	}
+
+	var token TokenResponse
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token endpoint didn't return an id_token")
+	}
+	return &token, nil
+}
+
+type IDTokenClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+	Nonce             string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// VerifyIDToken checks the signature against the provider keys and the
+// issuer, audience, expiry and nonce of the token.
+func (provider *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	claims := &IDTokenClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(signingMethods))
+	token, err := parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return provider.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid ||
+		!claims.VerifyIssuer(provider.metadata.Issuer, true) ||
+		!claims.VerifyAudience(provider.config.ClientID, true) ||
+		!claims.VerifyExpiresAt(time.Now(), true) ||
+		claims.Subject == "" ||
+		claims.Nonce != nonce {
+		return nil, ErrInvalidIDToken
+	}
+	return claims, nil
+}
+
+func (provider *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if key, ok := provider.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(provider.fetchedAt) < keysRefreshInterval {
+		return nil, ErrUnknownKeyID
+	}
+
+	var set util.JSONWebKeySet
+	err := getJSON(ctx, provider.client, provider.metadata.JWKSURI, &set)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch provider keys: %w", err)
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = public
+	}
+	provider.keys = keys
+	provider.fetchedAt = time.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	return key, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, value interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", url, rsp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(rsp.Body, 1<<20)).Decode(value)
+}
+
+// CodeChallenge derives the S256 PKCE challenge sent with the
+// authorization request from the verifier kept on our side.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
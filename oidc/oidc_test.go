@@ -0,0 +1,266 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GustavoNoronha0/gofinance-backend/util"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testClientID     = "gofinance"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "http://localhost:3000/oidc/callback"
+)
+
+// testProvider is a minimal OpenID Connect provider: it serves discovery,
+// JWKS and a token endpoint that checks PKCE, and hands out codes for
+// whatever identity the test asks for.
+type testProvider struct {
+	server *httptest.Server
+
+	mu     sync.Mutex
+	kid    string
+	key    *rsa.PrivateKey
+	codes  map[string]testGrant
+	issuer string
+}
+
+type testGrant struct {
+	challenge string
+	claims    IDTokenClaims
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	provider := &testProvider{codes: map[string]testGrant{}}
+	provider.rotateKey(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Metadata{
+			Issuer:                provider.issuer,
+			AuthorizationEndpoint: provider.issuer + "/authorize",
+			TokenEndpoint:         provider.issuer + "/token",
+			JWKSURI:               provider.issuer + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		provider.mu.Lock()
+		defer provider.mu.Unlock()
+		json.NewEncoder(w).Encode(util.JSONWebKeySet{Keys: []util.JSONWebKey{{
+			KeyID:     provider.kid,
+			KeyType:   "RSA",
+			Algorithm: "RS256",
+			Use:       "sig",
+			N:         base64.RawURLEncoding.EncodeToString(provider.key.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(provider.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", provider.token)
+
+	provider.server = httptest.NewServer(mux)
+	provider.issuer = provider.server.URL
+	t.Cleanup(provider.server.Close)
+	return provider
+}
+
+func (provider *testProvider) rotateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	provider.key = key
+	provider.kid = util.RandomString(8)
+}
+
+// authorize stands in for the user logging in at the provider and being
+// redirected back with a code.
+func (provider *testProvider) authorize(t *testing.T, authURL string, claims IDTokenClaims) (code, state string) {
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.Equal(t, testClientID, query.Get("client_id"))
+
+	claims.Issuer = provider.issuer
+	claims.Audience = jwt.ClaimStrings{query.Get("client_id")}
+	claims.Nonce = query.Get("nonce")
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+	}
+
+	code = util.RandomString(16)
+	provider.mu.Lock()
+	provider.codes[code] = testGrant{challenge: query.Get("code_challenge"), claims: claims}
+	provider.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func (provider *testProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != testClientID || clientSecret != testClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	grant, ok := provider.codes[r.PostFormValue("code")]
+	delete(provider.codes, r.PostFormValue("code"))
+	if !ok || CodeChallenge(r.PostFormValue("code_verifier")) != grant.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, grant.claims)
+	token.Header["kid"] = provider.kid
+	idToken, err := token.SignedString(provider.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access", TokenType: "Bearer", IDToken: idToken})
+}
+
+func discoverTestProvider(t *testing.T, provider *testProvider) *Provider {
+	client, err := Discover(context.Background(), Config{
+		IssuerURL:    provider.issuer,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"email", "profile"},
+	}, nil)
+	require.NoError(t, err)
+	return client
+}
+
+func testIdentity() IDTokenClaims {
+	return IDTokenClaims{
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "user-1",
+		},
+	}
+}
+
+func TestLoginFlow(t *testing.T) {
+	provider := newTestProvider(t)
+	client := discoverTestProvider(t, provider)
+
+	verifier := util.RandomString(43)
+	authURL := client.AuthCodeURL("state-1", "nonce-1", CodeChallenge(verifier))
+	code, state := provider.authorize(t, authURL, testIdentity())
+	require.Equal(t, "state-1", state)
+
+	token, err := client.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+
+	claims, err := client.VerifyIDToken(context.Background(), token.IDToken, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+	require.Equal(t, "jane@example.com", claims.Email)
+	require.True(t, claims.EmailVerified)
+}
+
+func TestExchangeWithWrongVerifier(t *testing.T) {
+	provider := newTestProvider(t)
+	client := discoverTestProvider(t, provider)
+
+	authURL := client.AuthCodeURL("state", "nonce", CodeChallenge(util.RandomString(43)))
+	code, _ := provider.authorize(t, authURL, testIdentity())
+
+	_, err := client.Exchange(context.Background(), code, util.RandomString(43))
+	require.Error(t, err)
+}
+
+func TestVerifyIDTokenRejectsWrongNonceAndExpiredTokens(t *testing.T) {
+	provider := newTestProvider(t)
+	client := discoverTestProvider(t, provider)
+
+	verifier := util.RandomString(43)
+	code, _ := provider.authorize(t, client.AuthCodeURL("state", "nonce", CodeChallenge(verifier)), testIdentity())
+	token, err := client.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+
+	_, err = client.VerifyIDToken(context.Background(), token.IDToken, "another-nonce")
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+
+	expired := testIdentity()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	code, _ = provider.authorize(t, client.AuthCodeURL("state", "nonce", CodeChallenge(verifier)), expired)
+	token, err = client.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+
+	_, err = client.VerifyIDToken(context.Background(), token.IDToken, "nonce")
+	require.Error(t, err)
+}
+
+func TestVerifyIDTokenRejectsOtherAudience(t *testing.T) {
+	provider := newTestProvider(t)
+	client := discoverTestProvider(t, provider)
+
+	provider.mu.Lock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, IDTokenClaims{
+		Nonce: "nonce",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    provider.issuer,
+			Subject:   "user-1",
+			Audience:  jwt.ClaimStrings{"another-client"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	token.Header["kid"] = provider.kid
+	idToken, err := token.SignedString(provider.key)
+	provider.mu.Unlock()
+	require.NoError(t, err)
+
+	_, err = client.VerifyIDToken(context.Background(), idToken, "nonce")
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestVerifyIDTokenAfterKeyRotation(t *testing.T) {
+	provider := newTestProvider(t)
+	client := discoverTestProvider(t, provider)
+	verifier := util.RandomString(43)
+
+	code, _ := provider.authorize(t, client.AuthCodeURL("state", "nonce", CodeChallenge(verifier)), testIdentity())
+	token, err := client.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+	_, err = client.VerifyIDToken(context.Background(), token.IDToken, "nonce")
+	require.NoError(t, err)
+
+	provider.rotateKey(t)
+	code, _ = provider.authorize(t, client.AuthCodeURL("state", "nonce", CodeChallenge(verifier)), testIdentity())
+	token, err = client.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+
+	_, err = client.VerifyIDToken(context.Background(), token.IDToken, "nonce")
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+
+	client.fetchedAt = time.Time{}
+	_, err = client.VerifyIDToken(context.Background(), token.IDToken, "nonce")
+	require.NoError(t, err)
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.issuer = "https://accounts.example.com"
+
+	_, err := Discover(context.Background(), Config{IssuerURL: provider.server.URL}, nil)
+	require.ErrorContains(t, err, "doesn't match")
+}
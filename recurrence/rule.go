@@ -0,0 +1,327 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules
+// used for recurring transactions: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY),
+// INTERVAL, BYMONTHDAY, UNTIL and COUNT. Occurrences are calendar dates.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+const (
+	untilLayout = "20060102"
+
+	// maxEmptyPeriods stops rules that can never produce another date,
+	// such as the 31st of every other February.
+	maxEmptyPeriods = 1000
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+type Rule struct {
+	Frequency  Frequency
+	Interval   int
+	ByMonthDay []int
+	// Until is the last date the series may produce, inclusive.
+	Until time.Time
+	// Count limits the series to that many occurrences from its start.
+	Count int
+}
+
+func invalidRule(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+}
+
+// Parse reads a rule such as "FREQ=MONTHLY;BYMONTHDAY=5;COUNT=12". A
+// leading "RRULE:" is accepted.
+func Parse(value string) (Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	rule := Rule{Interval: 1}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(value, ";") {
+		name, partValue, ok := strings.Cut(part, "=")
+		if !ok || partValue == "" {
+			return Rule{}, invalidRule("%q is not NAME=VALUE", part)
+		}
+		name = strings.ToUpper(name)
+		if seen[name] {
+			return Rule{}, invalidRule("%s is repeated", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Frequency = Frequency(strings.ToUpper(partValue))
+			switch rule.Frequency {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				return Rule{}, invalidRule("unsupported FREQ %s", partValue)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(partValue)
+			if err != nil || rule.Interval < 1 {
+				return Rule{}, invalidRule("INTERVAL must be a positive number")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(partValue)
+			if err != nil || rule.Count < 1 {
+				return Rule{}, invalidRule("COUNT must be a positive number")
+			}
+		case "UNTIL":
+			rule.Until, err = parseUntil(partValue)
+			if err != nil {
+				return Rule{}, err
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(partValue, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return Rule{}, invalidRule("BYMONTHDAY must be between 1 and 31 or -31 and -1")
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		default:
+			return Rule{}, invalidRule("%s is not supported", name)
+		}
+	}
+
+	return rule, rule.Validate()
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if len(value) > len(untilLayout) {
+		// Date-times are cut to their date, occurrences have no time.
+		if value[len(untilLayout)] != 'T' {
+			return time.Time{}, invalidRule("UNTIL must be a date like 20241231")
+		}
+		value = value[:len(untilLayout)]
+	}
+	until, err := time.Parse(untilLayout, value)
+	if err != nil {
+		return time.Time{}, invalidRule("UNTIL must be a date like 20241231")
+	}
+	return until, nil
+}
+
+func (rule Rule) Validate() error {
+	switch rule.Frequency {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return invalidRule("FREQ is required")
+	default:
+		return invalidRule("unsupported FREQ %s", rule.Frequency)
+	}
+	if rule.Interval < 1 {
+		return invalidRule("INTERVAL must be a positive number")
+	}
+	if rule.Count < 0 {
+		return invalidRule("COUNT must be a positive number")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return invalidRule("COUNT and UNTIL can't be used together")
+	}
+	if rule.Frequency == Weekly && len(rule.ByMonthDay) > 0 {
+		return invalidRule("BYMONTHDAY can't be used with FREQ=WEEKLY")
+	}
+	return nil
+}
+
+// String writes the rule back in RFC 5545 form.
+func (rule Rule) String() string {
+	parts := []string{"FREQ=" + string(rule.Frequency)}
+	if rule.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(rule.Interval))
+	}
+	if len(rule.ByMonthDay) > 0 {
+		days := make([]string, 0, len(rule.ByMonthDay))
+		for _, day := range rule.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if !rule.Until.IsZero() {
+		parts = append(parts, "UNTIL="+rule.Until.Format(untilLayout))
+	}
+	if rule.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(rule.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Iterator walks the occurrences of a rule in order.
+type Iterator struct {
+	rule    Rule
+	start   time.Time
+	period  int
+	pending []time.Time
+	emitted int
+	done    bool
+}
+
+// Iterate returns the occurrences of rule starting at start, which is the
+// first date of the series (DTSTART). Only dates on or after start are
+// produced and COUNT counts from there.
+func (rule Rule) Iterate(start time.Time) *Iterator {
+	return &Iterator{rule: rule, start: Date(start)}
+}
+
+// Next returns the next occurrence, or false once the series is over.
+func (it *Iterator) Next() (time.Time, bool) {
+	for !it.done {
+		if len(it.pending) == 0 {
+			it.fill()
+			continue
+		}
+
+		next := it.pending[0]
+		it.pending = it.pending[1:]
+		if !it.rule.Until.IsZero() && next.After(it.rule.Until) {
+			it.done = true
+			break
+		}
+		it.emitted++
+		if it.rule.Count > 0 && it.emitted >= it.rule.Count {
+			it.done = true
+		}
+		return next, true
+	}
+	return time.Time{}, false
+}
+
+// fill loads the dates of the next periods until one has any.
+func (it *Iterator) fill() {
+	for empty := 0; empty < maxEmptyPeriods; empty++ {
+		dates := it.periodDates(it.period)
+		it.period++
+		for _, date := range dates {
+			if !date.Before(it.start) {
+				it.pending = append(it.pending, date)
+			}
+		}
+		if len(it.pending) > 0 {
+			return
+		}
+	}
+	it.done = true
+}
+
+func (it *Iterator) periodDates(period int) []time.Time {
+	step := period * it.rule.Interval
+	switch it.rule.Frequency {
+	case Daily:
+		date := it.start.AddDate(0, 0, step)
+		if len(it.rule.ByMonthDay) > 0 && !matchesMonthDay(date, it.rule.ByMonthDay) {
+			return nil
+		}
+		return []time.Time{date}
+	case Weekly:
+		return []time.Time{it.start.AddDate(0, 0, 7*step)}
+	case Monthly:
+		first := time.Date(it.start.Year(), it.start.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		return monthDates(first.Year(), first.Month(), it.monthDays())
+	case Yearly:
+		return monthDates(it.start.Year()+step, it.start.Month(), it.monthDays())
+	}
+	return nil
+}
+
+func (it *Iterator) monthDays() []int {
+	if len(it.rule.ByMonthDay) > 0 {
+		return it.rule.ByMonthDay
+	}
+	return []int{it.start.Day()}
+}
+
+// monthDates resolves month days, negative ones counting from the end,
+// and leaves out days the month doesn't have.
+func monthDates(year int, month time.Month, monthDays []int) []time.Time {
+	length := daysIn(year, month)
+	var dates []time.Time
+	seen := map[int]bool{}
+	for _, monthDay := range monthDays {
+		day := monthDay
+		if day < 0 {
+			day = length + day + 1
+		}
+		if day < 1 || day > length || seen[day] {
+			continue
+		}
+		seen[day] = true
+		dates = append(dates, time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+func matchesMonthDay(date time.Time, monthDays []int) bool {
+	length := daysIn(date.Year(), date.Month())
+	for _, monthDay := range monthDays {
+		if monthDay == date.Day() || length+monthDay+1 == date.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Date drops the time of day, keeping the calendar date.
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Between returns the occurrences from start that fall between from and
+// to, both inclusive.
+func (rule Rule) Between(start, from, to time.Time) []time.Time {
+	from, to = Date(from), Date(to)
+	var dates []time.Time
+	it := rule.Iterate(start)
+	for {
+		date, ok := it.Next()
+		if !ok || date.After(to) {
+			return dates
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+}
+
+// After returns the first occurrence from start that falls after date.
+func (rule Rule) After(start, date time.Time) (time.Time, bool) {
+	date = Date(date)
+	it := rule.Iterate(start)
+	for {
+		next, ok := it.Next()
+		if !ok || next.After(date) {
+			return next, ok
+		}
+	}
+}
+
+// Includes reports whether date is an occurrence of the series.
+func (rule Rule) Includes(start, date time.Time) bool {
+	return len(rule.Between(start, date, date)) == 1
+}
+
+// CountBefore returns how many occurrences the series has before date.
+func (rule Rule) CountBefore(start, date time.Time) int {
+	return len(rule.Between(start, start, Date(date).AddDate(0, 0, -1)))
+}
@@ -0,0 +1,113 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func dates(values ...string) []time.Time {
+	result := make([]time.Time, 0, len(values))
+	for _, value := range values {
+		result = append(result, date(value))
+	}
+	return result
+}
+
+func TestParse(t *testing.T) {
+	rule, err := Parse("RRULE:FREQ=monthly;INTERVAL=2;BYMONTHDAY=1,-1;UNTIL=20241231T235959Z")
+	require.NoError(t, err)
+	require.Equal(t, Rule{
+		Frequency:  Monthly,
+		Interval:   2,
+		ByMonthDay: []int{1, -1},
+		Until:      date("2024-12-31"),
+	}, rule)
+	require.Equal(t, "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=1,-1;UNTIL=20241231", rule.String())
+
+	rule, err = Parse("FREQ=WEEKLY;COUNT=3")
+	require.NoError(t, err)
+	require.Equal(t, "FREQ=WEEKLY;COUNT=3", rule.String())
+
+	invalid := []string{
+		"",
+		"COUNT=3",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=WEEKLY;BYDAY=MO",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;UNTIL=2024-01-01",
+	}
+	for _, value := range invalid {
+		_, err := Parse(value)
+		require.ErrorIs(t, err, ErrInvalidRule, value)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rule  string
+		start string
+		to    string
+		want  []time.Time
+	}{
+		{"daily", "FREQ=DAILY;INTERVAL=3", "2024-01-30", "2024-02-08", dates("2024-01-30", "2024-02-02", "2024-02-05", "2024-02-08")},
+		{"weekly with count", "FREQ=WEEKLY;COUNT=3", "2024-01-01", "2024-12-31", dates("2024-01-01", "2024-01-08", "2024-01-15")},
+		{"monthly skips short months", "FREQ=MONTHLY", "2024-01-31", "2024-05-31", dates("2024-01-31", "2024-03-31", "2024-05-31")},
+		{"monthly last day", "FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-15", "2024-04-30", dates("2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30")},
+		{"monthly several days", "FREQ=MONTHLY;BYMONTHDAY=20,5", "2024-01-10", "2024-02-29", dates("2024-01-20", "2024-02-05", "2024-02-20")},
+		{"monthly until", "FREQ=MONTHLY;INTERVAL=2;UNTIL=20240601", "2024-01-05", "2024-12-31", dates("2024-01-05", "2024-03-05", "2024-05-05")},
+		{"yearly leap day", "FREQ=YEARLY;COUNT=2", "2024-02-29", "2040-12-31", dates("2024-02-29", "2028-02-29")},
+		{"daily by month day", "FREQ=DAILY;BYMONTHDAY=1", "2024-01-15", "2024-03-31", dates("2024-02-01", "2024-03-01")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := Parse(tc.rule)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, rule.Between(date(tc.start), date(tc.start), date(tc.to)))
+		})
+	}
+}
+
+func TestNeverEnds(t *testing.T) {
+	rule, err := Parse("FREQ=YEARLY;BYMONTHDAY=30")
+	require.NoError(t, err)
+
+	_, ok := rule.Iterate(date("2024-02-01")).Next()
+	require.False(t, ok)
+}
+
+func TestAfterAndCountBefore(t *testing.T) {
+	rule, err := Parse("FREQ=MONTHLY;BYMONTHDAY=10;COUNT=4")
+	require.NoError(t, err)
+	start := date("2024-01-10")
+
+	next, ok := rule.After(start, date("2024-02-10"))
+	require.True(t, ok)
+	require.Equal(t, date("2024-03-10"), next)
+
+	_, ok = rule.After(start, date("2024-04-10"))
+	require.False(t, ok)
+
+	require.Equal(t, 0, rule.CountBefore(start, start))
+	require.Equal(t, 2, rule.CountBefore(start, date("2024-03-10")))
+	require.True(t, rule.Includes(start, date("2024-04-10")))
+	require.False(t, rule.Includes(start, date("2024-05-10")))
+	require.False(t, rule.Includes(start, date("2024-02-11")))
+}
@@ -0,0 +1,52 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps counters in process memory. It is fine for a single
+// instance and for tests; counters are lost on restart.
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempt
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: map[string]Attempt{}}
+}
+
+func (store *MemoryStore) Get(ctx context.Context, key string) (Attempt, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.attempts[key], nil
+}
+
+func (store *MemoryStore) Fail(ctx context.Context, key string, resetBefore time.Time) (Attempt, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	attempt := store.attempts[key]
+	if attempt.LastFailureAt.Before(resetBefore) {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = time.Now()
+	store.attempts[key] = attempt
+
+	for other, old := range store.attempts {
+		if other != key && old.LastFailureAt.Before(resetBefore) {
+			delete(store.attempts, other)
+		}
+	}
+
+	return attempt, nil
+}
+
+func (store *MemoryStore) Reset(ctx context.Context, key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.attempts, key)
+	return nil
+}
@@ -0,0 +1,50 @@
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/GustavoNoronha0/gofinance-backend/db/sqlc"
+)
+
+// PostgresStore shares counters between every instance of the API through
+// the login_attempts table.
+type PostgresStore struct {
+	queries db.Querier
+}
+
+func NewPostgresStore(queries db.Querier) *PostgresStore {
+	return &PostgresStore{queries: queries}
+}
+
+func (store *PostgresStore) Get(ctx context.Context, key string) (Attempt, error) {
+	attempt, err := store.queries.GetLoginAttempt(ctx, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Attempt{}, nil
+		}
+		return Attempt{}, err
+	}
+	return Attempt{Failures: attempt.Failures, LastFailureAt: attempt.LastFailureAt}, nil
+}
+
+func (store *PostgresStore) Fail(ctx context.Context, key string, resetBefore time.Time) (Attempt, error) {
+	attempt, err := store.queries.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Key:         key,
+		ResetBefore: resetBefore,
+	})
+	if err != nil {
+		return Attempt{}, err
+	}
+
+	err = store.queries.DeleteStaleLoginAttempts(ctx, resetBefore)
+	if err != nil {
+		return Attempt{}, err
+	}
+	return Attempt{Failures: attempt.Failures, LastFailureAt: attempt.LastFailureAt}, nil
+}
+
+func (store *PostgresStore) Reset(ctx context.Context, key string) error {
+	return store.queries.DeleteLoginAttempt(ctx, key)
+}
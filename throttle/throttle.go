@@ -0,0 +1,100 @@
+package throttle
+
+import (
+	"context"
+	"time"
+)
+
+// Attempt is the failure history of one key, like a username or an IP.
+type Attempt struct {
+	Failures      int32
+	LastFailureAt time.Time
+}
+
+// AttemptStore keeps failure counters. Failures older than the reset window
+// don't count anymore and the next failure starts again from one.
+type AttemptStore interface {
+	Get(ctx context.Context, key string) (Attempt, error)
+	Fail(ctx context.Context, key string, resetBefore time.Time) (Attempt, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// Limiter applies exponential backoff to keys with recent failures: the
+// first FreeFailures are not delayed, then every failure doubles the wait
+// starting at BaseDelay, up to MaxDelay.
+type Limiter struct {
+	Store        AttemptStore
+	FreeFailures int32
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	ResetAfter   time.Duration
+}
+
+func NewLimiter(store AttemptStore) *Limiter {
+	return &Limiter{
+		Store:        store,
+		FreeFailures: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     15 * time.Minute,
+		ResetAfter:   time.Hour,
+	}
+}
+
+func (limiter *Limiter) delay(attempt Attempt) time.Duration {
+	excess := attempt.Failures - limiter.FreeFailures
+	if excess <= 0 {
+		return 0
+	}
+
+	delay := limiter.BaseDelay
+	for i := int32(1); i < excess && delay < limiter.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > limiter.MaxDelay {
+		delay = limiter.MaxDelay
+	}
+	return delay
+}
+
+// Wait returns how long the caller must wait before trying any of the keys
+// again, or zero when they are all allowed.
+func (limiter *Limiter) Wait(ctx context.Context, keys ...string) (time.Duration, error) {
+	var wait time.Duration
+	now := time.Now()
+
+	for _, key := range keys {
+		attempt, err := limiter.Store.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if attempt.Failures == 0 || now.Sub(attempt.LastFailureAt) > limiter.ResetAfter {
+			continue
+		}
+
+		remaining := attempt.LastFailureAt.Add(limiter.delay(attempt)).Sub(now)
+		if remaining > wait {
+			wait = remaining
+		}
+	}
+
+	return wait, nil
+}
+
+func (limiter *Limiter) Fail(ctx context.Context, keys ...string) error {
+	resetBefore := time.Now().Add(-limiter.ResetAfter)
+	for _, key := range keys {
+		if _, err := limiter.Store.Fail(ctx, key, resetBefore); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (limiter *Limiter) Reset(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := limiter.Store.Reset(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
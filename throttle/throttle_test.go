@@ -0,0 +1,53 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterBackoff(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.Fail(ctx, "user:bob"))
+	}
+	wait, err := limiter.Wait(ctx, "user:bob")
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	require.NoError(t, limiter.Fail(ctx, "user:bob"))
+	wait, err = limiter.Wait(ctx, "user:bob", "ip:127.0.0.1")
+	require.NoError(t, err)
+	require.InDelta(t, time.Second, wait, float64(100*time.Millisecond))
+
+	require.NoError(t, limiter.Fail(ctx, "user:bob"))
+	wait, err = limiter.Wait(ctx, "user:bob")
+	require.NoError(t, err)
+	require.InDelta(t, 2*time.Second, wait, float64(100*time.Millisecond))
+
+	require.NoError(t, limiter.Reset(ctx, "user:bob"))
+	wait, err = limiter.Wait(ctx, "user:bob")
+	require.NoError(t, err)
+	require.Zero(t, wait)
+}
+
+func TestLimiterMaxDelay(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore())
+	require.Equal(t, limiter.MaxDelay, limiter.delay(Attempt{Failures: 100}))
+}
+
+func TestMemoryStoreResetWindow(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	_, err := store.Fail(ctx, "ip:1", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+
+	attempt, err := store.Fail(ctx, "ip:1", time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), attempt.Failures)
+}
@@ -1,51 +1,163 @@
 package util
 
 import (
-	"net/http"
-	"strings"
+	"context"
+	"errors"
+	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrRevokedToken = errors.New("token has been revoked")
+)
+
+const (
+	tokenIssuer = "gofinance"
+
+	PersonalAccessTokenPrefix = "gfp_"
+)
+
+const (
+	RoleUser            = "user"
+	RoleAdmin           = "admin"
+	RoleSupportReadonly = "support-readonly"
 )
 
 type Claims struct {
-	Username string `json:"username"`
+	UserID        int32     `json:"user_id"`
+	Username      string    `json:"username"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"`
+	SessionID     uuid.UUID `json:"sid"`
 	jwt.RegisteredClaims
+
+	// Set only when the request was authenticated with a personal access
+	// token instead of a session JWT.
+	PersonalAccessTokenID int32    `json:"-"`
+	Scopes                []string `json:"-"`
+}
+
+// HasScope reports whether the credentials allow the given scope. Session
+// tokens belong to the user themselves and are allowed everything.
+func (claims *Claims) HasScope(scope string) bool {
+	if claims.PersonalAccessTokenID == 0 {
+		return true
+	}
+	for _, granted := range claims.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the user has one of the given roles.
+func (claims *Claims) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if claims.Role == role {
+			return true
+		}
+	}
+	return false
 }
 
-func ValidateToken(ctx *gin.Context, token string) error {
+// TokenSubject is the part of the user that is copied into access tokens.
+type TokenSubject struct {
+	UserID        int32
+	Username      string
+	EmailVerified bool
+	Role          string
+}
+
+// SessionRevokedFunc reports whether the session a token was issued for
+// has been revoked since.
+type SessionRevokedFunc func(ctx context.Context, sessionID uuid.UUID) (bool, error)
+
+func (manager *KeyManager) CreateToken(subject TokenSubject, sessionID uuid.UUID, duration time.Duration) (string, *Claims, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:        subject.UserID,
+		Username:      subject.Username,
+		EmailVerified: subject.EmailVerified,
+		Role:          subject.Role,
+		SessionID:     sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+		},
+	}
+
+	signedToken, err := manager.Sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signedToken, claims, nil
+}
+
+func (manager *KeyManager) ValidateToken(ctx context.Context, token string, isRevoked SessionRevokedFunc) (*Claims, error) {
 	claims := &Claims{}
-	var jwtSignedKey = []byte("secret_key")
-	tokenParse, err := jwt.ParseWithClaims(token, claims,
-		func(t *jwt.Token) (interface{}, error) {
-			return jwtSignedKey, nil
-		})
+	tokenParse, err := jwt.ParseWithClaims(token, claims, manager.keyFunc)
+	if err != nil {
+		return nil, err
+	}
 
+	if !tokenParse.Valid || !claims.VerifyIssuer(tokenIssuer, true) || len(claims.Audience) > 0 ||
+		claims.UserID == 0 || claims.SessionID == uuid.Nil {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := isRevoked(ctx, claims.SessionID)
 	if err != nil {
-		if err == jwt.ErrSignatureInvalid {
-			ctx.JSON(http.StatusUnauthorized, err)
-			return err
-		}
-		ctx.JSON(http.StatusBadRequest, err)
-		return err
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrRevokedToken
 	}
 
-	if !tokenParse.Valid {
-		ctx.JSON(http.StatusUnauthorized, "Token is invalid")
-		return nil
+	return claims, nil
+}
+
+const mfaAudience = "gofinance-mfa"
+
+// MFAChallengeClaims identify a user that passed the password check but
+// still has to present a second factor. They can't be used as access tokens.
+type MFAChallengeClaims struct {
+	UserID int32 `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+func (manager *KeyManager) CreateMFAChallenge(userID int32, duration time.Duration) (string, *MFAChallengeClaims, error) {
+	now := time.Now()
+	claims := &MFAChallengeClaims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+		},
 	}
 
-	ctx.Next()
-	return nil
+	signedToken, err := manager.Sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signedToken, claims, nil
 }
 
-func GetTokenInHeaderAndVerify(ctx *gin.Context) error {
-	authorizationHeaderKey := ctx.GetHeader("authorization")
-	fields := strings.Fields(authorizationHeaderKey)
-	tokenToValidate := fields[1]
-	errOnValiteToken := ValidateToken(ctx, tokenToValidate)
-	if errOnValiteToken != nil {
-		return errOnValiteToken
+func (manager *KeyManager) ValidateMFAChallenge(token string) (*MFAChallengeClaims, error) {
+	claims := &MFAChallengeClaims{}
+	tokenParse, err := jwt.ParseWithClaims(token, claims, manager.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tokenParse.Valid || !claims.VerifyAudience(mfaAudience, true) || claims.UserID == 0 {
+		return nil, ErrInvalidToken
 	}
-	return nil
+	return claims, nil
 }
@@ -0,0 +1,163 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	DBDriver             string
+	DBSource             string
+	ServerAddress        string
+	AppBaseURL           string
+	TokenKeys            string
+	AccessTokenDuration  time.Duration
+	RefreshTokenDuration time.Duration
+	Mailer               string
+	MailFrom             string
+	MailDir              string
+	SMTPHost             string
+	SMTPPort             int
+	SMTPUsername         string
+	SMTPPassword         string
+	LoginAttemptStore    string
+	LoginMaxFailures     int
+	LoginLockoutDuration time.Duration
+	PasswordMinLength    int
+	PasswordMaxLength    int
+	PasswordBreachedList string
+	DeletionGracePeriod  time.Duration
+	DeletionPurgeEvery   time.Duration
+	RecurringEvery       time.Duration
+	// NotificationChannels lists where notifications go besides the app,
+	// comma separated: email, webhook.
+	NotificationChannels      string
+	NotificationWebhookURL    string
+	NotificationWebhookSecret string
+	OIDCIssuerURL             string
+	OIDCClientID              string
+	OIDCClientSecret          string
+	OIDCRedirectURL           string
+}
+
+func LoadConfig() (Config, error) {
+	config := Config{
+		DBDriver:      os.Getenv("DB_DRIVER"),
+		DBSource:      os.Getenv("DB_SOURCE"),
+		ServerAddress: os.Getenv("SERVER_ADDRESS"),
+		AppBaseURL:    os.Getenv("APP_BASE_URL"),
+		TokenKeys:     os.Getenv("TOKEN_KEYS"),
+		Mailer:        stringFromEnv("MAILER", "file"),
+		MailFrom:      stringFromEnv("MAIL_FROM", "gofinance <no-reply@gofinance.local>"),
+		MailDir:       stringFromEnv("MAIL_DIR", "tmp/mail"),
+		SMTPHost:      os.Getenv("SMTP_HOST"),
+		SMTPUsername:  os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:  os.Getenv("SMTP_PASSWORD"),
+
+		LoginAttemptStore:    stringFromEnv("LOGIN_ATTEMPT_STORE", "postgres"),
+		PasswordBreachedList: os.Getenv("PASSWORD_BREACHED_LIST"),
+
+		NotificationChannels:      os.Getenv("NOTIFICATION_CHANNELS"),
+		NotificationWebhookURL:    os.Getenv("NOTIFICATION_WEBHOOK_URL"),
+		NotificationWebhookSecret: os.Getenv("NOTIFICATION_WEBHOOK_SECRET"),
+
+		OIDCIssuerURL:    os.Getenv("OIDC_ISSUER_URL"),
+		OIDCClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+	}
+
+	var err error
+	config.AccessTokenDuration, err = durationFromEnv("ACCESS_TOKEN_DURATION", 15*time.Minute)
+	if err != nil {
+		return config, err
+	}
+	config.RefreshTokenDuration, err = durationFromEnv("REFRESH_TOKEN_DURATION", 30*24*time.Hour)
+	if err != nil {
+		return config, err
+	}
+
+	config.SMTPPort, err = intFromEnv("SMTP_PORT", 587)
+	if err != nil {
+		return config, err
+	}
+	config.LoginMaxFailures, err = intFromEnv("LOGIN_MAX_FAILURES", 5)
+	if err != nil {
+		return config, err
+	}
+	config.LoginLockoutDuration, err = durationFromEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+	if err != nil {
+		return config, err
+	}
+	config.PasswordMinLength, err = intFromEnv("PASSWORD_MIN_LENGTH", 8)
+	if err != nil {
+		return config, err
+	}
+	config.PasswordMaxLength, err = intFromEnv("PASSWORD_MAX_LENGTH", 128)
+	if err != nil {
+		return config, err
+	}
+	config.DeletionGracePeriod, err = durationFromEnv("DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	if err != nil {
+		return config, err
+	}
+	config.DeletionPurgeEvery, err = positiveDurationFromEnv("DELETION_PURGE_EVERY", time.Hour)
+	if err != nil {
+		return config, err
+	}
+	config.RecurringEvery, err = positiveDurationFromEnv("RECURRING_EVERY", time.Hour)
+	if err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+func stringFromEnv(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func intFromEnv(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return number, nil
+}
+
+func durationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return duration, nil
+}
+
+// positiveDurationFromEnv reads a duration that drives a ticker, which
+// can't run every zero or negative interval.
+func positiveDurationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	duration, err := durationFromEnv(key, fallback)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be positive", key)
+	}
+	return duration, nil
+}
@@ -0,0 +1,270 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no signing key configured")
+	ErrUnknownKeyID = errors.New("token was signed with an unknown key")
+)
+
+// SigningKey is one entry of the key ring. Keys loaded from a public key PEM
+// can only verify tokens; they are kept around while old tokens expire.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	method    jwt.SigningMethod
+	private   interface{}
+	public    interface{}
+}
+
+func (key *SigningKey) CanSign() bool {
+	return key.private != nil
+}
+
+// KeyManager signs tokens with its current key and verifies tokens signed
+// by any key still present in the configuration.
+type KeyManager struct {
+	signer *SigningKey
+	keys   map[string]*SigningKey
+	order  []string
+}
+
+// LoadKeyManager parses a comma separated list of kid:algorithm:path
+// entries. The first entry is the one used to sign new tokens.
+func LoadKeyManager(spec string) (*KeyManager, error) {
+	manager := &KeyManager{keys: map[string]*SigningKey{}}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid key entry %q, expected kid:algorithm:path", entry)
+		}
+
+		data, err := os.ReadFile(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("cannot read key %s: %w", parts[0], err)
+		}
+
+		key, err := NewSigningKey(parts[0], parts[1], data)
+		if err != nil {
+			return nil, err
+		}
+		if err = manager.Add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if manager.signer == nil {
+		return nil, ErrNoSigningKey
+	}
+	return manager, nil
+}
+
+// NewSigningKey builds a key from the raw secret (HS256) or PEM encoded
+// private or public key (RS256, EdDSA).
+func NewSigningKey(id, algorithm string, data []byte) (*SigningKey, error) {
+	key := &SigningKey{ID: id, Algorithm: algorithm}
+	block, _ := pem.Decode(data)
+	isPrivate := block != nil && strings.Contains(block.Type, "PRIVATE KEY")
+
+	var err error
+	switch algorithm {
+	case AlgorithmHS256:
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("key %s: HS256 secret must be at least 32 bytes", id)
+		}
+		key.method = jwt.SigningMethodHS256
+		key.private, key.public = secret, secret
+	case AlgorithmRS256:
+		key.method = jwt.SigningMethodRS256
+		if isPrivate {
+			var private *rsa.PrivateKey
+			private, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err == nil {
+				key.private, key.public = private, &private.PublicKey
+			}
+		} else {
+			key.public, err = jwt.ParseRSAPublicKeyFromPEM(data)
+		}
+	case AlgorithmEdDSA:
+		key.method = jwt.SigningMethodEdDSA
+		if isPrivate {
+			var private interface{}
+			private, err = jwt.ParseEdPrivateKeyFromPEM(data)
+			if err == nil {
+				key.private, key.public = private, private.(ed25519.PrivateKey).Public()
+			}
+		} else {
+			key.public, err = jwt.ParseEdPublicKeyFromPEM(data)
+		}
+	default:
+		return nil, fmt.Errorf("key %s: unsupported algorithm %q", id, algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	return key, nil
+}
+
+func (manager *KeyManager) Add(key *SigningKey) error {
+	if _, exists := manager.keys[key.ID]; exists {
+		return fmt.Errorf("duplicate key id %q", key.ID)
+	}
+
+	manager.keys[key.ID] = key
+	manager.order = append(manager.order, key.ID)
+	if manager.signer == nil && key.CanSign() {
+		manager.signer = key
+	}
+	return nil
+}
+
+func (manager *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	if manager.signer == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(manager.signer.method, claims)
+	token.Header["kid"] = manager.signer.ID
+	return token.SignedString(manager.signer.private)
+}
+
+func (manager *KeyManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := manager.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, ErrInvalidToken
+	}
+	return key.public, nil
+}
+
+type JSONWebKey struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS publishes the public half of every asymmetric key. Symmetric keys
+// are never exposed.
+func (manager *KeyManager) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	for _, kid := range manager.order {
+		key := manager.keys[kid]
+		jwk := JSONWebKey{KeyID: key.ID, Algorithm: key.Algorithm, Use: "sig"}
+
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// PublicKey decodes the key into the type jwt expects for its algorithm:
+// *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func (jwk JSONWebKey) PublicKey() (interface{}, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", jwk.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", jwk.KeyID, err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("key %s: unsupported curve %q", jwk.KeyID, jwk.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", jwk.KeyID, err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", jwk.KeyID, err)
+		}
+		public := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(public.X, public.Y) {
+			return nil, fmt.Errorf("key %s: point is not on the curve", jwk.KeyID)
+		}
+		return public, nil
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return nil, fmt.Errorf("key %s: unsupported curve %q", jwk.KeyID, jwk.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", jwk.KeyID, err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s: invalid Ed25519 key size", jwk.KeyID)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("key %s: unsupported key type %q", jwk.KeyID, jwk.KeyType)
+	}
+}
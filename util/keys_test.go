@@ -0,0 +1,146 @@
+package util
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func notRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func newRandomKey(t *testing.T, id, algorithm string) *SigningKey {
+	var data []byte
+	switch algorithm {
+	case AlgorithmHS256:
+		data = []byte(RandomString(40))
+	case AlgorithmRS256:
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		data = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	case AlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(private)
+		require.NoError(t, err)
+		data = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+
+	key, err := NewSigningKey(id, algorithm, data)
+	require.NoError(t, err)
+	require.True(t, key.CanSign())
+	return key
+}
+
+func TestKeyManagerRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			manager := &KeyManager{keys: map[string]*SigningKey{}}
+			require.NoError(t, manager.Add(newRandomKey(t, "k1", algorithm)))
+
+			sessionID := uuid.New()
+			token, _, err := manager.CreateToken(TokenSubject{UserID: 7, Username: "user", Role: RoleAdmin}, sessionID, time.Minute)
+			require.NoError(t, err)
+
+			claims, err := manager.ValidateToken(context.Background(), token, notRevoked)
+			require.NoError(t, err)
+			require.Equal(t, int32(7), claims.UserID)
+			require.Equal(t, sessionID, claims.SessionID)
+			require.True(t, claims.HasRole(RoleAdmin, RoleSupportReadonly))
+			require.False(t, claims.HasRole(RoleUser))
+		})
+	}
+}
+
+func TestKeyManagerRotation(t *testing.T) {
+	oldKey := newRandomKey(t, "old", AlgorithmHS256)
+	oldManager := &KeyManager{keys: map[string]*SigningKey{}}
+	require.NoError(t, oldManager.Add(oldKey))
+
+	token, _, err := oldManager.CreateToken(TokenSubject{UserID: 1, Username: "user"}, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	rotated := &KeyManager{keys: map[string]*SigningKey{}}
+	require.NoError(t, rotated.Add(newRandomKey(t, "new", AlgorithmEdDSA)))
+	require.NoError(t, rotated.Add(oldKey))
+
+	_, err = rotated.ValidateToken(context.Background(), token, notRevoked)
+	require.NoError(t, err)
+
+	retired := &KeyManager{keys: map[string]*SigningKey{}}
+	require.NoError(t, retired.Add(newRandomKey(t, "new", AlgorithmEdDSA)))
+
+	_, err = retired.ValidateToken(context.Background(), token, notRevoked)
+	require.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestKeyManagerRejectsRevokedSession(t *testing.T) {
+	manager := &KeyManager{keys: map[string]*SigningKey{}}
+	require.NoError(t, manager.Add(newRandomKey(t, "k1", AlgorithmEdDSA)))
+
+	token, _, err := manager.CreateToken(TokenSubject{UserID: 1, Username: "user"}, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	_, err = manager.ValidateToken(context.Background(), token, func(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+		return true, nil
+	})
+	require.ErrorIs(t, err, ErrRevokedToken)
+}
+
+func TestJWKSSkipsSymmetricKeys(t *testing.T) {
+	manager := &KeyManager{keys: map[string]*SigningKey{}}
+	require.NoError(t, manager.Add(newRandomKey(t, "rsa", AlgorithmRS256)))
+	require.NoError(t, manager.Add(newRandomKey(t, "hmac", AlgorithmHS256)))
+	require.NoError(t, manager.Add(newRandomKey(t, "ed", AlgorithmEdDSA)))
+
+	set := manager.JWKS()
+	require.Len(t, set.Keys, 2)
+	require.Equal(t, "RSA", set.Keys[0].KeyType)
+	require.Equal(t, "OKP", set.Keys[1].KeyType)
+	require.NotEmpty(t, set.Keys[1].X)
+}
+
+func TestJWKSPublicKeyRoundTrip(t *testing.T) {
+	manager := &KeyManager{keys: map[string]*SigningKey{}}
+	require.NoError(t, manager.Add(newRandomKey(t, "rsa", AlgorithmRS256)))
+	require.NoError(t, manager.Add(newRandomKey(t, "ed", AlgorithmEdDSA)))
+
+	for _, jwk := range manager.JWKS().Keys {
+		public, err := jwk.PublicKey()
+		require.NoError(t, err)
+		require.Equal(t, manager.keys[jwk.KeyID].public, public)
+	}
+
+	_, err := JSONWebKey{KeyID: "bad", KeyType: "oct"}.PublicKey()
+	require.Error(t, err)
+}
+
+func TestMFAChallengeIsNotAnAccessToken(t *testing.T) {
+	manager := &KeyManager{keys: map[string]*SigningKey{}}
+	require.NoError(t, manager.Add(newRandomKey(t, "k1", AlgorithmEdDSA)))
+
+	challenge, _, err := manager.CreateMFAChallenge(3, time.Minute)
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateMFAChallenge(challenge)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), claims.UserID)
+
+	_, err = manager.ValidateToken(context.Background(), challenge, notRevoked)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	accessToken, _, err := manager.CreateToken(TokenSubject{UserID: 3}, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	_, err = manager.ValidateMFAChallenge(accessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
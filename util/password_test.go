@@ -0,0 +1,63 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPassword(t *testing.T) {
+	password := RandomString(12)
+
+	hashedPassword, err := HashPassword(password)
+	require.NoError(t, err)
+	require.Contains(t, hashedPassword, "$argon2id$v=19$")
+
+	require.NoError(t, CheckPassword(password, hashedPassword))
+	require.ErrorIs(t, CheckPassword(RandomString(12), hashedPassword), ErrPasswordMismatch)
+	require.False(t, PasswordNeedsRehash(hashedPassword))
+
+	hashedPassword2, err := HashPassword(password)
+	require.NoError(t, err)
+	require.NotEqual(t, hashedPassword, hashedPassword2)
+}
+
+func TestCheckLegacyPassword(t *testing.T) {
+	password := RandomString(12)
+	legacyHash, err := bcrypt.GenerateFromPassword(prepareLegacyPassword(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	require.NoError(t, CheckPassword(password, string(legacyHash)))
+	require.ErrorIs(t, CheckPassword(RandomString(12), string(legacyHash)), ErrPasswordMismatch)
+	require.True(t, PasswordNeedsRehash(string(legacyHash)))
+}
+
+func TestPasswordNeedsRehashWithOldParams(t *testing.T) {
+	params := DefaultArgon2Params
+	params.Iterations = 1
+
+	hashedPassword, err := hashArgon2id("secret-password", params)
+	require.NoError(t, err)
+
+	require.NoError(t, CheckPassword("secret-password", hashedPassword))
+	require.True(t, PasswordNeedsRehash(hashedPassword))
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "password123\n" + sha1Hex("qwertyuiop") + ":42\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	policy, err := LoadPasswordPolicy(8, 64, path)
+	require.NoError(t, err)
+
+	require.NoError(t, policy.Validate("correct horse battery"))
+	require.ErrorIs(t, policy.Validate("short"), ErrPasswordTooShort)
+	require.ErrorIs(t, policy.Validate(RandomString(65)), ErrPasswordTooLong)
+	require.ErrorIs(t, policy.Validate("password123"), ErrPasswordBreached)
+	require.ErrorIs(t, policy.Validate("qwertyuiop"), ErrPasswordBreached)
+	require.ErrorIs(t, policy.Validate("someone@email.com", "someone", "someone@email.com"), ErrPasswordPersonal)
+}